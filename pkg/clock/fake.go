@@ -0,0 +1,189 @@
+package clock
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Fake is a Clock whose time only moves when advanced, for tests.
+// The timers due are fired by Advance in deadline order: the AfterFunc
+// functions are called synchronously, the timer and ticker channels get
+// the time they fired at if they have room for it.
+type Fake struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*fakeTimer
+}
+
+// NewFake creates a new Fake clock at now.
+func NewFake(now time.Time) *Fake {
+	c := &Fake{now: now}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now implements Clock.
+func (c *Fake) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Since implements Clock.
+func (c *Fake) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// AfterFunc implements Clock.
+func (c *Fake) AfterFunc(d time.Duration, f func()) Timer {
+	t := &fakeTimer{clock: c, f: f}
+	c.schedule(t, d)
+	return t
+}
+
+// NewTimer implements Clock.
+func (c *Fake) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{clock: c, c: make(chan time.Time, 1)}
+	c.schedule(t, d)
+	return t
+}
+
+// NewTicker implements Clock.
+func (c *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	t := &fakeTimer{clock: c, c: make(chan time.Time, 1), period: d}
+	c.schedule(t, d)
+	return fakeTicker{t}
+}
+
+// After implements Clock.
+func (c *Fake) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C()
+}
+
+// Sleep implements Clock, it returns once the clock is advanced by d.
+func (c *Fake) Sleep(d time.Duration) {
+	<-c.After(d)
+}
+
+// Advance moves the time forward by d, firing the timers due.
+func (c *Fake) Advance(d time.Duration) {
+	c.mu.Lock()
+	target := c.now.Add(d)
+	c.mu.Unlock()
+
+	for {
+		c.mu.Lock()
+		if len(c.waiters) == 0 || c.waiters[0].deadline.After(target) {
+			c.now = target
+			c.mu.Unlock()
+			return
+		}
+		t := c.waiters[0]
+		c.waiters = c.waiters[1:]
+		c.now = t.deadline
+		if t.period > 0 {
+			t.deadline = t.deadline.Add(t.period)
+			c.insert(t)
+		}
+		now := c.now
+		c.mu.Unlock()
+
+		if t.f != nil {
+			t.f()
+		} else {
+			select {
+			case t.c <- now:
+			default:
+			}
+		}
+	}
+}
+
+// Timers returns the number of timers and tickers pending.
+func (c *Fake) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// BlockUntil waits until n timers and tickers are pending, e.g. until the
+// goroutine under test scheduled the timer the test is about to fire.
+func (c *Fake) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.waiters) != n {
+		c.cond.Wait()
+	}
+}
+
+func (c *Fake) schedule(t *fakeTimer, d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t.deadline = c.now.Add(d)
+	c.insert(t)
+}
+
+// insert adds t after the waiters with the same or an earlier deadline, c.mu held.
+func (c *Fake) insert(t *fakeTimer) {
+	i, _ := slices.BinarySearchFunc(c.waiters, t.deadline, func(w *fakeTimer, deadline time.Time) int {
+		if w.deadline.After(deadline) {
+			return 1
+		}
+		return -1
+	})
+	c.waiters = slices.Insert(c.waiters, i, t)
+	c.cond.Broadcast()
+}
+
+// remove removes t from the waiters and reports whether it was pending, c.mu held.
+func (c *Fake) remove(t *fakeTimer) bool {
+	i := slices.Index(c.waiters, t)
+	if i < 0 {
+		return false
+	}
+	c.waiters = slices.Delete(c.waiters, i, i+1)
+	c.cond.Broadcast()
+	return true
+}
+
+// fakeTimer is a timer or, with a period, a ticker of a Fake clock.
+type fakeTimer struct {
+	clock    *Fake
+	deadline time.Time
+	period   time.Duration
+	f        func()
+	c        chan time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.remove(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	pending := t.clock.remove(t)
+	if t.period > 0 {
+		t.period = d
+	}
+	t.deadline = t.clock.now.Add(d)
+	t.clock.insert(t)
+	return pending
+}
+
+type fakeTicker struct{ t *fakeTimer }
+
+func (t fakeTicker) C() <-chan time.Time   { return t.t.c }
+func (t fakeTicker) Stop()                 { t.t.Stop() }
+func (t fakeTicker) Reset(d time.Duration) { t.t.Reset(d) }
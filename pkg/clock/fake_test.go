@@ -0,0 +1,92 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFake_Now(t *testing.T) {
+	c := NewFake(epoch)
+	assert.Equal(t, epoch, c.Now())
+	c.Advance(time.Second)
+	assert.Equal(t, epoch.Add(time.Second), c.Now())
+	assert.Equal(t, time.Second, c.Since(epoch))
+}
+
+func TestFake_AfterFunc(t *testing.T) {
+	c := NewFake(epoch)
+	var fired []string
+	c.AfterFunc(20*time.Millisecond, func() { fired = append(fired, "b") })
+	c.AfterFunc(10*time.Millisecond, func() { fired = append(fired, "a") })
+	stopped := c.AfterFunc(15*time.Millisecond, func() { fired = append(fired, "stopped") })
+	assert.Equal(t, 3, c.Timers())
+
+	assert.True(t, stopped.Stop())
+	assert.False(t, stopped.Stop())
+	c.Advance(19 * time.Millisecond)
+	assert.Equal(t, []string{"a"}, fired)
+	c.Advance(time.Millisecond)
+	assert.Equal(t, []string{"a", "b"}, fired)
+	assert.Equal(t, 0, c.Timers())
+}
+
+func TestFake_AfterFuncSeesItsDeadline(t *testing.T) {
+	c := NewFake(epoch)
+	var at time.Time
+	var timer Timer
+	timer = c.AfterFunc(10*time.Millisecond, func() {
+		at = c.Now()
+		timer.Reset(10 * time.Millisecond)
+	})
+	c.Advance(25 * time.Millisecond)
+	assert.Equal(t, epoch.Add(20*time.Millisecond), at)
+	assert.Equal(t, epoch.Add(25*time.Millisecond), c.Now())
+}
+
+func TestFake_Timer(t *testing.T) {
+	c := NewFake(epoch)
+	timer := c.NewTimer(time.Second)
+	c.Advance(time.Second)
+	select {
+	case at := <-timer.C():
+		assert.Equal(t, epoch.Add(time.Second), at)
+	default:
+		t.Fatal("timer not fired")
+	}
+
+	assert.False(t, timer.Reset(time.Second))
+	assert.True(t, timer.Reset(2*time.Second))
+	c.Advance(time.Second)
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired before its reset deadline")
+	default:
+	}
+}
+
+func TestFake_Ticker(t *testing.T) {
+	c := NewFake(epoch)
+	ticker := c.NewTicker(10 * time.Millisecond)
+	for i := 1; i <= 3; i++ {
+		c.Advance(10 * time.Millisecond)
+		assert.Equal(t, epoch.Add(time.Duration(i)*10*time.Millisecond), <-ticker.C())
+	}
+	ticker.Stop()
+	assert.Equal(t, 0, c.Timers())
+}
+
+func TestFake_Sleep(t *testing.T) {
+	c := NewFake(epoch)
+	done := make(chan struct{})
+	go func() {
+		c.Sleep(time.Minute)
+		close(done)
+	}()
+	c.BlockUntil(1)
+	c.Advance(time.Minute)
+	<-done
+}
@@ -2,6 +2,8 @@ package frames
 
 import (
 	"fmt"
+	"reflect"
+	"sync"
 
 	"github.com/weedge/pipeline-go/pkg"
 )
@@ -17,6 +19,9 @@ type Frame interface {
 type BaseFrame struct {
 	id   uint64
 	name string
+
+	metadataMu sync.Mutex
+	metadata   map[string]any
 }
 
 // NewBaseFrameWithName creates a new BaseFrame.
@@ -54,3 +59,73 @@ func (f *BaseFrame) Name() string {
 func (f *BaseFrame) String() string {
 	return f.name
 }
+
+// SetMetadata sets a metadata value of the frame, e.g. the tracing context
+// propagated with the frame. It does nothing on a nil BaseFrame.
+func (f *BaseFrame) SetMetadata(key string, value any) {
+	if f == nil {
+		return
+	}
+	f.metadataMu.Lock()
+	defer f.metadataMu.Unlock()
+	if f.metadata == nil {
+		f.metadata = make(map[string]any)
+	}
+	f.metadata[key] = value
+}
+
+// Metadata returns a metadata value of the frame.
+func (f *BaseFrame) Metadata(key string) (any, bool) {
+	if f == nil {
+		return nil, false
+	}
+	f.metadataMu.Lock()
+	defer f.metadataMu.Unlock()
+	value, ok := f.metadata[key]
+	return value, ok
+}
+
+var baseFrameType = reflect.TypeOf(&BaseFrame{})
+
+// Base returns the BaseFrame embedded in frame, nil if it has none,
+// e.g. a frame literal like &IdleFrame{} whose embedded frames are unset.
+func Base(frame Frame) *BaseFrame {
+	v := reflect.ValueOf(frame)
+	for {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return nil
+			}
+			if v.Type() == baseFrameType {
+				return v.Interface().(*BaseFrame)
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return nil
+		}
+		embedded := false
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).Anonymous {
+				v, embedded = v.Field(i), true
+				break
+			}
+		}
+		if !embedded {
+			return nil
+		}
+	}
+}
+
+// TypeName returns the name of the type of frame, e.g. TextFrame, the same
+// for all the frames of a type unlike their Name.
+func TypeName(frame Frame) string {
+	t := reflect.TypeOf(frame)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return "nil"
+	}
+	return t.Name()
+}
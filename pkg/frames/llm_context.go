@@ -0,0 +1,157 @@
+package frames
+
+import (
+	"fmt"
+	"sync"
+	"unicode/utf8"
+)
+
+// The roles of the LLM messages.
+const (
+	LLMRoleSystem    = "system"
+	LLMRoleUser      = "user"
+	LLMRoleAssistant = "assistant"
+	LLMRoleTool      = "tool"
+)
+
+// LLMMessage is a role-tagged message of a conversation. The assistant messages
+// may call tools, the results are the tool messages answering the calls by ID.
+type LLMMessage struct {
+	Role       string        `json:"role"`
+	Content    string        `json:"content"`
+	ToolCalls  []LLMToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string        `json:"tool_call_id,omitempty"`
+}
+
+// LLMToolCall is a call of a function tool requested by the LLM.
+type LLMToolCall struct {
+	ID       string          `json:"id"`
+	Type     string          `json:"type"`
+	Function LLMFunctionCall `json:"function"`
+}
+
+// LLMFunctionCall is the function called by a LLMToolCall, with its arguments encoded in JSON.
+type LLMFunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// LLMContext is the message history of a conversation, shared by the processors
+// of a pipeline. It is safe for concurrent use.
+//
+// The history is truncated as messages are added to at most maxMessages messages
+// and maxChars characters of content, dropping the oldest messages first. The
+// system messages are kept, and so is the last message, however long. The tool
+// messages are kept or dropped with the assistant message calling them, so a
+// tool result is never left without its call.
+type LLMContext struct {
+	lock        sync.Mutex
+	messages    []LLMMessage
+	maxMessages int
+	maxChars    int
+}
+
+// NewLLMContext creates a new LLMContext with messages, e.g. a system prompt, and no truncation.
+func NewLLMContext(messages ...LLMMessage) *LLMContext {
+	return &LLMContext{messages: append([]LLMMessage(nil), messages...)}
+}
+
+// WithMaxMessages sets the maximum number of messages kept, 0 for no maximum.
+func (c *LLMContext) WithMaxMessages(maxMessages int) *LLMContext {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.maxMessages = maxMessages
+	c.truncate()
+	return c
+}
+
+// WithMaxChars sets the maximum number of characters of content kept, 0 for no maximum.
+func (c *LLMContext) WithMaxChars(maxChars int) *LLMContext {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.maxChars = maxChars
+	c.truncate()
+	return c
+}
+
+// AddMessage appends a message with role and content, and truncates the history.
+func (c *LLMContext) AddMessage(role, content string) {
+	c.AddMessages(LLMMessage{Role: role, Content: content})
+}
+
+// AddMessages appends messages, and truncates the history.
+func (c *LLMContext) AddMessages(messages ...LLMMessage) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.messages = append(c.messages, messages...)
+	c.truncate()
+}
+
+// SetMessages replaces the history with messages, truncated.
+func (c *LLMContext) SetMessages(messages ...LLMMessage) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.messages = append([]LLMMessage(nil), messages...)
+	c.truncate()
+}
+
+// Messages returns a copy of the history.
+func (c *LLMContext) Messages() []LLMMessage {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]LLMMessage(nil), c.messages...)
+}
+
+// Len returns the number of messages of the history.
+func (c *LLMContext) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.messages)
+}
+
+// truncate drops the oldest messages, but the system messages and the last
+// message, until the limits are met, c.lock held. An assistant message calling
+// tools and the tool messages of its results are kept or dropped together.
+func (c *LLMContext) truncate() {
+	chars := 0
+	for _, m := range c.messages {
+		chars += utf8.RuneCountInString(m.Content)
+	}
+	for i := 0; i < len(c.messages); {
+		if (c.maxMessages <= 0 || len(c.messages) <= c.maxMessages) &&
+			(c.maxChars <= 0 || chars <= c.maxChars) {
+			return
+		}
+		if c.messages[i].Role == LLMRoleSystem {
+			i++
+			continue
+		}
+		end := c.unitEnd(i)
+		if end == len(c.messages) {
+			return
+		}
+		for _, m := range c.messages[i:end] {
+			chars -= utf8.RuneCountInString(m.Content)
+		}
+		c.messages = append(c.messages[:i], c.messages[end:]...)
+	}
+}
+
+// unitEnd returns the end of the messages kept or dropped with the message i:
+// the tool messages following it, if it calls tools or is a tool message.
+func (c *LLMContext) unitEnd(i int) int {
+	m := c.messages[i]
+	end := i + 1
+	if len(m.ToolCalls) > 0 || m.Role == LLMRoleTool {
+		for end < len(c.messages) && c.messages[end].Role == LLMRoleTool {
+			end++
+		}
+	}
+	return end
+}
+
+func (c *LLMContext) String() string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return fmt.Sprintf("LLMContext(messages: %d)", len(c.messages))
+}
@@ -0,0 +1,81 @@
+package frames
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func contents(c *LLMContext) []string {
+	var contents []string
+	for _, m := range c.Messages() {
+		contents = append(contents, m.Content)
+	}
+	return contents
+}
+
+func TestLLMContext_MaxMessages(t *testing.T) {
+	c := NewLLMContext(LLMMessage{Role: LLMRoleSystem, Content: "system"}).WithMaxMessages(3)
+	c.AddMessage(LLMRoleUser, "u1")
+	c.AddMessage(LLMRoleAssistant, "a1")
+	c.AddMessage(LLMRoleUser, "u2")
+
+	// The system message is kept, the oldest messages are dropped.
+	assert.Equal(t, []string{"system", "a1", "u2"}, contents(c))
+}
+
+func TestLLMContext_MaxChars(t *testing.T) {
+	c := NewLLMContext(LLMMessage{Role: LLMRoleSystem, Content: "system"}).WithMaxChars(12)
+	c.AddMessages(
+		LLMMessage{Role: LLMRoleUser, Content: "hello"},
+		LLMMessage{Role: LLMRoleAssistant, Content: "你好"},
+		LLMMessage{Role: LLMRoleUser, Content: "bye"},
+	)
+	assert.Equal(t, []string{"system", "你好", "bye"}, contents(c))
+
+	// The last message is kept, however long.
+	c.AddMessage(LLMRoleAssistant, "a long goodbye")
+	assert.Equal(t, []string{"system", "a long goodbye"}, contents(c))
+}
+
+func TestLLMContext_MessagesCopy(t *testing.T) {
+	c := NewLLMContext()
+	c.AddMessage(LLMRoleUser, "hi")
+	messages := c.Messages()
+	messages[0].Content = "changed"
+
+	assert.Equal(t, []string{"hi"}, contents(c))
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestLLMContext_TruncateToolMessages(t *testing.T) {
+	c := NewLLMContext().WithMaxMessages(3)
+	c.AddMessages(
+		LLMMessage{Role: LLMRoleUser, Content: "weather?"},
+		LLMMessage{Role: LLMRoleAssistant, ToolCalls: []LLMToolCall{{ID: "call_a", Type: "function"}}},
+		LLMMessage{Role: LLMRoleTool, Content: "sunny", ToolCallID: "call_a"},
+		LLMMessage{Role: LLMRoleAssistant, Content: "It is sunny."},
+		LLMMessage{Role: LLMRoleUser, Content: "thanks"},
+	)
+
+	// The tool result is dropped with its call.
+	assert.Equal(t, []string{"It is sunny.", "thanks"}, contents(c))
+}
+
+func TestLLMContext_TruncateToolResultOverBudget(t *testing.T) {
+	c := NewLLMContext(LLMMessage{Role: LLMRoleSystem, Content: "system"}).WithMaxChars(20)
+	c.AddMessages(
+		LLMMessage{Role: LLMRoleUser, Content: "forecast?"},
+		LLMMessage{Role: LLMRoleAssistant, ToolCalls: []LLMToolCall{{ID: "call_a", Type: "function"}, {ID: "call_b", Type: "function"}}},
+		LLMMessage{Role: LLMRoleTool, Content: "sunny", ToolCallID: "call_a"},
+		LLMMessage{Role: LLMRoleTool, Content: "a forecast far longer than the budget", ToolCallID: "call_b"},
+	)
+
+	// The last tool results are kept with their call, however long.
+	messages := c.Messages()
+	assert.Equal(t, []string{"system", "", "sunny", "a forecast far longer than the budget"}, contents(c))
+	assert.Len(t, messages[1].ToolCalls, 2)
+
+	c.AddMessage(LLMRoleAssistant, "Sunny, then rain.")
+	assert.Equal(t, []string{"system", "Sunny, then rain."}, contents(c))
+}
@@ -0,0 +1,99 @@
+package frames
+
+import "fmt"
+
+// LLMContextFrame carries the conversation context, e.g. for a LLM processor to
+// complete it.
+type LLMContextFrame struct {
+	*DataFrame
+	Context *LLMContext
+}
+
+// NewLLMContextFrame creates a new LLMContextFrame.
+func NewLLMContextFrame(context *LLMContext) *LLMContextFrame {
+	return &LLMContextFrame{
+		DataFrame: NewDataFrameWithName("LLMContextFrame"),
+		Context:   context,
+	}
+}
+
+// String returns a string representation of the LLMContextFrame.
+func (f *LLMContextFrame) String() string {
+	return fmt.Sprintf("%s(context: %s)", f.Name(), f.Context)
+}
+
+// LLMFullResponseStartFrame indicates the start of a LLM response, followed by its TextFrames.
+type LLMFullResponseStartFrame struct {
+	*ControlFrame
+}
+
+func NewLLMFullResponseStartFrame() *LLMFullResponseStartFrame {
+	return &LLMFullResponseStartFrame{
+		ControlFrame: &ControlFrame{
+			BaseFrame: NewBaseFrameWithName("LLMFullResponseStartFrame"),
+		},
+	}
+}
+
+// LLMFullResponseEndFrame indicates the end of a LLM response.
+type LLMFullResponseEndFrame struct {
+	*ControlFrame
+}
+
+func NewLLMFullResponseEndFrame() *LLMFullResponseEndFrame {
+	return &LLMFullResponseEndFrame{
+		ControlFrame: &ControlFrame{
+			BaseFrame: NewBaseFrameWithName("LLMFullResponseEndFrame"),
+		},
+	}
+}
+
+// FunctionCallInProgressFrame indicates the LLM called a function tool, being run.
+type FunctionCallInProgressFrame struct {
+	*SystemFrame
+	ToolCallID   string
+	FunctionName string
+	// Arguments are the arguments of the call encoded in JSON.
+	Arguments string
+}
+
+func NewFunctionCallInProgressFrame(toolCallID, functionName, arguments string) *FunctionCallInProgressFrame {
+	return &FunctionCallInProgressFrame{
+		SystemFrame: &SystemFrame{
+			BaseFrame: NewBaseFrameWithName("FunctionCallInProgressFrame"),
+		},
+		ToolCallID:   toolCallID,
+		FunctionName: functionName,
+		Arguments:    arguments,
+	}
+}
+
+func (f *FunctionCallInProgressFrame) String() string {
+	return fmt.Sprintf("%s(id: %s, function: %s, arguments: %s)", f.Name(), f.ToolCallID, f.FunctionName, f.Arguments)
+}
+
+// FunctionCallResultFrame contains the result of a function tool called by the LLM.
+type FunctionCallResultFrame struct {
+	*DataFrame
+	ToolCallID   string
+	FunctionName string
+	// Arguments are the arguments of the call encoded in JSON.
+	Arguments string
+	// Result is the result of the call given to the LLM, encoded in JSON.
+	Result string
+}
+
+func NewFunctionCallResultFrame(toolCallID, functionName, arguments, result string) *FunctionCallResultFrame {
+	return &FunctionCallResultFrame{
+		DataFrame:    NewDataFrameWithName("FunctionCallResultFrame"),
+		ToolCallID:   toolCallID,
+		FunctionName: functionName,
+		Arguments:    arguments,
+		Result:       result,
+	}
+}
+
+func (f *FunctionCallResultFrame) String() string {
+	return fmt.Sprintf("%s(id: %s, function: %s, arguments: %s, result: %s)",
+		f.Name(), f.ToolCallID, f.FunctionName, f.Arguments, f.Result)
+}
@@ -0,0 +1,72 @@
+package frames
+
+import (
+	"fmt"
+	"time"
+)
+
+// MetricsData is what all the metrics carried by a MetricsFrame have in common.
+type MetricsData struct {
+	// Processor is the name of the processor reporting the metric.
+	Processor string
+	// Model is the model used by the processor, if any.
+	Model string
+	// Timestamp is when the metric was measured.
+	Timestamp time.Time
+}
+
+func (d MetricsData) String() string {
+	if d.Model == "" {
+		return d.Processor
+	}
+	return fmt.Sprintf("%s(model: %s)", d.Processor, d.Model)
+}
+
+// TTFBMetricsData is the time to first byte of a processor,
+// e.g. from a request sent to a service to its first response.
+type TTFBMetricsData struct {
+	MetricsData
+	Value time.Duration
+}
+
+func (d TTFBMetricsData) String() string {
+	return fmt.Sprintf("%s: %s", d.MetricsData, d.Value)
+}
+
+// ProcessingMetricsData is the time a processor took to process a frame.
+type ProcessingMetricsData struct {
+	MetricsData
+	Value time.Duration
+}
+
+func (d ProcessingMetricsData) String() string {
+	return fmt.Sprintf("%s: %s", d.MetricsData, d.Value)
+}
+
+// LLMTokenUsage is the number of tokens used by a LLM completion.
+type LLMTokenUsage struct {
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+}
+
+// LLMUsageMetricsData is the token usage of a LLM processor.
+type LLMUsageMetricsData struct {
+	MetricsData
+	Value LLMTokenUsage
+}
+
+func (d LLMUsageMetricsData) String() string {
+	return fmt.Sprintf("%s: prompt %d, completion %d, total %d tokens",
+		d.MetricsData, d.Value.PromptTokens, d.Value.CompletionTokens, d.Value.TotalTokens)
+}
+
+// TTSUsageMetricsData is the number of characters synthesized by a TTS processor.
+type TTSUsageMetricsData struct {
+	MetricsData
+	Value int
+}
+
+func (d TTSUsageMetricsData) String() string {
+	return fmt.Sprintf("%s: %d characters", d.MetricsData, d.Value)
+}
@@ -0,0 +1,53 @@
+package frames
+
+import "fmt"
+
+// TranscriptionFrame contains the text transcribed from the user speech by a STT service.
+type TranscriptionFrame struct {
+	*TextFrame
+	Language string
+}
+
+// NewTranscriptionFrame creates a new TranscriptionFrame.
+func NewTranscriptionFrame(text, language string) *TranscriptionFrame {
+	return &TranscriptionFrame{
+		TextFrame: &TextFrame{
+			DataFrame: NewDataFrameWithName("TranscriptionFrame"),
+			Text:      text,
+		},
+		Language: language,
+	}
+}
+
+// String returns a string representation of the TranscriptionFrame.
+func (f *TranscriptionFrame) String() string {
+	return fmt.Sprintf("%s(text: %s, language: %s)", f.Name(), f.Text, f.Language)
+}
+
+// TTSStartedFrame indicates a TTS service started synthesizing a text, followed by its AudioRawFrames.
+type TTSStartedFrame struct {
+	*ControlFrame
+}
+
+// NewTTSStartedFrame creates a new TTSStartedFrame.
+func NewTTSStartedFrame() *TTSStartedFrame {
+	return &TTSStartedFrame{
+		ControlFrame: &ControlFrame{
+			BaseFrame: NewBaseFrameWithName("TTSStartedFrame"),
+		},
+	}
+}
+
+// TTSStoppedFrame indicates a TTS service synthesized a text.
+type TTSStoppedFrame struct {
+	*ControlFrame
+}
+
+// NewTTSStoppedFrame creates a new TTSStoppedFrame.
+func NewTTSStoppedFrame() *TTSStoppedFrame {
+	return &TTSStoppedFrame{
+		ControlFrame: &ControlFrame{
+			BaseFrame: NewBaseFrameWithName("TTSStoppedFrame"),
+		},
+	}
+}
@@ -89,43 +89,79 @@ func NewStopInterruptionFrame() *StopInterruptionFrame {
 	}
 }
 
-// MetricsFrame contains metrics about the pipeline.
-type MetricsFrame struct {
+// UserStartedSpeakingFrame is emitted when voice activity from the user is detected.
+type UserStartedSpeakingFrame struct {
 	*SystemFrame
-	TTFB       []map[string]any
-	Processing []map[string]any
-	Tokens     []map[string]any
-	Characters []map[string]any
 }
 
-func NewMetricsFrame() *MetricsFrame {
-	return &MetricsFrame{
-		SystemFrame: NewSystemFrame(),
+func NewUserStartedSpeakingFrame() *UserStartedSpeakingFrame {
+	return &UserStartedSpeakingFrame{
+		SystemFrame: &SystemFrame{
+			BaseFrame: NewBaseFrameWithName("UserStartedSpeakingFrame"),
+		},
 	}
 }
 
-// NewMetricsFrameWithTTFB creates a new MetricsFrame with TTFB metrics.
-func NewMetricsFrameWithTTFB(ttfb []map[string]interface{}) *MetricsFrame {
-	return &MetricsFrame{
+// UserStoppedSpeakingFrame is emitted when the user's voice activity has ended.
+type UserStoppedSpeakingFrame struct {
+	*SystemFrame
+}
+
+func NewUserStoppedSpeakingFrame() *UserStoppedSpeakingFrame {
+	return &UserStoppedSpeakingFrame{
 		SystemFrame: &SystemFrame{
-			BaseFrame: NewBaseFrameWithName("MetricsFrame"),
+			BaseFrame: NewBaseFrameWithName("UserStoppedSpeakingFrame"),
 		},
-		TTFB: ttfb,
 	}
 }
 
-// NewMetricsFrameWithProcessing creates a new MetricsFrame with processing metrics.
-func NewMetricsFrameWithProcessing(processing []map[string]interface{}) *MetricsFrame {
+// MetricsFrame contains metrics about the pipeline.
+type MetricsFrame struct {
+	*SystemFrame
+	TTFB       []TTFBMetricsData
+	Processing []ProcessingMetricsData
+	Tokens     []LLMUsageMetricsData
+	Characters []TTSUsageMetricsData
+}
+
+func NewMetricsFrame() *MetricsFrame {
 	return &MetricsFrame{
 		SystemFrame: &SystemFrame{
 			BaseFrame: NewBaseFrameWithName("MetricsFrame"),
 		},
-		Processing: processing,
 	}
 }
 
+// NewMetricsFrameWithTTFB creates a new MetricsFrame with TTFB metrics.
+func NewMetricsFrameWithTTFB(ttfb ...TTFBMetricsData) *MetricsFrame {
+	f := NewMetricsFrame()
+	f.TTFB = ttfb
+	return f
+}
+
+// NewMetricsFrameWithProcessing creates a new MetricsFrame with processing metrics.
+func NewMetricsFrameWithProcessing(processing ...ProcessingMetricsData) *MetricsFrame {
+	f := NewMetricsFrame()
+	f.Processing = processing
+	return f
+}
+
+// NewMetricsFrameWithTokens creates a new MetricsFrame with LLM token usage metrics.
+func NewMetricsFrameWithTokens(tokens ...LLMUsageMetricsData) *MetricsFrame {
+	f := NewMetricsFrame()
+	f.Tokens = tokens
+	return f
+}
+
+// NewMetricsFrameWithCharacters creates a new MetricsFrame with TTS character usage metrics.
+func NewMetricsFrameWithCharacters(characters ...TTSUsageMetricsData) *MetricsFrame {
+	f := NewMetricsFrame()
+	f.Characters = characters
+	return f
+}
+
 func (f *MetricsFrame) String() string {
-	return fmt.Sprintf("%s ttfb:%+v | processing:%+v | tokens:%+v | characters:%+v",
+	return fmt.Sprintf("%s ttfb:%v | processing:%v | tokens:%v | characters:%v",
 		f.Name(), f.TTFB, f.Processing, f.Tokens, f.Characters)
 }
 
@@ -0,0 +1,467 @@
+// Package inspector serves a read-only debug view of live pipeline tasks over HTTP.
+package inspector
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/pipeline"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+// DefaultLastFrames is the default number of last frames kept per processor.
+const DefaultLastFrames = 10
+
+// tapBufferSize is the number of frame events buffered per tap, more are missed.
+const tapBufferSize = 64
+
+// Task states, see TaskSummary.
+const (
+	TaskStateCreated = "created"
+	TaskStateRunning = "running"
+)
+
+// Inspector observes pipeline tasks and serves, with Handler:
+//   - GET /tasks: the running tasks,
+//   - GET /tasks/{task}: the processor graph of a task (by name or ID), with the
+//     queue lengths, statistics, last frames and interruption state of the processors,
+//   - GET /tasks/{task}/processors/{processor}/frames: a server-sent events stream
+//     of the frames pushed to the processors with that name, filtered by frame type
+//     with the include and ignore query parameters (a processors.FrameTypeFilter,
+//     like the one of a FrameLoggerProcessor).
+//
+// It only observes the frames (FrameObserver) and reads atomic counters, it never
+// changes the processors nor blocks them: a slow stream misses frames. Frame
+// contents are not exposed, only their type, name and ID.
+type Inspector struct {
+	lastFrames int
+
+	mu    sync.Mutex
+	tasks []*taskState
+}
+
+// TaskSummary describes a task.
+type TaskSummary struct {
+	ID                 int        `json:"id"`
+	Name               string     `json:"name"`
+	State              string     `json:"state"`
+	StartedAt          *time.Time `json:"started_at,omitempty"`
+	AllowInterruptions bool       `json:"allow_interruptions"`
+}
+
+// TaskInfo describes a task and its processor graph.
+type TaskInfo struct {
+	TaskSummary
+	Processors []ProcessorInfo `json:"processors"`
+}
+
+// ProcessorInfo describes a processor and the processors it contains.
+type ProcessorInfo struct {
+	Name          string          `json:"name"`
+	Type          string          `json:"type"`
+	Queues        *DirectionCount `json:"queues,omitempty"`
+	FramesIn      DirectionCount  `json:"frames_in"`
+	FramesOut     DirectionCount  `json:"frames_out"`
+	Dropped       uint64          `json:"dropped"`
+	Errors        uint64          `json:"errors"`
+	Panics        uint64          `json:"panics"`
+	LastFrameTime *time.Time      `json:"last_frame_time,omitempty"`
+	// Interrupted is whether the last interruption frame pushed to the processor was a StartInterruptionFrame.
+	Interrupted bool            `json:"interrupted"`
+	LastFrames  []FrameInfo     `json:"last_frames,omitempty"`
+	Children    []ProcessorInfo `json:"children,omitempty"`
+}
+
+// DirectionCount counts per direction.
+type DirectionCount struct {
+	Downstream uint64 `json:"downstream"`
+	Upstream   uint64 `json:"upstream"`
+}
+
+// FrameInfo describes a frame pushed from a processor to another one.
+type FrameInfo struct {
+	ID        uint64    `json:"id"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Direction string    `json:"direction"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Time      time.Time `json:"time"`
+}
+
+// NewInspector creates a new Inspector.
+func NewInspector() *Inspector {
+	return &Inspector{lastFrames: DefaultLastFrames}
+}
+
+// WithLastFrames sets the number of last frames kept per processor.
+func (i *Inspector) WithLastFrames(n int) *Inspector {
+	i.lastFrames = n
+	return i
+}
+
+// Observe inspects task until it ends, it must be called before the task runs.
+func (i *Inspector) Observe(task *pipeline.PipelineTask) {
+	ts := &taskState{
+		inspector:  i,
+		task:       task,
+		state:      TaskStateCreated,
+		names:      make(map[*processors.FrameProcessor]string),
+		processors: make(map[*processors.FrameProcessor]*processorState),
+		taps:       make(map[*tap]struct{}),
+	}
+	procs := task.Processors()
+	if len(procs) > 0 {
+		ts.root = processors.BaseProcessor(procs[0])
+	}
+	// The sources of the push events are the FrameProcessors embedded in the
+	// processors, which may override their name.
+	for _, processor := range procs {
+		if base := processors.BaseProcessor(processor); base != nil {
+			ts.names[base] = processors.ProcessorName(processor)
+		}
+	}
+	i.mu.Lock()
+	i.tasks = append(i.tasks, ts)
+	i.mu.Unlock()
+	task.AddObserver(ts)
+}
+
+// Tasks returns the running tasks.
+func (i *Inspector) Tasks() []TaskSummary {
+	i.mu.Lock()
+	tasks := slices.Clone(i.tasks)
+	i.mu.Unlock()
+
+	summaries := make([]TaskSummary, 0, len(tasks))
+	for _, ts := range tasks {
+		summaries = append(summaries, ts.summary())
+	}
+	return summaries
+}
+
+// Task returns the processor graph of the running task with name or ID task.
+func (i *Inspector) Task(task string) (TaskInfo, bool) {
+	ts := i.findTask(task)
+	if ts == nil {
+		return TaskInfo{}, false
+	}
+	return ts.info(), true
+}
+
+func (i *Inspector) findTask(task string) *taskState {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, ts := range i.tasks {
+		if ts.task.Name == task || strconv.Itoa(ts.task.ID) == task {
+			return ts
+		}
+	}
+	return nil
+}
+
+func (i *Inspector) removeTask(ts *taskState) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.tasks = slices.DeleteFunc(i.tasks, func(t *taskState) bool { return t == ts })
+}
+
+// Handler returns the HTTP handler of the inspector, see Inspector.
+func (i *Inspector) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /tasks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, i.Tasks())
+	})
+	mux.HandleFunc("GET /tasks/{task}", func(w http.ResponseWriter, r *http.Request) {
+		info, ok := i.Task(r.PathValue("task"))
+		if !ok {
+			http.Error(w, "task not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, info)
+	})
+	mux.HandleFunc("GET /tasks/{task}/processors/{processor}/frames", i.serveFrames)
+	return mux
+}
+
+// serveFrames streams the frames pushed to a processor as server-sent events.
+func (i *Inspector) serveFrames(w http.ResponseWriter, r *http.Request) {
+	ts := i.findTask(r.PathValue("task"))
+	if ts == nil {
+		http.Error(w, "task not found", http.StatusNotFound)
+		return
+	}
+	name := r.PathValue("processor")
+	if !ts.hasProcessor(name) {
+		http.Error(w, "processor not found", http.StatusNotFound)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	t := ts.addTap(name, processors.FrameTypeFilter{Include: r.URL.Query()["include"], Ignore: r.URL.Query()["ignore"]})
+	defer ts.removeTap(t)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	var missed uint64
+	writeFrame := func(frame FrameInfo) {
+		if n := t.missedCount(); n > missed {
+			fmt.Fprintf(w, "event: missed\ndata: {\"count\":%d}\n\n", n-missed)
+			missed = n
+		}
+		data, _ := json.Marshal(frame)
+		fmt.Fprintf(w, "event: frame\ndata: %s\n\n", data)
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case frame := <-t.frames:
+			writeFrame(frame)
+			flusher.Flush()
+		case <-t.ended:
+			for len(t.frames) > 0 {
+				writeFrame(<-t.frames)
+			}
+			fmt.Fprint(w, "event: end\ndata: {}\n\n")
+			flusher.Flush()
+			return
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// taskState is the state of an observed task, updated by its processors' pushes.
+type taskState struct {
+	inspector *Inspector
+	task      *pipeline.PipelineTask
+	root      *processors.FrameProcessor
+	names     map[*processors.FrameProcessor]string
+
+	mu                 sync.Mutex
+	state              string
+	startedAt          time.Time
+	allowInterruptions bool
+	processors         map[*processors.FrameProcessor]*processorState
+	taps               map[*tap]struct{}
+}
+
+// processorState is what the observer saw of a processor.
+type processorState struct {
+	lastFrames  []FrameInfo // oldest first
+	interrupted bool
+}
+
+// tap streams the frames pushed to the processors named processor.
+type tap struct {
+	processor string
+	filter    processors.FrameTypeFilter
+	frames    chan FrameInfo
+	ended     chan struct{}
+
+	mu     sync.Mutex
+	missed uint64
+}
+
+func (t *tap) missedCount() uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.missed
+}
+
+// OnPushFrame implements processors.FrameObserver.
+func (ts *taskState) OnPushFrame(event processors.FramePushEvent) {
+	info := FrameInfo{
+		Type:      frames.TypeName(event.Frame),
+		Direction: event.Direction.Label(),
+		From:      ts.sourceName(event.Source),
+		To:        processors.ProcessorName(event.Destination),
+		Time:      event.Timestamp,
+	}
+	if base := frames.Base(event.Frame); base != nil {
+		info.ID, info.Name = base.ID(), base.Name()
+	}
+	dest := processors.BaseProcessor(event.Destination)
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if startFrame, ok := event.Frame.(*frames.StartFrame); ok && processors.BaseProcessor(event.Source) == ts.root {
+		ts.state = TaskStateRunning
+		ts.startedAt = event.Timestamp
+		ts.allowInterruptions = startFrame.AllowInterruptions
+	}
+
+	if dest != nil {
+		ps, ok := ts.processors[dest]
+		if !ok {
+			ps = &processorState{}
+			ts.processors[dest] = ps
+		}
+		ps.lastFrames = append(ps.lastFrames, info)
+		if over := len(ps.lastFrames) - ts.inspector.lastFrames; over > 0 {
+			ps.lastFrames = ps.lastFrames[over:]
+		}
+		switch event.Frame.(type) {
+		case *frames.StartInterruptionFrame, frames.StartInterruptionFrame:
+			ps.interrupted = true
+		case *frames.StopInterruptionFrame, frames.StopInterruptionFrame:
+			ps.interrupted = false
+		}
+	}
+
+	for t := range ts.taps {
+		if t.processor != info.To || !t.filter.Match(event.Frame) {
+			continue
+		}
+		select {
+		case t.frames <- info:
+		default:
+			t.mu.Lock()
+			t.missed++
+			t.mu.Unlock()
+		}
+	}
+}
+
+// OnFrameProcessed implements processors.FrameProcessedObserver.
+func (ts *taskState) OnFrameProcessed(event processors.FramePushEvent) {
+	switch event.Frame.(type) {
+	case *frames.EndFrame, *frames.CancelFrame:
+		if processors.BaseProcessor(event.Source) != ts.root {
+			return
+		}
+	default:
+		return
+	}
+
+	// The task is done, end its streams and stop listing it.
+	ts.inspector.removeTask(ts)
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	for t := range ts.taps {
+		close(t.ended)
+		delete(ts.taps, t)
+	}
+}
+
+// OnDropFrame implements processors.FrameObserver.
+func (ts *taskState) OnDropFrame(event processors.FrameDropEvent) {
+	// Drops are counted by the processor statistics.
+}
+
+// sourceName returns the name of the processor embedding source.
+func (ts *taskState) sourceName(source processors.IFrameProcessor) string {
+	if name, ok := ts.names[processors.BaseProcessor(source)]; ok {
+		return name
+	}
+	return processors.ProcessorName(source)
+}
+
+func (ts *taskState) addTap(processor string, filter processors.FrameTypeFilter) *tap {
+	t := &tap{
+		processor: processor,
+		filter:    filter,
+		frames:    make(chan FrameInfo, tapBufferSize),
+		ended:     make(chan struct{}),
+	}
+	ts.mu.Lock()
+	ts.taps[t] = struct{}{}
+	ts.mu.Unlock()
+	return t
+}
+
+func (ts *taskState) removeTap(t *tap) {
+	ts.mu.Lock()
+	delete(ts.taps, t)
+	ts.mu.Unlock()
+}
+
+func (ts *taskState) hasProcessor(name string) bool {
+	return slices.ContainsFunc(ts.task.Processors(), func(processor processors.IFrameProcessor) bool {
+		return processors.ProcessorName(processor) == name
+	})
+}
+
+func (ts *taskState) summary() TaskSummary {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	summary := TaskSummary{
+		ID:                 ts.task.ID,
+		Name:               ts.task.Name,
+		State:              ts.state,
+		AllowInterruptions: ts.allowInterruptions,
+	}
+	if !ts.startedAt.IsZero() {
+		startedAt := ts.startedAt
+		summary.StartedAt = &startedAt
+	}
+	return summary
+}
+
+func (ts *taskState) info() TaskInfo {
+	info := TaskInfo{TaskSummary: ts.summary()}
+	procs := ts.task.Processors()
+	if len(procs) > 0 {
+		// The task source, then the pipeline graph.
+		info.Processors = append(info.Processors, ts.processorInfo(procs[0]))
+	}
+	info.Processors = append(info.Processors, ts.processorInfo(ts.task.Pipeline()))
+	return info
+}
+
+func (ts *taskState) processorInfo(processor processors.IFrameProcessor) ProcessorInfo {
+	info := ProcessorInfo{Name: processors.ProcessorName(processor), Type: fmt.Sprintf("%T", processor)}
+	if q, ok := processor.(processors.QueueProcessor); ok {
+		info.Queues = &DirectionCount{
+			Downstream: uint64(q.QueueLen(processors.FrameDirectionDownstream)),
+			Upstream:   uint64(q.QueueLen(processors.FrameDirectionUpstream)),
+		}
+	}
+	if base := processors.BaseProcessor(processor); base != nil {
+		stats := base.Stats()
+		info.FramesIn = DirectionCount{Downstream: stats.FramesIn.Downstream, Upstream: stats.FramesIn.Upstream}
+		info.FramesOut = DirectionCount{Downstream: stats.FramesOut.Downstream, Upstream: stats.FramesOut.Upstream}
+		info.Dropped, info.Errors, info.Panics = stats.Dropped, stats.Errors, stats.Panics
+		if !stats.LastFrameTime.IsZero() {
+			info.LastFrameTime = &stats.LastFrameTime
+		}
+
+		ts.mu.Lock()
+		if ps, ok := ts.processors[base]; ok {
+			info.Interrupted = ps.interrupted
+			info.LastFrames = slices.Clone(ps.lastFrames)
+		}
+		ts.mu.Unlock()
+	}
+
+	switch p := processor.(type) {
+	case processors.ProcessorContainer:
+		for _, child := range p.Processors() {
+			info.Children = append(info.Children, ts.processorInfo(child))
+		}
+	case processors.ProcessorWrapper:
+		info.Children = append(info.Children, ts.processorInfo(p.Wrapped()))
+	}
+	return info
+}
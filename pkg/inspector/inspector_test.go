@@ -0,0 +1,194 @@
+package inspector
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/pipeline"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+// blockingProcessor blocks on the "block" TextFrame until released.
+type blockingProcessor struct {
+	*processors.FrameProcessor
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingProcessor) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	if text, ok := frame.(*frames.TextFrame); ok && text.Text == "block" {
+		close(p.entered)
+		<-p.release
+	}
+	p.PushFrame(frame, direction)
+}
+
+func getJSON(t *testing.T, url string, v any) {
+	resp, err := http.Get(url)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+}
+
+// readEvent reads the next server-sent event.
+func readEvent(scanner *bufio.Scanner) (event, data string) {
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		case line == "" && event != "":
+			return event, data
+		}
+	}
+	return "", ""
+}
+
+func findProcessor(infos []ProcessorInfo, name string) *ProcessorInfo {
+	for i := range infos {
+		if infos[i].Name == name {
+			return &infos[i]
+		}
+		if info := findProcessor(infos[i].Children, name); info != nil {
+			return info
+		}
+	}
+	return nil
+}
+
+func TestInspector(t *testing.T) {
+	first := processors.NewDefaultFrameLoggerProcessorWithName("first")
+	async := processors.NewAsyncFrameProcessor("async").WithPorcessFrameAllowPush(true)
+	blocking := &blockingProcessor{
+		FrameProcessor: processors.NewFrameProcessor("blocking"),
+		entered:        make(chan struct{}),
+		release:        make(chan struct{}),
+	}
+	pl := pipeline.NewPipeline([]processors.IFrameProcessor{first, async, blocking}, nil, nil)
+	task := pipeline.NewPipelineTask(pl, pipeline.PipelineParams{AllowInterruptions: true})
+
+	inspector := NewInspector().WithLastFrames(2)
+	inspector.Observe(task)
+	server := httptest.NewServer(inspector.Handler())
+	defer server.Close()
+	taskURL := fmt.Sprintf("%s/tasks/%d", server.URL, task.ID)
+
+	var tasks []TaskSummary
+	getJSON(t, server.URL+"/tasks", &tasks)
+	assert.Equal(t, []TaskSummary{{ID: task.ID, Name: task.Name, State: TaskStateCreated}}, tasks)
+
+	done := make(chan struct{})
+	go func() {
+		task.Run()
+		close(done)
+	}()
+	assert.Eventually(t, func() bool { return inspector.Tasks()[0].State == TaskStateRunning }, 2*time.Second, time.Millisecond)
+	assert.True(t, inspector.Tasks()[0].AllowInterruptions)
+
+	resp, err := http.Get(taskURL + "/processors/first/frames?include=TextFrame")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	stream := bufio.NewScanner(resp.Body)
+
+	task.QueueFrame(frames.NewTextFrame("hello"))
+	event, data := readEvent(stream)
+	assert.Equal(t, "frame", event)
+	var frame FrameInfo
+	assert.NoError(t, json.Unmarshal([]byte(data), &frame))
+	assert.Equal(t, "TextFrame", frame.Type)
+	assert.Equal(t, "downstream", frame.Direction)
+	assert.Equal(t, "PipelineSource", frame.From)
+	assert.Equal(t, "first", frame.To)
+
+	// "block" blocks the async worker, "queued" waits in its queue.
+	block, queued := frames.NewTextFrame("block"), frames.NewTextFrame("queued")
+	task.QueueFrame(block)
+	<-blocking.entered
+	task.QueueFrame(queued)
+	assert.Eventually(t, func() bool { return async.QueueLen(processors.FrameDirectionDownstream) == 1 }, 2*time.Second, time.Millisecond)
+
+	var info TaskInfo
+	getJSON(t, taskURL, &info)
+	assert.Equal(t, TaskStateRunning, info.State)
+	assert.Equal(t, 2, len(info.Processors))
+	assert.Equal(t, "*pipeline.TaskSource", info.Processors[0].Name)
+	names := []string{}
+	for _, child := range info.Processors[1].Children {
+		names = append(names, child.Name)
+	}
+	assert.Equal(t, []string{"PipelineSource", "first", "async", "blocking", "PipelineSink"}, names)
+	asyncInfo := findProcessor(info.Processors, "async")
+	assert.Equal(t, &DirectionCount{Downstream: 1}, asyncInfo.Queues)
+	assert.Equal(t, uint64(4), asyncInfo.FramesIn.Downstream) // StartFrame and the TextFrames
+	blockingInfo := findProcessor(info.Processors, "blocking")
+	assert.Nil(t, blockingInfo.Queues)
+	assert.Equal(t, 2, len(blockingInfo.LastFrames))
+	assert.Equal(t, block.Name(), blockingInfo.LastFrames[1].Name)
+	assert.NotNil(t, blockingInfo.LastFrameTime)
+
+	close(blocking.release)
+	task.QueueFrame(frames.NewStartInterruptionFrame())
+	assert.Eventually(t, func() bool {
+		info, _ := inspector.Task(task.Name)
+		return findProcessor(info.Processors, "first").Interrupted
+	}, 2*time.Second, time.Millisecond)
+
+	// The stream ends with the task, after the filtered frames.
+	task.StopWhenDone()
+	for _, text := range []*frames.TextFrame{block, queued} {
+		event, data = readEvent(stream)
+		assert.Equal(t, "frame", event)
+		assert.NoError(t, json.Unmarshal([]byte(data), &frame))
+		assert.Equal(t, text.Name(), frame.Name)
+	}
+	event, _ = readEvent(stream)
+	assert.Equal(t, "end", event)
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("task not finished")
+	}
+
+	getJSON(t, server.URL+"/tasks", &tasks)
+	assert.Empty(t, tasks)
+	resp, err = http.Get(taskURL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestInspector_NotFound(t *testing.T) {
+	pl := pipeline.NewPipeline([]processors.IFrameProcessor{processors.NewDefaultFrameLoggerProcessorWithName("first")}, nil, nil)
+	task := pipeline.NewPipelineTask(pl, pipeline.PipelineParams{})
+	inspector := NewInspector()
+	inspector.Observe(task)
+	server := httptest.NewServer(inspector.Handler())
+	defer server.Close()
+
+	for _, path := range []string{
+		"/tasks/unknown",
+		"/tasks/unknown/processors/first/frames",
+		fmt.Sprintf("/tasks/%d/processors/unknown/frames", task.ID),
+	} {
+		resp, err := http.Get(server.URL + path)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, path)
+	}
+	resp, err := http.Post(server.URL+"/tasks", "application/json", nil)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
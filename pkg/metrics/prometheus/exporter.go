@@ -0,0 +1,157 @@
+// Package prometheus exports pipeline and processor metrics to Prometheus.
+package prometheus
+
+import (
+	"net/http"
+	"sync"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/pipeline"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+const namespace = "pipeline"
+
+// Exporter observes pipeline tasks and exports:
+//   - pipeline_ttfb_seconds and pipeline_processing_seconds histograms per processor,
+//     from the MetricsFrames the processors push (PipelineParams.EnableMetrics),
+//   - pipeline_frames_processed_total counters per processor, frame type and direction,
+//   - pipeline_frames_dropped_total counters per processor, frame type, direction and reason,
+//   - pipeline_queue_depth gauges of the AsyncFrameProcessor queues,
+//   - pipeline_panics_total counters per processor.
+//
+// Processors are identified by name, the values of processors with the same name are summed.
+type Exporter struct {
+	registry        *prom.Registry
+	ttfb            *prom.HistogramVec
+	processing      *prom.HistogramVec
+	framesProcessed *prom.CounterVec
+	framesDropped   *prom.CounterVec
+	queueDepthDesc  *prom.Desc
+	panicsDesc      *prom.Desc
+
+	mu         sync.Mutex
+	processors []processors.IFrameProcessor
+}
+
+type panicCounter interface {
+	PanicCount() uint64
+}
+
+// NewExporter creates a new Exporter with its own registry.
+func NewExporter() *Exporter {
+	buckets := prom.ExponentialBuckets(0.005, 2, 12) // 5ms to ~10s
+	e := &Exporter{
+		registry: prom.NewRegistry(),
+		ttfb: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "ttfb_seconds",
+			Help:      "Time to first byte of the processors.",
+			Buckets:   buckets,
+		}, []string{"processor", "model"}),
+		processing: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "processing_seconds",
+			Help:      "Processing time of the processors.",
+			Buckets:   buckets,
+		}, []string{"processor", "model"}),
+		framesProcessed: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "frames_processed_total",
+			Help:      "Frames pushed to the processors.",
+		}, []string{"processor", "frame", "direction"}),
+		framesDropped: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "frames_dropped_total",
+			Help:      "Frames dropped by the processor queues.",
+		}, []string{"processor", "frame", "direction", "reason"}),
+		queueDepthDesc: prom.NewDesc(prom.BuildFQName(namespace, "", "queue_depth"),
+			"Frames waiting in the processor queues.", []string{"processor", "direction"}, nil),
+		panicsDesc: prom.NewDesc(prom.BuildFQName(namespace, "", "panics_total"),
+			"Panics recovered from the processors.", []string{"processor"}, nil),
+	}
+	e.registry.MustRegister(e.ttfb, e.processing, e.framesProcessed, e.framesDropped, (*processorsCollector)(e))
+	return e
+}
+
+// Registry returns the registry of the exported metrics.
+func (e *Exporter) Registry() *prom.Registry {
+	return e.registry
+}
+
+// Handler returns the /metrics HTTP handler.
+func (e *Exporter) Handler() http.Handler {
+	return promhttp.HandlerFor(e.registry, promhttp.HandlerOpts{})
+}
+
+// Observe exports the metrics of task, it must be called before the task runs.
+func (e *Exporter) Observe(task *pipeline.PipelineTask) {
+	procs := task.Processors()
+	e.mu.Lock()
+	e.processors = append(e.processors, procs...)
+	e.mu.Unlock()
+	task.AddObserver(e)
+}
+
+// OnPushFrame implements processors.FrameObserver.
+func (e *Exporter) OnPushFrame(event processors.FramePushEvent) {
+	direction := event.Direction.Label()
+	e.framesProcessed.WithLabelValues(processors.ProcessorName(event.Destination), frames.TypeName(event.Frame), direction).Inc()
+
+	// A MetricsFrame is observed at each hop, only its first push (by the processor measured) counts.
+	if metricsFrame, ok := event.Frame.(*frames.MetricsFrame); ok {
+		for _, ttfb := range metricsFrame.TTFB {
+			if ttfb.Processor == event.Source.Name() {
+				e.ttfb.WithLabelValues(ttfb.Processor, ttfb.Model).Observe(ttfb.Value.Seconds())
+			}
+		}
+		for _, processing := range metricsFrame.Processing {
+			if processing.Processor == event.Source.Name() {
+				e.processing.WithLabelValues(processing.Processor, processing.Model).Observe(processing.Value.Seconds())
+			}
+		}
+	}
+}
+
+// OnDropFrame implements processors.FrameObserver.
+func (e *Exporter) OnDropFrame(event processors.FrameDropEvent) {
+	e.framesDropped.WithLabelValues(processors.ProcessorName(event.Processor), frames.TypeName(event.Frame), event.Direction.Label(), event.Reason).Inc()
+}
+
+// processorsCollector collects the metrics read from the observed processors at scrape time.
+type processorsCollector Exporter
+
+func (c *processorsCollector) Describe(ch chan<- *prom.Desc) {
+	ch <- c.queueDepthDesc
+	ch <- c.panicsDesc
+}
+
+func (c *processorsCollector) Collect(ch chan<- prom.Metric) {
+	c.mu.Lock()
+	procs := c.processors
+	c.mu.Unlock()
+
+	type queueKey struct{ processor, direction string }
+	queueDepths := make(map[queueKey]int)
+	panics := make(map[string]uint64)
+	for _, processor := range procs {
+		if q, ok := processor.(processors.QueueProcessor); ok {
+			for _, direction := range []processors.FrameDirection{processors.FrameDirectionDownstream, processors.FrameDirectionUpstream} {
+				queueDepths[queueKey{processors.ProcessorName(processor), direction.Label()}] += q.QueueLen(direction)
+			}
+		}
+		if p, ok := processor.(panicCounter); ok {
+			panics[processors.ProcessorName(processor)] += p.PanicCount()
+		}
+	}
+
+	for key, depth := range queueDepths {
+		ch <- prom.MustNewConstMetric(c.queueDepthDesc, prom.GaugeValue, float64(depth), key.processor, key.direction)
+	}
+	for processor, count := range panics {
+		ch <- prom.MustNewConstMetric(c.panicsDesc, prom.CounterValue, float64(count), processor)
+	}
+}
@@ -0,0 +1,112 @@
+package prometheus
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/pipeline"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+// serviceProcessor reports TTFB and processing metrics for each TextFrame,
+// and panics on the "panic" one.
+type serviceProcessor struct {
+	*processors.FrameProcessor
+	started chan struct{}
+}
+
+func (p *serviceProcessor) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	p.FrameProcessor.ProcessFrame(frame, direction)
+	if _, ok := frame.(*frames.StartFrame); ok {
+		close(p.started)
+	}
+	if text, ok := frame.(*frames.TextFrame); ok {
+		p.StartTTFBMetrics()
+		p.StartProcessingMetrics()
+		p.StopTTFBMetrics()
+		p.StopProcessingMetrics()
+		if text.Text == "panic" {
+			panic("service crashed")
+		}
+	}
+	p.PushFrame(frame, direction)
+}
+
+// blockingProcessor blocks on TextFrames until released.
+type blockingProcessor struct {
+	*processors.FrameProcessor
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingProcessor) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	if _, ok := frame.(*frames.TextFrame); ok {
+		p.entered <- struct{}{}
+		<-p.release
+	}
+	p.PushFrame(frame, direction)
+}
+
+func scrape(t *testing.T, server *httptest.Server) string {
+	resp, err := http.Get(server.URL + "/metrics")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	return string(body)
+}
+
+func TestExporter(t *testing.T) {
+	service := &serviceProcessor{FrameProcessor: processors.NewFrameProcessor("service"), started: make(chan struct{})}
+	service.SetModelName("test-model")
+	async := processors.NewAsyncFrameProcessorWithPushQueueSize("async", 1, 1).WithPorcessFrameAllowPush(true)
+	blocking := &blockingProcessor{
+		FrameProcessor: processors.NewFrameProcessor("blocking"),
+		entered:        make(chan struct{}, 1),
+		release:        make(chan struct{}),
+	}
+	pl := pipeline.NewPipeline([]processors.IFrameProcessor{async, service, blocking}, nil, nil)
+	task := pipeline.NewPipelineTask(pl, pipeline.PipelineParams{EnableMetrics: true})
+
+	exporter := NewExporter()
+	exporter.Observe(task)
+	server := httptest.NewServer(exporter.Handler())
+	defer server.Close()
+
+	// Each frame is queued once the async worker took the previous one out of its queue of 1.
+	go task.Run()
+	<-service.started
+	task.QueueFrame(frames.NewTextFrame("panic"))
+	assert.Eventually(t, func() bool { return service.PanicCount() == 1 }, 2*time.Second, time.Millisecond)
+	// The "block" frame blocks the async worker, the next one waits in the queue, the last one is dropped.
+	task.QueueFrame(frames.NewTextFrame("block"))
+	<-blocking.entered
+	task.QueueFrame(frames.NewTextFrame("queued"))
+	assert.Eventually(t, func() bool { return async.QueueLen(processors.FrameDirectionDownstream) == 1 }, 2*time.Second, time.Millisecond)
+	task.QueueFrame(frames.NewTextFrame("dropped"))
+
+	assert.Eventually(t, func() bool {
+		return strings.Contains(scrape(t, server), `pipeline_frames_dropped_total{direction="downstream",frame="TextFrame",processor="async",reason="queue_full"} 1`)
+	}, 2*time.Second, 10*time.Millisecond)
+	body := scrape(t, server)
+	assert.Contains(t, body, `pipeline_queue_depth{direction="downstream",processor="async"} 1`)
+	assert.Contains(t, body, `pipeline_panics_total{processor="service"} 1`)
+	assert.Contains(t, body, `pipeline_ttfb_seconds_count{model="test-model",processor="service"} 2`)
+	assert.Contains(t, body, `pipeline_processing_seconds_count{model="test-model",processor="service"} 2`)
+	assert.Contains(t, body, `pipeline_frames_processed_total{direction="downstream",frame="TextFrame",processor="service"} 2`)
+	assert.Contains(t, body, `pipeline_frames_processed_total{direction="upstream",frame="ErrorFrame",processor="PipelineSource"} 1`)
+
+	close(blocking.release)
+	select {
+	case <-blocking.entered:
+	case <-time.After(2 * time.Second):
+		t.Fatal("queued frame not pushed")
+	}
+	task.StopWhenDone()
+}
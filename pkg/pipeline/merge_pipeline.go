@@ -71,6 +71,11 @@ func (mp *MergePipeline) ProcessFrame(frame frames.Frame, direction processors.F
 	wg.Wait()
 }
 
+// Processors returns the merged pipelines.
+func (mp *MergePipeline) Processors() []processors.IFrameProcessor {
+	return mp.pipelines
+}
+
 // GetOutput returns the merged output channel.
 func (mp *MergePipeline) GetOutput() <-chan frames.Frame {
 	return mp.outQueue
@@ -75,6 +75,19 @@ func (pp *ParallelPipeline) ProcessFrame(frame frames.Frame, direction processor
 	wg.Wait()
 }
 
+// Processors returns the parallel pipelines.
+func (pp *ParallelPipeline) Processors() []processors.IFrameProcessor {
+	return pp.pipelines
+}
+
+// QueueLen returns the number of frames out of the pipelines waiting to be pushed in direction.
+func (pp *ParallelPipeline) QueueLen(direction processors.FrameDirection) int {
+	if direction == processors.FrameDirectionUpstream {
+		return len(pp.upQueue)
+	}
+	return len(pp.downQueue)
+}
+
 // startQueueProcessors starts the goroutines that fan-in results from the parallel pipelines.
 func (pp *ParallelPipeline) startQueueProcessors() {
 	pp.wg.Add(2)
@@ -138,6 +138,11 @@ func (p *Pipeline) ProcessFrame(frame frames.Frame, direction processors.FrameDi
 	}
 }
 
+// Processors returns the processors of the pipeline, its source and sink included.
+func (p *Pipeline) Processors() []processors.IFrameProcessor {
+	return p.processors
+}
+
 func (p *Pipeline) Cleanup() {
 	for _, proc := range p.processors {
 		proc.Cleanup()
@@ -0,0 +1,56 @@
+package pipeline_test
+
+import (
+	"testing"
+
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/pipeline"
+	"github.com/weedge/pipeline-go/pkg/pipeline/pipelinetest"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+func TestSimple(t *testing.T) {
+	sent := []frames.Frame{
+		frames.NewTextFrame("你好"),
+		frames.NewImageRawFrame([]byte{}, frames.ImageSize{Width: 0, Height: 0}, "PNG", "RGB"),
+		frames.NewAudioRawFrame([]byte{}, 16000, 1, 2),
+	}
+	pipelinetest.RunTest(t,
+		[]processors.IFrameProcessor{processors.NewDefaultFrameLoggerProcessor()},
+		sent, sent, nil,
+	)
+}
+
+func TestParallelPipeline(t *testing.T) {
+	pipelinetest.RunTest(t,
+		[]processors.IFrameProcessor{
+			processors.NewDefaultFrameLoggerProcessorWithName("P1"),
+			pipeline.NewParallelPipeline(
+				[]processors.IFrameProcessor{processors.NewDefaultFrameLoggerProcessorWithName("P1.1")},
+				[]processors.IFrameProcessor{processors.NewDefaultFrameLoggerProcessorWithName("P1.2")},
+			),
+			processors.NewDefaultFrameLoggerProcessorWithName("P3"),
+		},
+		[]frames.Frame{frames.NewTextFrame("你好")},
+		// Each branch pushes the frame out.
+		[]frames.Frame{frames.NewTextFrame("你好"), frames.NewTextFrame("你好")},
+		nil,
+	)
+}
+
+func TestSyncParallelPipeline(t *testing.T) {
+	pipelinetest.RunTest(t,
+		[]processors.IFrameProcessor{
+			processors.NewDefaultFrameLoggerProcessor(),
+			pipeline.NewSyncParallelPipeline(
+				pipeline.NewPipeline([]processors.IFrameProcessor{processors.NewDefaultFrameLoggerProcessor()}, nil, nil),
+				pipeline.NewPipeline([]processors.IFrameProcessor{processors.NewDefaultFrameLoggerProcessor()}, nil, nil),
+			),
+			processors.NewDefaultFrameLoggerProcessor(),
+		},
+		[]frames.Frame{frames.NewTextFrame("你好")},
+		// Each branch pushes the frame out.
+		[]frames.Frame{frames.NewTextFrame("你好"), frames.NewTextFrame("你好")},
+		nil,
+	)
+}
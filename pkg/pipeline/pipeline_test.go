@@ -1,7 +1,6 @@
 package pipeline
 
 import (
-	"fmt"
 	"sync"
 	"testing"
 	"time"
@@ -14,135 +13,6 @@ import (
 	"github.com/weedge/pipeline-go/pkg/processors/filters"
 )
 
-func TestSimple(t *testing.T) {
-	pipeline := NewPipeline([]processors.IFrameProcessor{
-		processors.NewDefaultFrameLoggerProcessor(),
-	}, nil, nil)
-	fmt.Println(pipeline)
-
-	task := NewPipelineTask(pipeline, PipelineParams{})
-
-	task.QueueFrame(frames.NewTextFrame("你好"))
-	task.QueueFrame(frames.NewImageRawFrame([]byte{}, frames.ImageSize{Width: 0, Height: 0}, "PNG", "RGB"))
-	task.QueueFrame(frames.NewAudioRawFrame([]byte{}, 16000, 1, 2))
-	task.QueueFrame(frames.NewEndFrame())
-
-	task.Run()
-}
-
-func TestParallelPipeline(t *testing.T) {
-	pipeline := NewPipeline([]processors.IFrameProcessor{
-		processors.NewDefaultFrameLoggerProcessorWithName("P1"),
-		NewParallelPipeline(
-			[]processors.IFrameProcessor{processors.NewDefaultFrameLoggerProcessorWithName("P1.1")},
-			[]processors.IFrameProcessor{processors.NewDefaultFrameLoggerProcessorWithName("P1.2")},
-		),
-		processors.NewDefaultFrameLoggerProcessorWithName("P3"),
-	}, nil, nil)
-	fmt.Println(pipeline)
-
-	task := NewPipelineTask(pipeline, PipelineParams{})
-
-	task.QueueFrame(frames.NewTextFrame("你好"))
-	task.QueueFrame(frames.NewEndFrame())
-
-	task.Run()
-}
-
-func TestSyncParallelPipeline(t *testing.T) {
-	pipeline := NewPipeline([]processors.IFrameProcessor{
-		processors.NewDefaultFrameLoggerProcessor(),
-		NewSyncParallelPipeline(
-			NewPipeline([]processors.IFrameProcessor{processors.NewDefaultFrameLoggerProcessor()}, nil, nil),
-			NewPipeline([]processors.IFrameProcessor{processors.NewDefaultFrameLoggerProcessor()}, nil, nil),
-		),
-		processors.NewDefaultFrameLoggerProcessor(),
-	}, nil, nil)
-	fmt.Println(pipeline)
-
-	task := NewPipelineTask(pipeline, PipelineParams{})
-
-	task.QueueFrame(frames.NewTextFrame("你好"))
-	task.QueueFrame(frames.NewEndFrame())
-
-	task.Run()
-}
-
-func TestFunctionFilter(t *testing.T) {
-	text := "你好"
-
-	textFilter := func(frame frames.Frame) bool {
-		if textFrame, ok := frame.(*frames.TextFrame); ok {
-			if textFrame.Text == text {
-				return false
-			}
-		}
-		return true
-	}
-
-	imageFilter := func(frame frames.Frame) bool {
-		if _, ok := frame.(*frames.ImageRawFrame); ok {
-			return false
-		}
-		return true
-	}
-
-	pipeline := NewPipeline([]processors.IFrameProcessor{
-		filters.NewFrameFilter(textFilter),
-		filters.NewCheckFilter(t, text, false),
-		filters.NewFrameFilter(imageFilter),
-		filters.NewCheckFilter(t, text, true),
-	}, nil, nil)
-
-	task := NewPipelineTask(pipeline, PipelineParams{})
-
-	task.QueueFrame(frames.NewTextFrame(text))
-	task.QueueFrame(frames.NewTextFrame("你好!"))
-	task.QueueFrame(frames.NewImageRawFrame([]byte{}, frames.ImageSize{Width: 0, Height: 0}, "PNG", "RGB"))
-	task.QueueFrame(frames.NewAudioRawFrame([]byte{}, 16000, 1, 2))
-	task.QueueFrame(frames.NewEndFrame())
-
-	task.Run()
-}
-
-func TestTypeFilter(t *testing.T) {
-	var collectedFrames []frames.Frame
-	var mu sync.Mutex
-
-	collector := processors.NewOutputProcessor(func(frame frames.Frame) {
-		// We don't want to collect control frames
-		switch frame.(type) {
-		case *frames.StartFrame, *frames.EndFrame:
-			return
-		}
-		mu.Lock()
-		defer mu.Unlock()
-		collectedFrames = append(collectedFrames, frame)
-	})
-
-	pipeline := NewPipeline([]processors.IFrameProcessor{
-		// This filter should only allow TextFrames and AudioRawFrames to pass.
-		filters.NewTypeFilter([]any{&frames.TextFrame{}, &frames.AudioRawFrame{}}),
-		collector,
-	}, nil, nil)
-
-	task := NewPipelineTask(pipeline, PipelineParams{})
-
-	task.QueueFrame(frames.NewTextFrame("one"))
-	task.QueueFrame(frames.NewImageRawFrame([]byte{}, frames.ImageSize{Width: 0, Height: 0}, "PNG", "RGB"))
-	task.QueueFrame(frames.NewAudioRawFrame([]byte{}, 16000, 1, 2))
-	task.QueueFrame(frames.NewTextFrame("two"))
-	task.QueueFrame(frames.NewEndFrame())
-
-	task.Run()
-
-	// Assert that only the correct frames were collected
-	assert.Len(t, collectedFrames, 3)
-	assert.IsType(t, &frames.TextFrame{}, collectedFrames[0])
-	assert.IsType(t, &frames.AudioRawFrame{}, collectedFrames[1])
-	assert.IsType(t, &frames.TextFrame{}, collectedFrames[2])
-}
-
 func TestHoldFramesAggregator(t *testing.T) {
 	notifier := notifiers.NewChannelNotifier()
 
@@ -288,3 +158,125 @@ func TestGatedAggregator(t *testing.T) {
 	assert.IsType(t, &frames.ImageRawFrame{}, collectedFrames[2])
 	assert.IsType(t, &frames.TextFrame{}, collectedFrames[3])
 }
+
+func TestInterruptionDropsBufferedFrames(t *testing.T) {
+	var mu sync.Mutex
+	var after []frames.Frame
+	interrupted := false
+	collector := processors.NewOutputProcessor(func(frame frames.Frame) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch frame.(type) {
+		case *frames.StartInterruptionFrame:
+			interrupted = true
+		case *frames.TextFrame, *frames.ImageRawFrame:
+			if interrupted {
+				after = append(after, frame)
+			}
+		}
+	})
+
+	notifier := notifiers.NewChannelNotifier()
+	pipeline := NewPipeline([]processors.IFrameProcessor{
+		aggregators.NewSentenceAggregator(),
+		aggregators.NewHoldFramesAggregator([]interface{}{&frames.ImageRawFrame{}}, notifier),
+		collector,
+	}, nil, nil)
+
+	task := NewPipelineTask(pipeline, PipelineParams{AllowInterruptions: true})
+	task.QueueFrame(frames.NewTextFrame("A sentence the user"))
+	task.QueueFrame(frames.NewImageRawFrame([]byte{}, frames.ImageSize{}, "JPEG", "RGB"))
+	task.QueueFrame(frames.NewStartInterruptionFrame())
+	task.QueueFrame(frames.NewTextFrame("Fresh."))
+	task.QueueFrame(frames.NewEndFrame())
+	task.Run()
+
+	// Neither the partial sentence nor the held image survive the interruption.
+	notifier.Notify()
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.True(t, interrupted)
+	assert.Equal(t, 1, len(after))
+	assert.Equal(t, "Fresh.", after[0].(*frames.TextFrame).Text)
+}
+
+// panicProcessor panics on TextFrames.
+type panicProcessor struct {
+	*processors.FrameProcessor
+}
+
+func (p *panicProcessor) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	if _, ok := frame.(*frames.TextFrame); ok {
+		panic("branch crashed")
+	}
+	p.PushFrame(frame, direction)
+}
+
+func TestParallelPipelineBranchPanic(t *testing.T) {
+	var mu sync.Mutex
+	var errs []*frames.ErrorFrame
+	upstream := func(frame frames.Frame, direction processors.FrameDirection) {
+		if errFrame, ok := frame.(*frames.ErrorFrame); ok {
+			mu.Lock()
+			errs = append(errs, errFrame)
+			mu.Unlock()
+		}
+	}
+
+	panicker := &panicProcessor{FrameProcessor: processors.NewFrameProcessor("panicker")}
+	parallel := NewParallelPipeline(
+		[]processors.IFrameProcessor{panicker},
+		[]processors.IFrameProcessor{processors.NewDefaultFrameLoggerProcessorWithName("P1.2")},
+	)
+	pipeline := NewPipeline([]processors.IFrameProcessor{parallel}, upstream, nil)
+
+	pipeline.ProcessFrame(frames.NewTextFrame("你好"), processors.FrameDirectionDownstream)
+	pipeline.ProcessFrame(frames.NewEndFrame(), processors.FrameDirectionDownstream)
+	parallel.Cleanup()
+
+	// The panic in the branch is reported upstream out of the parallel pipeline.
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, len(errs))
+	assert.False(t, errs[0].Fatal)
+	var panicErr *processors.PanicError
+	assert.ErrorAs(t, errs[0].Error, &panicErr)
+	assert.Equal(t, "panicker", panicErr.Processor)
+	assert.Equal(t, "branch crashed", panicErr.Value)
+	assert.Equal(t, uint64(1), panicker.PanicCount())
+}
+
+func TestPipelineStats(t *testing.T) {
+	panicker := &panicProcessor{FrameProcessor: processors.NewFrameProcessor("panicker")}
+	pipeline := NewPipeline([]processors.IFrameProcessor{
+		processors.NewDefaultFrameLoggerProcessorWithName("P1"),
+		NewParallelPipeline(
+			[]processors.IFrameProcessor{panicker},
+			[]processors.IFrameProcessor{processors.NewDefaultFrameLoggerProcessorWithName("P2.2")},
+		),
+		processors.NewDefaultFrameLoggerProcessorWithName("P3"),
+	}, nil, nil)
+
+	pipeline.ProcessFrame(frames.NewTextFrame("你好"), processors.FrameDirectionDownstream)
+	pipeline.ProcessFrame(frames.NewEndFrame(), processors.FrameDirectionDownstream)
+	pipeline.Cleanup()
+
+	stats := pipeline.Stats()
+	names := []string{}
+	var framesIn uint64
+	for _, s := range stats.Processors {
+		names = append(names, s.Name)
+		framesIn += s.FramesIn.Downstream + s.FramesIn.Upstream
+	}
+	// Nested branches are included, pipelines, sources and sinks are not.
+	assert.Equal(t, []string{"P1", "panicker", "P2.2", "P3"}, names)
+	// P1 gets the ErrorFrame of the panic upstream.
+	assert.Equal(t, processors.DirectionCounts{Downstream: 2, Upstream: 1}, stats.Processors[0].FramesIn)
+	assert.Equal(t, uint64(2), stats.Processors[1].FramesIn.Downstream)
+	assert.Equal(t, framesIn, stats.FramesIn.Downstream+stats.FramesIn.Upstream)
+	assert.Equal(t, uint64(1), stats.Panics)
+	assert.Equal(t, uint64(1), stats.Errors)
+	assert.Equal(t, framesIn, stats.Latency.Count)
+	assert.False(t, stats.LastFrameTime.IsZero())
+}
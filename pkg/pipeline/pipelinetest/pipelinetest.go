@@ -0,0 +1,264 @@
+// Package pipelinetest runs processors inside a real PipelineTask and asserts
+// the frames coming out of them, for processor tests.
+package pipelinetest
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weedge/pipeline-go/pkg/clock"
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/pipeline"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+// DefaultTimeout is the default time a test has to run its frames through the processors.
+const DefaultTimeout = 5 * time.Second
+
+// SleepFrame is not queued to the task: RunTest waits Duration before queueing the next frame.
+type SleepFrame struct {
+	Duration time.Duration
+}
+
+// Sleep returns a SleepFrame of d, to send frames apart.
+func Sleep(d time.Duration) *SleepFrame {
+	return &SleepFrame{Duration: d}
+}
+
+func (f *SleepFrame) ID() uint64     { return 0 }
+func (f *SleepFrame) Name() string   { return "SleepFrame" }
+func (f *SleepFrame) String() string { return fmt.Sprintf("SleepFrame(%s)", f.Duration) }
+
+// TimedFrame is an expected frame received in a time window since the frames were first sent.
+// A zero Max has no upper bound.
+type TimedFrame struct {
+	frames.Frame
+	Min time.Duration
+	Max time.Duration
+}
+
+// After expects frame to be received at least d after the frames were first sent.
+func After(d time.Duration, frame frames.Frame) *TimedFrame {
+	return &TimedFrame{Frame: frame, Min: d}
+}
+
+// Within expects frame to be received at most d after the frames were first sent.
+func Within(d time.Duration, frame frames.Frame) *TimedFrame {
+	return &TimedFrame{Frame: frame, Max: d}
+}
+
+// ReceivedFrame is a frame out of the processors, At is since the frames were first sent.
+type ReceivedFrame struct {
+	Frame frames.Frame
+	At    time.Duration
+}
+
+// Result holds the frames out of the processors, for further assertions.
+type Result struct {
+	Down []ReceivedFrame
+	Up   []ReceivedFrame
+}
+
+// Option configures RunTest.
+type Option func(*config)
+
+type config struct {
+	params              pipeline.PipelineParams
+	timeout             time.Duration
+	clock               clock.Clock
+	ignoreMetricsFrames bool
+	ignoreFields        []string
+}
+
+// WithParams sets the params of the task, e.g. to allow interruptions or enable metrics.
+func WithParams(params pipeline.PipelineParams) Option {
+	return func(c *config) { c.params = params }
+}
+
+// WithTimeout sets the time the test has to run, DefaultTimeout by default.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *config) { c.timeout = timeout }
+}
+
+// WithClock sets the clock of the task and its processors, e.g. a clock.Fake
+// for metrics values known in advance. The frames are still sent and timed
+// with the real clock.
+func WithClock(c clock.Clock) Option {
+	return func(cfg *config) { cfg.clock = c }
+}
+
+// IgnoreMetricsFrames leaves the MetricsFrames out of the received frames.
+func IgnoreMetricsFrames() Option {
+	return func(c *config) { c.ignoreMetricsFrames = true }
+}
+
+// IgnoreFields leaves the frame fields with these names out of the comparisons,
+// e.g. fields set from the clock.
+func IgnoreFields(names ...string) Option {
+	return func(c *config) { c.ignoreFields = append(c.ignoreFields, names...) }
+}
+
+// RunTest runs procs in a PipelineTask, queues sendFrames then an EndFrame
+// (unless sendFrames ends with an EndFrame or a StopTaskFrame), and asserts the
+// frames out of the processors downstream and upstream are expectDown and expectUp.
+// The StartFrame and EndFrame are left out of the downstream frames.
+//
+// Frames are compared in order, by type and exported fields (the BaseFrame ID and
+// name are left out), and by time for the TimedFrames. Frames are sent apart with
+// SleepFrames.
+func RunTest(t testing.TB, procs []processors.IFrameProcessor, sendFrames, expectDown, expectUp []frames.Frame, opts ...Option) *Result {
+	t.Helper()
+	cfg := &config{timeout: DefaultTimeout}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	var mu sync.Mutex
+	result := &Result{}
+	endReceived := make(chan struct{})
+	var endOnce sync.Once
+	var start time.Time
+	record := func(received *[]ReceivedFrame) func(frames.Frame, processors.FrameDirection) {
+		return func(frame frames.Frame, direction processors.FrameDirection) {
+			switch frame.(type) {
+			case *frames.StartFrame:
+				return
+			case *frames.EndFrame:
+				endOnce.Do(func() { close(endReceived) })
+				return
+			case *frames.MetricsFrame:
+				if cfg.ignoreMetricsFrames {
+					return
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			*received = append(*received, ReceivedFrame{Frame: frame, At: time.Since(start)})
+		}
+	}
+	pl := pipeline.NewPipeline(procs, record(&result.Up), record(&result.Down))
+	task := pipeline.NewPipelineTask(pl, cfg.params)
+	if cfg.clock != nil {
+		task.SetClock(cfg.clock)
+	}
+
+	start = time.Now()
+	done := make(chan struct{})
+	go func() {
+		task.Run()
+		close(done)
+	}()
+	for _, frame := range sendFrames {
+		if sleep, ok := frame.(*SleepFrame); ok {
+			time.Sleep(sleep.Duration)
+			continue
+		}
+		task.QueueFrame(frame)
+	}
+	// A StopTaskFrame stops the task without an EndFrame going through.
+	var last frames.Frame
+	if n := len(sendFrames); n > 0 {
+		last = sendFrames[n-1]
+	}
+	_, stopping := last.(*frames.StopTaskFrame)
+	if _, ending := last.(*frames.EndFrame); !ending && !stopping {
+		task.QueueFrame(frames.NewEndFrame())
+	}
+
+	timeout := time.After(cfg.timeout)
+	select {
+	case <-done:
+	case <-timeout:
+		t.Errorf("pipeline task not finished after %s", cfg.timeout)
+		return result
+	}
+	if !stopping {
+		select {
+		case <-endReceived:
+		case <-timeout:
+			t.Errorf("EndFrame not received downstream after %s", cfg.timeout)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assertFrames(t, cfg, "downstream", expectDown, result.Down)
+	assertFrames(t, cfg, "upstream", expectUp, result.Up)
+	return result
+}
+
+// frameView is what is compared of a frame.
+type frameView struct {
+	Type   string
+	Fields map[string]any
+}
+
+func assertFrames(t testing.TB, cfg *config, direction string, expected []frames.Frame, received []ReceivedFrame) {
+	t.Helper()
+	expectedViews := make([]frameView, 0, len(expected))
+	for _, frame := range expected {
+		if timed, ok := frame.(*TimedFrame); ok {
+			frame = timed.Frame
+		}
+		expectedViews = append(expectedViews, viewOf(cfg, frame))
+	}
+	receivedViews := make([]frameView, 0, len(received))
+	for _, r := range received {
+		receivedViews = append(receivedViews, viewOf(cfg, r.Frame))
+	}
+	if !assert.Equal(t, expectedViews, receivedViews, "%s frames", direction) {
+		return
+	}
+
+	for i, frame := range expected {
+		timed, ok := frame.(*TimedFrame)
+		if !ok {
+			continue
+		}
+		at := received[i].At
+		if at < timed.Min {
+			t.Errorf("%s frame %d (%s) received after %s, expected after at least %s", direction, i, expectedViews[i].Type, at, timed.Min)
+		}
+		if timed.Max > 0 && at > timed.Max {
+			t.Errorf("%s frame %d (%s) received after %s, expected within %s", direction, i, expectedViews[i].Type, at, timed.Max)
+		}
+	}
+}
+
+var baseFrameType = reflect.TypeOf(&frames.BaseFrame{})
+
+func viewOf(cfg *config, frame frames.Frame) frameView {
+	view := frameView{Type: fmt.Sprintf("%T", frame), Fields: make(map[string]any)}
+	collectFields(reflect.ValueOf(frame), view.Fields)
+	for _, name := range cfg.ignoreFields {
+		delete(view.Fields, name)
+	}
+	return view
+}
+
+// collectFields collects the exported fields of v and of the structs it embeds, but the BaseFrame.
+func collectFields(v reflect.Value, fields map[string]any) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() || v.Type() == baseFrameType {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		switch {
+		case field.Anonymous:
+			collectFields(v.Field(i), fields)
+		case field.IsExported():
+			fields[field.Name] = v.Field(i).Interface()
+		}
+	}
+}
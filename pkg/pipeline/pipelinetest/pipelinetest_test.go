@@ -0,0 +1,151 @@
+package pipelinetest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weedge/pipeline-go/pkg/clock"
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/pipeline"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+// fakeT records the errors of a failing test.
+type fakeT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+// upperProcessor uppercases the TextFrames, reports the empty ones upstream
+// and measures its processing when metrics are enabled.
+type upperProcessor struct {
+	*processors.FrameProcessor
+}
+
+func newUpperProcessor() *upperProcessor {
+	return &upperProcessor{FrameProcessor: processors.NewFrameProcessor("upper")}
+}
+
+func (p *upperProcessor) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	p.FrameProcessor.ProcessFrame(frame, direction)
+	text, ok := frame.(*frames.TextFrame)
+	if !ok {
+		p.PushFrame(frame, direction)
+		return
+	}
+	if text.Text == "" {
+		p.PushError(frames.NewErrorFrame(errors.New("empty text"), false))
+		return
+	}
+	p.StartProcessingMetrics()
+	p.PushFrame(frames.NewTextFrame(strings.ToUpper(text.Text)), direction)
+	p.StopProcessingMetrics()
+}
+
+func TestRunTest(t *testing.T) {
+	result := RunTest(t,
+		[]processors.IFrameProcessor{newUpperProcessor()},
+		[]frames.Frame{frames.NewTextFrame("hello"), frames.NewTextFrame(""), frames.NewImageRawFrame([]byte{1}, frames.ImageSize{Width: 1, Height: 1}, "PNG", "RGB")},
+		[]frames.Frame{frames.NewTextFrame("HELLO"), frames.NewImageRawFrame([]byte{1}, frames.ImageSize{Width: 1, Height: 1}, "PNG", "RGB")},
+		[]frames.Frame{frames.NewErrorFrame(errors.New("empty text"), false)},
+	)
+	assert.Equal(t, 2, len(result.Down))
+	assert.Equal(t, 1, len(result.Up))
+}
+
+func TestRunTest_Mismatch(t *testing.T) {
+	ft := &fakeT{TB: t}
+	RunTest(ft,
+		[]processors.IFrameProcessor{newUpperProcessor()},
+		[]frames.Frame{frames.NewTextFrame("hello")},
+		[]frames.Frame{frames.NewTextFrame("hello")},
+		nil,
+	)
+	assert.Equal(t, 1, len(ft.errors))
+	assert.Contains(t, ft.errors[0], "downstream frames")
+	assert.Contains(t, ft.errors[0], `"Text": (string) (len=5) "hello"`)
+	assert.Contains(t, ft.errors[0], `"Text": (string) (len=5) "HELLO"`)
+
+	ft = &fakeT{TB: t}
+	RunTest(ft,
+		[]processors.IFrameProcessor{newUpperProcessor()},
+		[]frames.Frame{frames.NewTextFrame("hello")},
+		[]frames.Frame{frames.NewEndFrame()},
+		nil,
+	)
+	assert.Equal(t, 1, len(ft.errors))
+	assert.Contains(t, ft.errors[0], `"*frames.EndFrame"`)
+}
+
+func TestRunTest_Timing(t *testing.T) {
+	RunTest(t,
+		[]processors.IFrameProcessor{newUpperProcessor()},
+		[]frames.Frame{frames.NewTextFrame("now"), Sleep(50 * time.Millisecond), frames.NewTextFrame("later")},
+		[]frames.Frame{Within(40*time.Millisecond, frames.NewTextFrame("NOW")), After(50*time.Millisecond, frames.NewTextFrame("LATER"))},
+		nil,
+	)
+
+	ft := &fakeT{TB: t}
+	RunTest(ft,
+		[]processors.IFrameProcessor{newUpperProcessor()},
+		[]frames.Frame{frames.NewTextFrame("now")},
+		[]frames.Frame{After(time.Second, frames.NewTextFrame("NOW"))},
+		nil,
+	)
+	assert.Equal(t, 1, len(ft.errors))
+	assert.Contains(t, ft.errors[0], "expected after at least 1s")
+}
+
+func TestRunTest_Metrics(t *testing.T) {
+	params := pipeline.PipelineParams{EnableMetrics: true}
+	result := RunTest(t,
+		[]processors.IFrameProcessor{newUpperProcessor()},
+		[]frames.Frame{frames.NewTextFrame("hello")},
+		[]frames.Frame{frames.NewTextFrame("HELLO")},
+		nil,
+		WithParams(params), IgnoreMetricsFrames(),
+	)
+	assert.Equal(t, 1, len(result.Down))
+
+	result = RunTest(t,
+		[]processors.IFrameProcessor{newUpperProcessor()},
+		[]frames.Frame{frames.NewTextFrame("hello")},
+		[]frames.Frame{frames.NewTextFrame("HELLO"), frames.NewMetricsFrame()},
+		nil,
+		WithParams(params), IgnoreFields("Processing"),
+	)
+	metrics := result.Down[1].Frame.(*frames.MetricsFrame)
+	assert.Equal(t, "upper", metrics.Processing[0].Processor)
+}
+
+func TestRunTest_Clock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	metrics := frames.NewMetricsFrame()
+	metrics.Processing = []frames.ProcessingMetricsData{{MetricsData: frames.MetricsData{Processor: "upper", Timestamp: fake.Now()}}}
+	RunTest(t,
+		[]processors.IFrameProcessor{newUpperProcessor()},
+		[]frames.Frame{frames.NewTextFrame("hello")},
+		[]frames.Frame{frames.NewTextFrame("HELLO"), metrics},
+		nil,
+		WithParams(pipeline.PipelineParams{EnableMetrics: true}), WithClock(fake),
+	)
+}
+
+func TestRunTest_StopTaskFrame(t *testing.T) {
+	RunTest(t,
+		[]processors.IFrameProcessor{newUpperProcessor()},
+		[]frames.Frame{frames.NewTextFrame("hello"), frames.NewStopTaskFrame()},
+		[]frames.Frame{frames.NewTextFrame("HELLO"), frames.NewStopTaskFrame()},
+		nil,
+	)
+}
@@ -0,0 +1,43 @@
+package pipeline
+
+import (
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+// PipelineStats is a snapshot of the statistics of the processors of a pipeline.
+type PipelineStats struct {
+	// ProcessorStats aggregates the statistics of the processors.
+	processors.ProcessorStats
+	// Processors are the statistics of each processor, in WalkProcessors order.
+	Processors []processors.ProcessorStats
+}
+
+// statsProcessor is a processor with statistics, e.g. embedding a FrameProcessor.
+type statsProcessor interface {
+	Stats() processors.ProcessorStats
+}
+
+// Stats returns the statistics of the processors of the pipeline, including
+// the processors of nested (parallel) pipelines and wrapped processors.
+// The pipelines themselves and their sources and sinks are left out.
+func (p *Pipeline) Stats() PipelineStats {
+	stats := PipelineStats{ProcessorStats: processors.ProcessorStats{Name: p.Name()}}
+	for _, child := range p.processors {
+		WalkProcessors(child, func(processor processors.IFrameProcessor) {
+			switch processor.(type) {
+			case processors.ProcessorContainer, *PipelineSource, *PipelineSink:
+				return
+			}
+			s, ok := processor.(statsProcessor)
+			if !ok {
+				return
+			}
+			processorStats := s.Stats()
+			// Processors embedding a FrameProcessor may override its name.
+			processorStats.Name = processor.Name()
+			stats.Processors = append(stats.Processors, processorStats)
+			stats.Merge(processorStats)
+		})
+	}
+	return stats
+}
@@ -24,6 +24,11 @@ func NewSyncParallelPipeline(pipelines ...processors.IFrameProcessor) *SyncParal
 	}
 }
 
+// Processors returns the parallel pipelines.
+func (spp *SyncParallelPipeline) Processors() []processors.IFrameProcessor {
+	return spp.pipelines
+}
+
 // ProcessFrame fans out frames and waits for all pipelines to return a result.
 func (spp *SyncParallelPipeline) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
 	var wg sync.WaitGroup
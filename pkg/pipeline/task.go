@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/weedge/pipeline-go/pkg/clock"
 	"github.com/weedge/pipeline-go/pkg/frames"
 	"github.com/weedge/pipeline-go/pkg/logger"
 	"github.com/weedge/pipeline-go/pkg/processors"
@@ -64,6 +65,7 @@ type PipelineTask struct {
 	downQueue chan frames.Frame
 	upQueue   chan frames.Frame
 	source    *TaskSource
+	clock     clock.Clock
 	ctx       context.Context
 	cancel    context.CancelFunc
 	wg        sync.WaitGroup
@@ -97,6 +99,74 @@ func NewPipelineTask(pipeline processors.IFrameProcessor, params PipelineParams)
 	return task
 }
 
+type observable interface {
+	AddObserver(observer processors.FrameObserver)
+}
+
+type clockable interface {
+	SetClock(c clock.Clock)
+}
+
+// Pipeline returns the pipeline run by the task.
+func (t *PipelineTask) Pipeline() processors.IFrameProcessor {
+	return t.pipeline
+}
+
+// Processors returns the processors run by the task, see WalkProcessors.
+func (t *PipelineTask) Processors() []processors.IFrameProcessor {
+	var procs []processors.IFrameProcessor
+	collect := func(processor processors.IFrameProcessor) {
+		procs = append(procs, processor)
+	}
+	WalkProcessors(t.source, collect)
+	WalkProcessors(t.pipeline, collect)
+	return procs
+}
+
+// AddObserver adds observer to all the processors of the task.
+// It must be called before Run.
+func (t *PipelineTask) AddObserver(observer processors.FrameObserver) {
+	for _, processor := range t.Processors() {
+		if p, ok := processor.(observable); ok {
+			p.AddObserver(observer)
+		}
+	}
+}
+
+// Clock returns the clock of the task, clock.Real unless set.
+func (t *PipelineTask) Clock() clock.Clock {
+	if t.clock == nil {
+		return clock.Real
+	}
+	return t.clock
+}
+
+// SetClock sets c as the clock of the task and of all its processors, e.g. a
+// clock.Fake to test time-dependent processors without sleeping.
+// It must be called before Run.
+func (t *PipelineTask) SetClock(c clock.Clock) {
+	t.clock = c
+	for _, processor := range t.Processors() {
+		if p, ok := processor.(clockable); ok {
+			p.SetClock(c)
+		}
+	}
+}
+
+// WalkProcessors calls fn for processor then, depth first, for the processors it
+// contains: the processors of pipelines and the processors wrapped by another one.
+func WalkProcessors(processor processors.IFrameProcessor, fn func(processors.IFrameProcessor)) {
+	fn(processor)
+	switch p := processor.(type) {
+	case processors.ProcessorContainer:
+		for _, child := range p.Processors() {
+			WalkProcessors(child, fn)
+		}
+	case processors.ProcessorWrapper:
+		WalkProcessors(p.Wrapped(), fn)
+	}
+}
+
 func (t *PipelineTask) HasFinished() bool {
 	return t.finished
 }
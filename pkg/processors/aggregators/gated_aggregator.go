@@ -10,6 +10,7 @@ type GatedAggregator struct {
 	processors.FrameProcessor
 	gateOpenFn  func(frames.Frame) bool
 	gateCloseFn func(frames.Frame) bool
+	startOpen   bool
 	isGateOpen  bool
 	accumulator []frames.Frame
 	direction   processors.FrameDirection
@@ -22,25 +23,36 @@ func NewGatedAggregator(
 	direction processors.FrameDirection,
 ) *GatedAggregator {
 	return &GatedAggregator{
-		gateOpenFn:  gateOpenFn,
-		gateCloseFn: gateCloseFn,
-		isGateOpen:  startOpen,
-		accumulator: make([]frames.Frame, 0),
-		direction:   direction,
+		FrameProcessor: *processors.NewFrameProcessor("GatedAggregator"),
+		gateOpenFn:     gateOpenFn,
+		gateCloseFn:    gateCloseFn,
+		startOpen:      startOpen,
+		isGateOpen:     startOpen,
+		accumulator:    make([]frames.Frame, 0),
+		direction:      direction,
 	}
 }
 
 // isControlFrame checks if a frame is a control frame that should always pass through.
 func (a *GatedAggregator) isControlFrame(frame frames.Frame) bool {
 	switch frame.(type) {
-	case *frames.StartFrame, *frames.EndFrame, *frames.CancelFrame, *frames.ErrorFrame, *frames.StopTaskFrame, *frames.MetricsFrame, *frames.SyncFrame:
+	case *frames.StartFrame, *frames.EndFrame, *frames.CancelFrame, *frames.ErrorFrame, *frames.StopTaskFrame, *frames.MetricsFrame, *frames.SyncFrame,
+		*frames.StartInterruptionFrame, *frames.StopInterruptionFrame:
 		return true
 	default:
 		return false
 	}
 }
 
+// ProcessFrame passes frames of the configured direction only while the gate is open.
+// An allowed StartInterruptionFrame drops accumulated frames and resets the gate to its start state.
 func (a *GatedAggregator) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	a.FrameProcessor.ProcessFrame(frame, direction)
+	if a.ShouldInterrupt(frame) {
+		a.accumulator = a.accumulator[:0]
+		a.isGateOpen = a.startOpen
+	}
+
 	// Always pass control frames through.
 	if a.isControlFrame(frame) {
 		a.PushFrame(frame, direction)
@@ -26,6 +26,7 @@ func NewHoldFramesAggregator(frameTypes []interface{}, notifier notifiers.Notifi
 		types = append(types, reflect.TypeOf(t))
 	}
 	return &HoldFramesAggregator{
+		FrameProcessor: *processors.NewFrameProcessor("HoldFramesAggregator"),
 		holdFrameTypes: types,
 		notifier:       notifier,
 	}
@@ -44,11 +45,20 @@ func (a *HoldFramesAggregator) startReleaseListener(direction processors.FrameDi
 	}()
 }
 
+// ProcessFrame holds frames of the configured types and passes everything else through.
+// An allowed StartInterruptionFrame drops the held frames.
 func (a *HoldFramesAggregator) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	a.FrameProcessor.ProcessFrame(frame, direction)
 	a.once.Do(func() {
 		a.startReleaseListener(direction)
 	})
 
+	if a.ShouldInterrupt(frame) {
+		a.lock.Lock()
+		a.heldFrames = nil
+		a.lock.Unlock()
+	}
+
 	isHeldType := false
 	frameType := reflect.TypeOf(frame)
 	for _, t := range a.holdFrameTypes {
@@ -84,6 +94,7 @@ func NewHoldLastFrameAggregator(frameTypes []interface{}, notifier notifiers.Not
 		types = append(types, reflect.TypeOf(t))
 	}
 	return &HoldLastFrameAggregator{
+		FrameProcessor: *processors.NewFrameProcessor("HoldLastFrameAggregator"),
 		holdFrameTypes: types,
 		notifier:       notifier,
 	}
@@ -102,11 +113,20 @@ func (a *HoldLastFrameAggregator) startReleaseListener(direction processors.Fram
 	}()
 }
 
+// ProcessFrame holds the last frame of the configured types and passes everything else through.
+// An allowed StartInterruptionFrame drops the held frame.
 func (a *HoldLastFrameAggregator) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	a.FrameProcessor.ProcessFrame(frame, direction)
 	a.once.Do(func() {
 		a.startReleaseListener(direction)
 	})
 
+	if a.ShouldInterrupt(frame) {
+		a.lock.Lock()
+		a.lastFrame = nil
+		a.lock.Unlock()
+	}
+
 	isHeldType := false
 	frameType := reflect.TypeOf(frame)
 	for _, t := range a.holdFrameTypes {
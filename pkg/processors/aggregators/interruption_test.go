@@ -0,0 +1,124 @@
+package aggregators
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/notifiers"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+// startFrame returns a StartFrame with AllowInterruptions set.
+func startFrame(allowInterruptions bool) *frames.StartFrame {
+	f := frames.NewStartFrame()
+	f.AllowInterruptions = allowInterruptions
+	return f
+}
+
+// textAfterInterruption returns the texts received downstream after the first StartInterruptionFrame.
+func (p *mockProcessor) textAfterInterruption() []string {
+	var texts []string
+	interrupted := false
+	for _, f := range p.receivedFrames[processors.FrameDirectionDownstream] {
+		switch f := f.(type) {
+		case *frames.StartInterruptionFrame:
+			interrupted = true
+		case *frames.TextFrame:
+			if interrupted {
+				texts = append(texts, f.Text)
+			}
+		}
+	}
+	return texts
+}
+
+func TestSentenceAggregator_Interruption(t *testing.T) {
+	mockProc := NewMockProcessor()
+	aggregator := NewSentenceAggregator()
+	aggregator.Link(mockProc)
+
+	aggregator.ProcessFrame(startFrame(true), processors.FrameDirectionDownstream)
+	aggregator.ProcessFrame(frames.NewTextFrame("Stale half"), processors.FrameDirectionDownstream)
+	aggregator.ProcessFrame(frames.NewStartInterruptionFrame(), processors.FrameDirectionDownstream)
+	aggregator.ProcessFrame(frames.NewTextFrame("Fresh."), processors.FrameDirectionDownstream)
+	aggregator.ProcessFrame(frames.NewEndFrame(), processors.FrameDirectionDownstream)
+
+	assert.Equal(t, []string{"Fresh."}, mockProc.textAfterInterruption())
+}
+
+func TestSentenceAggregator_InterruptionNotAllowed(t *testing.T) {
+	mockProc := NewMockProcessor()
+	aggregator := NewSentenceAggregator()
+	aggregator.Link(mockProc)
+
+	aggregator.ProcessFrame(startFrame(false), processors.FrameDirectionDownstream)
+	aggregator.ProcessFrame(frames.NewTextFrame("Kept "), processors.FrameDirectionDownstream)
+	aggregator.ProcessFrame(frames.NewStartInterruptionFrame(), processors.FrameDirectionDownstream)
+	aggregator.ProcessFrame(frames.NewTextFrame("text."), processors.FrameDirectionDownstream)
+
+	assert.Equal(t, []string{"Kept text."}, mockProc.textAfterInterruption())
+}
+
+func TestGatedAggregator_Interruption(t *testing.T) {
+	mockProc := NewMockProcessor()
+	isImage := func(f frames.Frame) bool {
+		_, ok := f.(*frames.ImageRawFrame)
+		return ok
+	}
+	isText := func(f frames.Frame) bool {
+		_, ok := f.(*frames.TextFrame)
+		return ok
+	}
+	aggregator := NewGatedAggregator(isImage, isText, false, processors.FrameDirectionDownstream)
+	aggregator.Link(mockProc)
+
+	aggregator.ProcessFrame(startFrame(true), processors.FrameDirectionDownstream)
+	aggregator.ProcessFrame(frames.NewImageRawFrame(nil, frames.ImageSize{}, "", "RGB"), processors.FrameDirectionDownstream)
+	aggregator.ProcessFrame(frames.NewStartInterruptionFrame(), processors.FrameDirectionDownstream)
+	// The gate is closed again, the interrupted turn doesn't leak.
+	aggregator.ProcessFrame(frames.NewTextFrame("stale"), processors.FrameDirectionDownstream)
+
+	assert.Empty(t, mockProc.textAfterInterruption())
+	received := mockProc.GetReceivedFrames(processors.FrameDirectionDownstream)
+	assert.IsType(t, &frames.StartInterruptionFrame{}, received[len(received)-1])
+}
+
+func TestHoldFramesAggregator_Interruption(t *testing.T) {
+	mockProc := NewMockProcessor()
+	notifier := notifiers.NewChannelNotifier()
+	aggregator := NewHoldFramesAggregator([]interface{}{&frames.TextFrame{}}, notifier)
+	aggregator.Link(mockProc)
+
+	aggregator.ProcessFrame(startFrame(true), processors.FrameDirectionDownstream)
+	aggregator.ProcessFrame(frames.NewTextFrame("stale1"), processors.FrameDirectionDownstream)
+	aggregator.ProcessFrame(frames.NewTextFrame("stale2"), processors.FrameDirectionDownstream)
+	aggregator.ProcessFrame(frames.NewStartInterruptionFrame(), processors.FrameDirectionDownstream)
+	aggregator.ProcessFrame(frames.NewTextFrame("fresh"), processors.FrameDirectionDownstream)
+
+	notifier.Notify()
+	time.Sleep(50 * time.Millisecond)
+
+	aggregator.lock.Lock()
+	defer aggregator.lock.Unlock()
+	assert.Equal(t, []string{"fresh"}, mockProc.textAfterInterruption())
+}
+
+func TestHoldLastFrameAggregator_Interruption(t *testing.T) {
+	mockProc := NewMockProcessor()
+	notifier := notifiers.NewChannelNotifier()
+	aggregator := NewHoldLastFrameAggregator([]interface{}{&frames.TextFrame{}}, notifier)
+	aggregator.Link(mockProc)
+
+	aggregator.ProcessFrame(startFrame(true), processors.FrameDirectionDownstream)
+	aggregator.ProcessFrame(frames.NewTextFrame("stale"), processors.FrameDirectionDownstream)
+	aggregator.ProcessFrame(frames.NewStartInterruptionFrame(), processors.FrameDirectionDownstream)
+
+	notifier.Notify()
+	time.Sleep(50 * time.Millisecond)
+
+	aggregator.lock.Lock()
+	defer aggregator.lock.Unlock()
+	assert.Empty(t, mockProc.textAfterInterruption())
+}
@@ -0,0 +1,189 @@
+package aggregators
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+// LLMUserContextAggregator adds the user turns to a conversation context.
+//
+// The TranscriptionFrames and TextFrames received between a StartInterruptionFrame
+// and a StopInterruptionFrame (the user speaking) are joined into a user message.
+// A text received outside of a turn, e.g. a transcription late after the
+// StopInterruptionFrame or typed text, is a user message by itself. Once a
+// message is added, the context is pushed downstream in a LLMContextFrame.
+// The texts are consumed, the other frames are passed through.
+type LLMUserContextAggregator struct {
+	*processors.FrameProcessor
+	context *frames.LLMContext
+
+	lock        sync.Mutex
+	aggregation string
+	speaking    bool
+}
+
+// NewLLMUserContextAggregator creates a new LLMUserContextAggregator adding to context.
+func NewLLMUserContextAggregator(context *frames.LLMContext) *LLMUserContextAggregator {
+	if context == nil {
+		context = frames.NewLLMContext()
+	}
+	return &LLMUserContextAggregator{
+		FrameProcessor: processors.NewFrameProcessor("LLMUserContextAggregator"),
+		context:        context,
+	}
+}
+
+// Context returns the conversation context.
+func (a *LLMUserContextAggregator) Context() *frames.LLMContext {
+	return a.context
+}
+
+// ProcessFrame aggregates the downstream TextFrames into user messages.
+func (a *LLMUserContextAggregator) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	a.FrameProcessor.ProcessFrame(frame, direction)
+
+	switch f := frame.(type) {
+	case *frames.StartInterruptionFrame:
+		a.lock.Lock()
+		a.speaking = true
+		a.lock.Unlock()
+		a.PushFrame(f, direction)
+	case *frames.StopInterruptionFrame:
+		a.PushFrame(f, direction)
+		a.lock.Lock()
+		a.speaking = false
+		message := a.aggregation
+		a.aggregation = ""
+		a.lock.Unlock()
+		a.commit(message)
+	case *frames.TranscriptionFrame:
+		a.aggregate(f, f.Text, direction)
+	case *frames.TextFrame:
+		a.aggregate(f, f.Text, direction)
+	case *frames.EndFrame, *frames.CancelFrame:
+		a.lock.Lock()
+		a.aggregation, a.speaking = "", false
+		a.lock.Unlock()
+		a.PushFrame(frame, direction)
+	default:
+		a.PushFrame(frame, direction)
+	}
+}
+
+// aggregate adds the downstream text of frame to the user turn.
+func (a *LLMUserContextAggregator) aggregate(frame frames.Frame, text string, direction processors.FrameDirection) {
+	if direction != processors.FrameDirectionDownstream {
+		a.PushFrame(frame, direction)
+		return
+	}
+	a.lock.Lock()
+	a.aggregation = strings.TrimSpace(a.aggregation + " " + strings.TrimSpace(text))
+	message := ""
+	if !a.speaking {
+		message, a.aggregation = a.aggregation, ""
+	}
+	a.lock.Unlock()
+	a.commit(message)
+}
+
+// commit adds message to the context, if not empty, and pushes the context.
+func (a *LLMUserContextAggregator) commit(message string) {
+	if message == "" {
+		return
+	}
+	a.context.AddMessage(frames.LLMRoleUser, message)
+	a.PushFrame(frames.NewLLMContextFrame(a.context), processors.FrameDirectionDownstream)
+}
+
+// LLMAssistantContextAggregator adds the assistant turns to a conversation context.
+//
+// The TextFrames received between a LLMFullResponseStartFrame and a
+// LLMFullResponseEndFrame (the bot response) are concatenated into an assistant
+// message. An allowed StartInterruptionFrame commits the response received so
+// far, the text spoken before the interruption, and the rest of the interrupted
+// response is ignored. Once a message is added, the context is pushed downstream
+// in a LLMContextFrame. All the frames are passed through.
+type LLMAssistantContextAggregator struct {
+	*processors.FrameProcessor
+	context *frames.LLMContext
+
+	lock        sync.Mutex
+	aggregation string
+	aggregating bool
+}
+
+// NewLLMAssistantContextAggregator creates a new LLMAssistantContextAggregator adding to context.
+func NewLLMAssistantContextAggregator(context *frames.LLMContext) *LLMAssistantContextAggregator {
+	if context == nil {
+		context = frames.NewLLMContext()
+	}
+	return &LLMAssistantContextAggregator{
+		FrameProcessor: processors.NewFrameProcessor("LLMAssistantContextAggregator"),
+		context:        context,
+	}
+}
+
+// NewLLMContextAggregatorPair creates the user and assistant aggregators of the conversation context.
+func NewLLMContextAggregatorPair(context *frames.LLMContext) (*LLMUserContextAggregator, *LLMAssistantContextAggregator) {
+	if context == nil {
+		context = frames.NewLLMContext()
+	}
+	return NewLLMUserContextAggregator(context), NewLLMAssistantContextAggregator(context)
+}
+
+// Context returns the conversation context.
+func (a *LLMAssistantContextAggregator) Context() *frames.LLMContext {
+	return a.context
+}
+
+// ProcessFrame aggregates the downstream TextFrames of the LLM responses into assistant messages.
+func (a *LLMAssistantContextAggregator) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	a.FrameProcessor.ProcessFrame(frame, direction)
+	if a.ShouldInterrupt(frame) {
+		a.commit()
+		a.PushFrame(frame, direction)
+		return
+	}
+
+	switch f := frame.(type) {
+	case *frames.LLMFullResponseStartFrame:
+		// A response not ended is committed as is.
+		a.commit()
+		a.lock.Lock()
+		a.aggregating = true
+		a.lock.Unlock()
+		a.PushFrame(f, direction)
+	case *frames.LLMFullResponseEndFrame, *frames.EndFrame:
+		a.commit()
+		a.PushFrame(frame, direction)
+	case *frames.TextFrame:
+		a.PushFrame(f, direction)
+		if direction != processors.FrameDirectionDownstream {
+			return
+		}
+		a.lock.Lock()
+		if a.aggregating {
+			a.aggregation += f.Text
+		}
+		a.lock.Unlock()
+	default:
+		a.PushFrame(frame, direction)
+	}
+}
+
+// commit adds the aggregated response to the context, if not empty, pushes the
+// context and ends the response.
+func (a *LLMAssistantContextAggregator) commit() {
+	a.lock.Lock()
+	message := strings.TrimSpace(a.aggregation)
+	a.aggregation, a.aggregating = "", false
+	a.lock.Unlock()
+	if message == "" {
+		return
+	}
+	a.context.AddMessage(frames.LLMRoleAssistant, message)
+	a.PushFrame(frames.NewLLMContextFrame(a.context), processors.FrameDirectionDownstream)
+}
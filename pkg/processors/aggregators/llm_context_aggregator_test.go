@@ -0,0 +1,117 @@
+package aggregators
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+// contextFrames returns the number of LLMContextFrames received downstream.
+func (p *mockProcessor) contextFrames() int {
+	n := 0
+	for _, f := range p.receivedFrames[processors.FrameDirectionDownstream] {
+		if _, ok := f.(*frames.LLMContextFrame); ok {
+			n++
+		}
+	}
+	return n
+}
+
+func sendFrames(processor processors.IFrameProcessor, fs ...frames.Frame) {
+	for _, f := range fs {
+		processor.ProcessFrame(f, processors.FrameDirectionDownstream)
+	}
+}
+
+func TestLLMUserContextAggregator(t *testing.T) {
+	context := frames.NewLLMContext(frames.LLMMessage{Role: frames.LLMRoleSystem, Content: "Be brief."})
+	user := NewLLMUserContextAggregator(context)
+	mockProc := NewMockProcessor()
+	user.Link(mockProc)
+
+	sendFrames(user, startFrame(true),
+		frames.NewStartInterruptionFrame(),
+		frames.NewTranscriptionFrame("What time", "en"),
+		frames.NewTextFrame(" is it? "),
+	)
+	assert.Equal(t, 0, mockProc.contextFrames())
+	sendFrames(user, frames.NewStopInterruptionFrame())
+	assert.Equal(t, 1, mockProc.contextFrames())
+
+	// A transcription late after the turn is a message by itself.
+	sendFrames(user, frames.NewStartInterruptionFrame(), frames.NewStopInterruptionFrame(), frames.NewTextFrame("Thanks"))
+	assert.Equal(t, 2, mockProc.contextFrames())
+	assert.Nil(t, mockProc.texts())
+
+	assert.Equal(t, []frames.LLMMessage{
+		{Role: frames.LLMRoleSystem, Content: "Be brief."},
+		{Role: frames.LLMRoleUser, Content: "What time is it?"},
+		{Role: frames.LLMRoleUser, Content: "Thanks"},
+	}, context.Messages())
+}
+
+func TestLLMAssistantContextAggregator(t *testing.T) {
+	context := frames.NewLLMContext()
+	assistant := NewLLMAssistantContextAggregator(context)
+	mockProc := NewMockProcessor()
+	assistant.Link(mockProc)
+
+	sendFrames(assistant, startFrame(true),
+		frames.NewLLMFullResponseStartFrame(),
+		frames.NewTextFrame("It is"),
+		frames.NewTextFrame(" noon."),
+		frames.NewLLMFullResponseEndFrame(),
+		// Text out of a response isn't added.
+		frames.NewTextFrame("Stray"),
+	)
+
+	assert.Equal(t, []string{"It is", " noon.", "Stray"}, mockProc.texts())
+	assert.Equal(t, 1, mockProc.contextFrames())
+	assert.Equal(t, []frames.LLMMessage{{Role: frames.LLMRoleAssistant, Content: "It is noon."}}, context.Messages())
+}
+
+func TestLLMContextAggregatorPair_Interruption(t *testing.T) {
+	user, assistant := NewLLMContextAggregatorPair(nil)
+	assert.Same(t, user.Context(), assistant.Context())
+	mockProc := NewMockProcessor()
+	user.Link(assistant)
+	assistant.Link(mockProc)
+
+	sendFrames(user, startFrame(true),
+		frames.NewStartInterruptionFrame(),
+		frames.NewTextFrame("Tell me a story."),
+		frames.NewStopInterruptionFrame(),
+	)
+	sendFrames(assistant,
+		frames.NewLLMFullResponseStartFrame(),
+		frames.NewTextFrame("Once upon"),
+		frames.NewTextFrame(" a time"),
+	)
+	// The user interrupts the response: the part received is committed, the rest ignored.
+	sendFrames(user, frames.NewStartInterruptionFrame())
+	sendFrames(assistant, frames.NewTextFrame(" there was"), frames.NewLLMFullResponseEndFrame())
+	sendFrames(user, frames.NewTextFrame("Stop."), frames.NewStopInterruptionFrame())
+
+	assert.Equal(t, []frames.LLMMessage{
+		{Role: frames.LLMRoleUser, Content: "Tell me a story."},
+		{Role: frames.LLMRoleAssistant, Content: "Once upon a time"},
+		{Role: frames.LLMRoleUser, Content: "Stop."},
+	}, user.Context().Messages())
+}
+
+func TestLLMAssistantContextAggregator_InterruptionNotAllowed(t *testing.T) {
+	assistant := NewLLMAssistantContextAggregator(nil)
+	assistant.Link(NewMockProcessor())
+
+	sendFrames(assistant, startFrame(false),
+		frames.NewLLMFullResponseStartFrame(),
+		frames.NewTextFrame("Kept"),
+		frames.NewStartInterruptionFrame(),
+		frames.NewTextFrame(" whole."),
+		frames.NewLLMFullResponseEndFrame(),
+	)
+
+	assert.Equal(t, []frames.LLMMessage{{Role: frames.LLMRoleAssistant, Content: "Kept whole."}}, assistant.Context().Messages())
+}
@@ -7,13 +7,17 @@ import (
 	"github.com/weedge/pipeline-go/pkg"
 	"github.com/weedge/pipeline-go/pkg/frames"
 	"github.com/weedge/pipeline-go/pkg/processors"
+	"github.com/weedge/pipeline-go/pkg/segmenters"
 )
 
 // SentenceAggregator aggregates text frames into sentences.
+// By default the aggregation is pushed when it ends like a sentence (see
+// pkg.MatchEndOfSentence), with a segmenter every sentence in it is pushed.
 type SentenceAggregator struct {
 	*processors.FrameProcessor
 	aggregation string
 	endFrame    reflect.Type // endFrame to flush sentence
+	segmenter   segmenters.SentenceSegmenter
 }
 
 // NewSentenceAggregator creates a new SentenceAggregator.
@@ -28,20 +32,41 @@ func NewSentenceAggregatorWithEnd(endFrame reflect.Type) *SentenceAggregator {
 	}
 }
 
+// WithSegmenter sets the segmenter splitting the aggregation into sentences,
+// e.g. a segmenters.CJKSegmenter for Chinese text.
+func (a *SentenceAggregator) WithSegmenter(segmenter segmenters.SentenceSegmenter) *SentenceAggregator {
+	a.segmenter = segmenter
+	return a
+}
+
 // hasEndOfSentence checks for sentence-terminating punctuation using regex.
 func (a *SentenceAggregator) hasEndOfSentence(s string) bool {
 	return pkg.MatchEndOfSentence(strings.TrimSpace(s))
 }
 
 // ProcessFrame accumulates text and emits a frame when a sentence is complete.
+// An allowed StartInterruptionFrame discards the partial sentence.
 func (a *SentenceAggregator) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	a.FrameProcessor.ProcessFrame(frame, direction)
+	if a.ShouldInterrupt(frame) {
+		a.aggregation = ""
+		a.PushFrame(frame, direction)
+		return
+	}
+
 	isPushAgg := reflect.TypeOf(frame) == a.endFrame
 
 	switch f := frame.(type) {
 	case *frames.TextFrame:
-		// Add a space if the aggregation is not empty and the new text doesn't start with one.
+		// The text is aggregated as is, the pushed sentences concatenated are the text received.
 		a.aggregation += f.Text
-		if a.hasEndOfSentence(a.aggregation) {
+		if a.segmenter != nil {
+			var sentences []string
+			sentences, a.aggregation = a.segmenter.Split(a.aggregation)
+			for _, sentence := range sentences {
+				a.PushFrame(frames.NewTextFrame(sentence), direction)
+			}
+		} else if a.hasEndOfSentence(a.aggregation) {
 			a.PushFrame(frames.NewTextFrame(a.aggregation), direction)
 			a.aggregation = ""
 		}
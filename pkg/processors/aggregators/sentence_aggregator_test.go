@@ -1,11 +1,14 @@
 package aggregators
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/weedge/pipeline-go/pkg"
 	"github.com/weedge/pipeline-go/pkg/frames"
 	"github.com/weedge/pipeline-go/pkg/processors"
+	"github.com/weedge/pipeline-go/pkg/segmenters"
 )
 
 // mockProcessor is a simple processor for testing.
@@ -39,9 +42,9 @@ func TestSentenceAggregator_ProcessFrame_TextFrames(t *testing.T) {
 	sentenceAggregator := NewSentenceAggregator()
 	sentenceAggregator.Link(mockProc)
 
-	// Send text frames that form a complete sentence
+	// Send text frames that form a complete sentence, the text is aggregated as is
 	sentenceAggregator.ProcessFrame(&frames.TextFrame{Text: "Hello"}, processors.FrameDirectionDownstream)
-	sentenceAggregator.ProcessFrame(&frames.TextFrame{Text: "world"}, processors.FrameDirectionDownstream)
+	sentenceAggregator.ProcessFrame(&frames.TextFrame{Text: " world"}, processors.FrameDirectionDownstream)
 	sentenceAggregator.ProcessFrame(&frames.TextFrame{Text: "."}, processors.FrameDirectionDownstream)
 
 	// Check that the aggregated sentence was sent
@@ -49,7 +52,7 @@ func TestSentenceAggregator_ProcessFrame_TextFrames(t *testing.T) {
 	assert.Equal(t, 1, len(received))
 	textFrame, ok := received[0].(*frames.TextFrame)
 	assert.True(t, ok)
-	assert.Equal(t, "Hello world .", textFrame.Text)
+	assert.Equal(t, "Hello world.", textFrame.Text)
 
 	// Check that aggregation was reset
 	assert.Equal(t, "", sentenceAggregator.aggregation)
@@ -74,7 +77,7 @@ func TestSentenceAggregator_ProcessFrame_TextFramesWithSpaces(t *testing.T) {
 	assert.Equal(t, 1, len(received))
 	textFrame, ok := received[0].(*frames.TextFrame)
 	assert.True(t, ok)
-	assert.Equal(t, "Hello beautiful world !", textFrame.Text)
+	assert.Equal(t, "Hello beautiful world!", textFrame.Text)
 }
 
 func TestSentenceAggregator_ProcessFrame_MultipleSentences(t *testing.T) {
@@ -87,11 +90,11 @@ func TestSentenceAggregator_ProcessFrame_MultipleSentences(t *testing.T) {
 
 	// Send text frames that form multiple sentences
 	sentenceAggregator.ProcessFrame(&frames.TextFrame{Text: "Hello"}, processors.FrameDirectionDownstream)
-	sentenceAggregator.ProcessFrame(&frames.TextFrame{Text: "world"}, processors.FrameDirectionDownstream)
+	sentenceAggregator.ProcessFrame(&frames.TextFrame{Text: " world"}, processors.FrameDirectionDownstream)
 	sentenceAggregator.ProcessFrame(&frames.TextFrame{Text: "."}, processors.FrameDirectionDownstream)
-	sentenceAggregator.ProcessFrame(&frames.TextFrame{Text: "How"}, processors.FrameDirectionDownstream)
-	sentenceAggregator.ProcessFrame(&frames.TextFrame{Text: "are"}, processors.FrameDirectionDownstream)
-	sentenceAggregator.ProcessFrame(&frames.TextFrame{Text: "you"}, processors.FrameDirectionDownstream)
+	sentenceAggregator.ProcessFrame(&frames.TextFrame{Text: " How"}, processors.FrameDirectionDownstream)
+	sentenceAggregator.ProcessFrame(&frames.TextFrame{Text: " are"}, processors.FrameDirectionDownstream)
+	sentenceAggregator.ProcessFrame(&frames.TextFrame{Text: " you"}, processors.FrameDirectionDownstream)
 	sentenceAggregator.ProcessFrame(&frames.TextFrame{Text: "?"}, processors.FrameDirectionDownstream)
 
 	// Check that both sentences were sent
@@ -100,11 +103,11 @@ func TestSentenceAggregator_ProcessFrame_MultipleSentences(t *testing.T) {
 
 	textFrame1, ok := received[0].(*frames.TextFrame)
 	assert.True(t, ok)
-	assert.Equal(t, "Hello world .", textFrame1.Text)
+	assert.Equal(t, "Hello world.", textFrame1.Text)
 
 	textFrame2, ok := received[1].(*frames.TextFrame)
 	assert.True(t, ok)
-	assert.Equal(t, "How are you ?", textFrame2.Text)
+	assert.Equal(t, " How are you?", textFrame2.Text)
 
 	// Check that aggregation was reset
 	assert.Equal(t, "", sentenceAggregator.aggregation)
@@ -120,7 +123,7 @@ func TestSentenceAggregator_ProcessFrame_EndFrameWithLeftoverText(t *testing.T)
 
 	// Send text frames without ending punctuation
 	sentenceAggregator.ProcessFrame(&frames.TextFrame{Text: "Hello"}, processors.FrameDirectionDownstream)
-	sentenceAggregator.ProcessFrame(&frames.TextFrame{Text: "world"}, processors.FrameDirectionDownstream)
+	sentenceAggregator.ProcessFrame(&frames.TextFrame{Text: " world"}, processors.FrameDirectionDownstream)
 
 	// Send an end frame
 	endFrame := frames.NewEndFrame()
@@ -195,3 +198,108 @@ func TestSentenceAggregator_hasEndOfSentence(t *testing.T) {
 	assert.False(t, aggregator.hasEndOfSentence(""))
 	assert.False(t, aggregator.hasEndOfSentence("   "))
 }
+
+func TestSentenceAggregator_WithSegmenter(t *testing.T) {
+	mockProc := NewMockProcessor()
+	sentenceAggregator := NewSentenceAggregator().WithSegmenter(segmenters.NewEnglishSegmenter())
+	sentenceAggregator.Link(mockProc)
+
+	// Several sentences out of a single frame, the rest is aggregated.
+	sentenceAggregator.ProcessFrame(frames.NewTextFrame("Hello Mr. Smith. How are you? I am"), processors.FrameDirectionDownstream)
+	sentenceAggregator.ProcessFrame(frames.NewTextFrame(" fine, thanks."), processors.FrameDirectionDownstream)
+
+	var texts []string
+	for _, frame := range mockProc.GetReceivedFrames(processors.FrameDirectionDownstream) {
+		texts = append(texts, frame.(*frames.TextFrame).Text)
+	}
+	assert.Equal(t, []string{"Hello Mr. Smith.", " How are you?", " I am fine, thanks."}, texts)
+	assert.Equal(t, "", sentenceAggregator.aggregation)
+}
+
+func TestSentenceAggregator_WithCJKSegmenter(t *testing.T) {
+	mockProc := NewMockProcessor()
+	sentenceAggregator := NewSentenceAggregator().WithSegmenter(segmenters.NewCJKSegmenter())
+	sentenceAggregator.Link(mockProc)
+
+	sentenceAggregator.ProcessFrame(frames.NewTextFrame("你好，世界。我想…"), processors.FrameDirectionDownstream)
+	sentenceAggregator.ProcessFrame(frames.NewTextFrame("…也许"), processors.FrameDirectionDownstream)
+	sentenceAggregator.ProcessFrame(frames.NewEndFrame(), processors.FrameDirectionDownstream)
+
+	received := mockProc.GetReceivedFrames(processors.FrameDirectionDownstream)
+	assert.Equal(t, 4, len(received))
+	assert.Equal(t, "你好，世界。", received[0].(*frames.TextFrame).Text)
+	assert.Equal(t, "我想……", received[1].(*frames.TextFrame).Text)
+	assert.Equal(t, "也许", received[2].(*frames.TextFrame).Text)
+	assert.IsType(t, &frames.EndFrame{}, received[3])
+}
+
+// splitText splits text in chunks of the lengths in cuts (in bytes, a chunk may
+// end in the middle of a rune), the last chunk is the rest of the text.
+func splitText(text string, cuts []byte) []string {
+	var chunks []string
+	for _, cut := range cuts {
+		if text == "" {
+			break
+		}
+		n := min(int(cut)%16+1, len(text))
+		chunks = append(chunks, text[:n])
+		text = text[n:]
+	}
+	if text != "" {
+		chunks = append(chunks, text)
+	}
+	return chunks
+}
+
+// FuzzSentenceAggregator checks the sentences pushed by a SentenceAggregator,
+// however the text is split in frames: concatenated they are the text received,
+// and without a segmenter all but the one flushed by the EndFrame end a sentence.
+func FuzzSentenceAggregator(f *testing.F) {
+	for _, text := range []string{
+		"Hello world. How are you?",
+		"I saw Mr. Smith yesterday. He was nice.",
+		"The meeting is at 3:00 p.m. See you there!",
+		"1. First item 2. Second item",
+		"I visited the U.S.A.. It was fun.",
+		"这是一个测试。你好！最后",
+		"no punctuation at all",
+		"",
+	} {
+		f.Add(text, []byte{1, 5, 3, 8})
+	}
+	f.Fuzz(func(t *testing.T, text string, cuts []byte) {
+		checkSentenceAggregator(t, NewSentenceAggregator(), text, cuts, true)
+		checkSentenceAggregator(t, NewSentenceAggregator().WithSegmenter(segmenters.NewEnglishSegmenter()), text, cuts, false)
+		checkSentenceAggregator(t, NewSentenceAggregator().WithSegmenter(segmenters.NewCJKSegmenter()), text, cuts, false)
+	})
+}
+
+func checkSentenceAggregator(t *testing.T, sentenceAggregator *SentenceAggregator, text string, cuts []byte, matchEnds bool) {
+	mockProc := NewMockProcessor()
+	sentenceAggregator.Link(mockProc)
+
+	for _, chunk := range splitText(text, cuts) {
+		sentenceAggregator.ProcessFrame(frames.NewTextFrame(chunk), processors.FrameDirectionDownstream)
+	}
+	sentenceAggregator.ProcessFrame(frames.NewEndFrame(), processors.FrameDirectionDownstream)
+
+	received := mockProc.GetReceivedFrames(processors.FrameDirectionDownstream)
+	if _, ok := received[len(received)-1].(*frames.EndFrame); !ok {
+		t.Fatalf("last frame %s, want EndFrame", received[len(received)-1])
+	}
+	var sentences []string
+	for _, frame := range received[:len(received)-1] {
+		sentences = append(sentences, frame.(*frames.TextFrame).Text)
+	}
+	if got := strings.Join(sentences, ""); got != text {
+		t.Errorf("sentences %q concatenated = %q, want %q", sentences, got, text)
+	}
+	for i, sentence := range sentences {
+		if sentence == "" {
+			t.Errorf("sentence %d of %q is empty", i, sentences)
+		}
+		if matchEnds && i < len(sentences)-1 && !pkg.MatchEndOfSentence(sentence) {
+			t.Errorf("sentence %d of %q doesn't end a sentence", i, sentences)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package aggregators
+
+import (
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/weedge/pipeline-go/pkg/clock"
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/processors"
+	"github.com/weedge/pipeline-go/pkg/segmenters"
+)
+
+const (
+	// DefaultChunkMinChars is the default minimum number of characters of a chunk.
+	DefaultChunkMinChars = 20
+	// DefaultChunkMaxChars is the default maximum number of characters of a chunk.
+	DefaultChunkMaxChars = 250
+	// DefaultChunkIdleTimeout is the default time without text after which the buffered text is pushed.
+	DefaultChunkIdleTimeout = time.Second
+)
+
+// clauseBreaks are the characters a chunk too long is preferably split after.
+const clauseBreaks = ",;:.!?…—，、；：。！？"
+
+// TextChunkAggregator aggregates streamed TextFrame tokens into chunks for a TTS
+// service, neither too short (choppy audio) nor too long (latency).
+//
+// The buffered text is pushed by whole sentences, merged until the chunk has at
+// least minChars characters. Text longer than maxChars is split after the last
+// clause break (punctuation, or else a space) within maxChars. The buffered text
+// is pushed, however short, after the idle timeout without text and on an
+// EndFrame; an allowed StartInterruptionFrame or a CancelFrame drops it.
+// The chunks concatenated are the text received downstream; the upstream
+// TextFrames are passed through.
+type TextChunkAggregator struct {
+	*processors.FrameProcessor
+	minChars    int
+	maxChars    int
+	idleTimeout time.Duration
+	segmenter   segmenters.SentenceSegmenter
+
+	lock   sync.Mutex
+	buffer string
+	timer  clock.Timer
+	// generation discards the idle timers fired after the buffer changed.
+	generation uint64
+	// queued are the frames to be pushed downstream, in order, by the goroutine
+	// pushing if any.
+	queued  []frames.Frame
+	pushing bool
+}
+
+// NewTextChunkAggregator creates a new TextChunkAggregator with the default lengths
+// and idle timeout, finding the sentences with a segmenters.EnglishSegmenter.
+func NewTextChunkAggregator() *TextChunkAggregator {
+	return &TextChunkAggregator{
+		FrameProcessor: processors.NewFrameProcessor("TextChunkAggregator"),
+		minChars:       DefaultChunkMinChars,
+		maxChars:       DefaultChunkMaxChars,
+		idleTimeout:    DefaultChunkIdleTimeout,
+		segmenter:      segmenters.NewEnglishSegmenter(),
+	}
+}
+
+// WithMinChars sets the minimum number of characters of a chunk pushed at the end of a sentence.
+func (a *TextChunkAggregator) WithMinChars(minChars int) *TextChunkAggregator {
+	a.minChars = minChars
+	return a
+}
+
+// WithMaxChars sets the maximum number of characters of a chunk, 0 for no maximum.
+func (a *TextChunkAggregator) WithMaxChars(maxChars int) *TextChunkAggregator {
+	a.maxChars = maxChars
+	return a
+}
+
+// WithIdleTimeout sets the time without text after which the buffered text is pushed, 0 to wait.
+func (a *TextChunkAggregator) WithIdleTimeout(idleTimeout time.Duration) *TextChunkAggregator {
+	a.idleTimeout = idleTimeout
+	return a
+}
+
+// WithSegmenter sets the segmenter finding the sentences, e.g. a segmenters.CJKSegmenter.
+func (a *TextChunkAggregator) WithSegmenter(segmenter segmenters.SentenceSegmenter) *TextChunkAggregator {
+	a.segmenter = segmenter
+	return a
+}
+
+// ProcessFrame aggregates the downstream TextFrames and passes the other frames through.
+func (a *TextChunkAggregator) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	a.FrameProcessor.ProcessFrame(frame, direction)
+	if direction != processors.FrameDirectionDownstream {
+		if a.ShouldInterrupt(frame) {
+			a.lock.Lock()
+			a.reset()
+			a.lock.Unlock()
+		}
+		a.PushFrame(frame, direction)
+		return
+	}
+
+	a.lock.Lock()
+	switch f := frame.(type) {
+	case *frames.TextFrame:
+		a.buffer += f.Text
+		a.queueChunks(a.takeChunks())
+		a.resetTimer()
+	case *frames.EndFrame:
+		a.queueChunks(a.flush())
+		a.queue(frame)
+	case *frames.CancelFrame:
+		a.reset()
+		a.queue(frame)
+	default:
+		if a.ShouldInterrupt(frame) {
+			a.reset()
+		}
+		a.queue(frame)
+	}
+	a.lock.Unlock()
+	a.pushQueued()
+}
+
+// Cleanup stops the idle timer.
+func (a *TextChunkAggregator) Cleanup() {
+	a.lock.Lock()
+	a.reset()
+	a.lock.Unlock()
+	a.FrameProcessor.Cleanup()
+}
+
+// takeChunks takes the sentences buffered long enough and the heads of the text
+// too long out of the buffer, a.lock held.
+func (a *TextChunkAggregator) takeChunks() []string {
+	sentences, rest := a.segmenter.Split(a.buffer)
+	var chunks []string
+	chunk := ""
+	for _, sentence := range sentences {
+		chunk += sentence
+		if utf8.RuneCountInString(chunk) >= a.minChars {
+			chunks = append(chunks, a.split(chunk)...)
+			chunk = ""
+		}
+	}
+	a.buffer = chunk + rest
+	for a.maxChars > 0 && utf8.RuneCountInString(a.buffer) > a.maxChars {
+		i := splitIndex(a.buffer, a.maxChars)
+		chunks = append(chunks, a.buffer[:i])
+		a.buffer = a.buffer[i:]
+	}
+	return chunks
+}
+
+// split splits chunk in chunks of at most maxChars characters.
+func (a *TextChunkAggregator) split(chunk string) []string {
+	var chunks []string
+	for a.maxChars > 0 && utf8.RuneCountInString(chunk) > a.maxChars {
+		i := splitIndex(chunk, a.maxChars)
+		chunks = append(chunks, chunk[:i])
+		chunk = chunk[i:]
+	}
+	return append(chunks, chunk)
+}
+
+// queueChunks queues chunks as TextFrames to be pushed downstream, a.lock held.
+func (a *TextChunkAggregator) queueChunks(chunks []string) {
+	for _, chunk := range chunks {
+		a.queue(frames.NewTextFrame(chunk))
+	}
+}
+
+// queue queues frame to be pushed downstream, a.lock held.
+func (a *TextChunkAggregator) queue(frame frames.Frame) {
+	a.queued = append(a.queued, frame)
+}
+
+// pushQueued pushes the frames queued downstream in order, a.lock not held: the
+// next processor may send frames back to a. A single goroutine pushes at a
+// time, the idle timer or ProcessFrame, and the frames queued meanwhile, even
+// by a frame sent back, are pushed by it once its push returns.
+func (a *TextChunkAggregator) pushQueued() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.pushing {
+		return
+	}
+	a.pushing = true
+	for len(a.queued) > 0 {
+		frame := a.queued[0]
+		a.queued = a.queued[1:]
+		a.lock.Unlock()
+		a.PushFrame(frame, processors.FrameDirectionDownstream)
+		a.lock.Lock()
+	}
+	a.pushing = false
+}
+
+// flush takes all the buffered text out of the buffer, a.lock held.
+func (a *TextChunkAggregator) flush() []string {
+	var chunks []string
+	if a.buffer != "" {
+		chunks = a.split(a.buffer)
+	}
+	a.reset()
+	return chunks
+}
+
+// reset drops the buffered text and the chunks not pushed yet, and stops the
+// idle timer, a.lock held.
+func (a *TextChunkAggregator) reset() {
+	a.buffer = ""
+	// The downstream TextFrames queued are all chunks.
+	queued := a.queued[:0]
+	for _, frame := range a.queued {
+		if _, ok := frame.(*frames.TextFrame); !ok {
+			queued = append(queued, frame)
+		}
+	}
+	a.queued = queued
+	a.generation++
+	if a.timer != nil {
+		a.timer.Stop()
+	}
+}
+
+// resetTimer restarts the idle timer if text is buffered, a.lock held.
+func (a *TextChunkAggregator) resetTimer() {
+	if a.timer != nil {
+		a.timer.Stop()
+	}
+	if a.idleTimeout <= 0 || a.buffer == "" {
+		return
+	}
+	a.generation++
+	generation := a.generation
+	a.timer = a.Clock().AfterFunc(a.idleTimeout, func() {
+		a.lock.Lock()
+		if generation == a.generation {
+			a.queueChunks(a.flush())
+		}
+		a.lock.Unlock()
+		a.pushQueued()
+	})
+}
+
+// splitIndex returns the byte index text is split at for a head of at most
+// maxChars characters: after the last clause break, else before the last space,
+// else after maxChars characters.
+func splitIndex(text string, maxChars int) int {
+	clause, space, i := -1, -1, 0
+	for n := 0; n < maxChars && i < len(text); n++ {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case strings.ContainsRune(clauseBreaks, r):
+			clause = i + size
+		case unicode.IsSpace(r) && i > 0:
+			space = i
+		}
+		i += size
+	}
+	switch {
+	case clause > 0:
+		return clause
+	case space > 0:
+		return space
+	default:
+		return i
+	}
+}
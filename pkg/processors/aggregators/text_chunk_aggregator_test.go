@@ -0,0 +1,265 @@
+package aggregators
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weedge/pipeline-go/pkg/clock"
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/processors"
+	"github.com/weedge/pipeline-go/pkg/segmenters"
+)
+
+// texts returns the texts of the TextFrames received downstream.
+func (p *mockProcessor) texts() []string {
+	var texts []string
+	for _, f := range p.receivedFrames[processors.FrameDirectionDownstream] {
+		if f, ok := f.(*frames.TextFrame); ok {
+			texts = append(texts, f.Text)
+		}
+	}
+	return texts
+}
+
+func newChunkTest(aggregator *TextChunkAggregator) (*mockProcessor, *clock.Fake) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	aggregator.SetClock(fake)
+	mockProc := NewMockProcessor()
+	aggregator.Link(mockProc)
+	aggregator.ProcessFrame(startFrame(true), processors.FrameDirectionDownstream)
+	return mockProc, fake
+}
+
+func sendTokens(aggregator *TextChunkAggregator, tokens ...string) {
+	for _, token := range tokens {
+		aggregator.ProcessFrame(frames.NewTextFrame(token), processors.FrameDirectionDownstream)
+	}
+}
+
+func TestTextChunkAggregator_MinChars(t *testing.T) {
+	aggregator := NewTextChunkAggregator().WithMinChars(15)
+	mockProc, _ := newChunkTest(aggregator)
+
+	// The short sentences are merged until the chunk is long enough.
+	sendTokens(aggregator, "Hi.", " Sure.", " I can", " help you.", " Wait")
+	assert.Equal(t, []string{"Hi. Sure. I can help you."}, mockProc.texts())
+	assert.Equal(t, " Wait", aggregator.buffer)
+}
+
+func TestTextChunkAggregator_MaxChars(t *testing.T) {
+	aggregator := NewTextChunkAggregator().WithMinChars(1).WithMaxChars(20)
+	mockProc, _ := newChunkTest(aggregator)
+
+	// Split after the last clause break, else before the last space, else hard.
+	sendTokens(aggregator, "One, two, three and four")
+	assert.Equal(t, []string{"One, two,"}, mockProc.texts())
+	sendTokens(aggregator, " and five and six")
+	assert.Equal(t, []string{"One, two,", " three and four and"}, mockProc.texts())
+	assert.Equal(t, " five and six", aggregator.buffer)
+	sendTokens(aggregator, " "+strings.Repeat("x", 25))
+	assert.Equal(t, []string{"One, two,", " three and four and", " five and six", " " + strings.Repeat("x", 19)}, mockProc.texts())
+
+	// Same for a sentence too long.
+	aggregator = NewTextChunkAggregator().WithMinChars(1).WithMaxChars(10).WithSegmenter(segmenters.NewCJKSegmenter())
+	mockProc = NewMockProcessor()
+	aggregator.Link(mockProc)
+	sendTokens(aggregator, "这是一个，很长的句子。")
+	assert.Equal(t, []string{"这是一个，", "很长的句子。"}, mockProc.texts())
+}
+
+func TestTextChunkAggregator_IdleTimeout(t *testing.T) {
+	aggregator := NewTextChunkAggregator().WithIdleTimeout(500 * time.Millisecond)
+	mockProc, fake := newChunkTest(aggregator)
+
+	sendTokens(aggregator, "Hello")
+	fake.Advance(400 * time.Millisecond)
+	// A token restarts the idle timer.
+	sendTokens(aggregator, " there")
+	fake.Advance(499 * time.Millisecond)
+	assert.Empty(t, mockProc.texts())
+	fake.Advance(time.Millisecond)
+	assert.Equal(t, []string{"Hello there"}, mockProc.texts())
+	assert.Equal(t, 0, fake.Timers())
+}
+
+func TestTextChunkAggregator_EndFrame(t *testing.T) {
+	aggregator := NewTextChunkAggregator()
+	mockProc, fake := newChunkTest(aggregator)
+
+	sendTokens(aggregator, "Bye")
+	aggregator.ProcessFrame(frames.NewEndFrame(), processors.FrameDirectionDownstream)
+	assert.Equal(t, []string{"Bye"}, mockProc.texts())
+	received := mockProc.GetReceivedFrames(processors.FrameDirectionDownstream)
+	assert.IsType(t, &frames.EndFrame{}, received[len(received)-1])
+
+	fake.Advance(time.Minute)
+	assert.Equal(t, []string{"Bye"}, mockProc.texts())
+}
+
+func TestTextChunkAggregator_Interruption(t *testing.T) {
+	aggregator := NewTextChunkAggregator().WithSegmenter(segmenters.NewCJKSegmenter())
+	mockProc, fake := newChunkTest(aggregator)
+
+	sendTokens(aggregator, "被打断的")
+	aggregator.ProcessFrame(frames.NewStartInterruptionFrame(), processors.FrameDirectionDownstream)
+	fake.Advance(time.Minute)
+	sendTokens(aggregator, "新的回答")
+	aggregator.ProcessFrame(frames.NewEndFrame(), processors.FrameDirectionDownstream)
+
+	assert.Equal(t, []string{"新的回答"}, mockProc.textAfterInterruption())
+	assert.Equal(t, []string{"新的回答"}, mockProc.texts())
+}
+
+func TestTextChunkAggregator_Cancel(t *testing.T) {
+	aggregator := NewTextChunkAggregator()
+	mockProc, fake := newChunkTest(aggregator)
+
+	sendTokens(aggregator, "Hello")
+	aggregator.ProcessFrame(frames.NewCancelFrame(), processors.FrameDirectionDownstream)
+	fake.Advance(2 * time.Second)
+	assert.Empty(t, mockProc.texts())
+	received := mockProc.GetReceivedFrames(processors.FrameDirectionDownstream)
+	assert.IsType(t, &frames.CancelFrame{}, received[len(received)-1])
+	assert.Equal(t, 0, fake.Timers())
+
+	// Cleanup stops the idle timer too.
+	sendTokens(aggregator, "Hello")
+	aggregator.Cleanup()
+	fake.Advance(2 * time.Second)
+	assert.Empty(t, mockProc.texts())
+	assert.Equal(t, 0, fake.Timers())
+}
+
+// blockingProcessor blocks on the first TextFrame received until released.
+type blockingProcessor struct {
+	*mockProcessor
+	entered, release chan struct{}
+	once             sync.Once
+}
+
+func (p *blockingProcessor) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	if _, ok := frame.(*frames.TextFrame); ok {
+		p.once.Do(func() {
+			close(p.entered)
+			<-p.release
+		})
+	}
+	p.mockProcessor.ProcessFrame(frame, direction)
+}
+
+func TestTextChunkAggregator_IdleTimeoutOrder(t *testing.T) {
+	aggregator := NewTextChunkAggregator().WithIdleTimeout(500 * time.Millisecond)
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	aggregator.SetClock(fake)
+	next := &blockingProcessor{mockProcessor: NewMockProcessor(), entered: make(chan struct{}), release: make(chan struct{})}
+	aggregator.Link(next)
+	sendTokens(aggregator, "Hello")
+
+	// The EndFrame received while the idle timer pushes the chunk is pushed after it.
+	fired := make(chan struct{})
+	go func() {
+		defer close(fired)
+		fake.Advance(time.Second)
+	}()
+	<-next.entered
+	aggregator.ProcessFrame(frames.NewEndFrame(), processors.FrameDirectionDownstream)
+	close(next.release)
+	<-fired
+
+	received := next.GetReceivedFrames(processors.FrameDirectionDownstream)
+	if assert.Len(t, received, 2) {
+		assert.Equal(t, "Hello", received[0].(*frames.TextFrame).Text)
+		assert.IsType(t, &frames.EndFrame{}, received[1])
+	}
+}
+
+// FuzzTextChunkAggregator checks the chunks concatenated are the text received
+// and are at most maxChars characters.
+func FuzzTextChunkAggregator(f *testing.F) {
+	f.Add("Hi. Sure, I can help you with that. Let me check, one moment...", []byte{1, 5, 3, 8}, uint8(10), uint8(30))
+	f.Add("你好。今天天气很好，我们去公园吧！", []byte{2, 2, 2}, uint8(4), uint8(8))
+	f.Fuzz(func(t *testing.T, text string, cuts []byte, minChars, maxChars uint8) {
+		aggregator := NewTextChunkAggregator().WithMinChars(int(minChars)).WithMaxChars(int(maxChars))
+		mockProc, _ := newChunkTest(aggregator)
+		sendTokens(aggregator, splitText(text, cuts)...)
+		aggregator.ProcessFrame(frames.NewEndFrame(), processors.FrameDirectionDownstream)
+
+		chunks := mockProc.texts()
+		if got := strings.Join(chunks, ""); got != text {
+			t.Errorf("chunks %q concatenated = %q, want %q", chunks, got, text)
+		}
+		for _, chunk := range chunks {
+			if chunk == "" || maxChars > 0 && len([]rune(chunk)) > int(maxChars) {
+				t.Errorf("chunk %q of %q is empty or longer than %d characters", chunk, chunks, maxChars)
+			}
+		}
+	})
+}
+
+// reentrantProcessor sends a frame back upstream to the processor before it for each TextFrame received.
+type reentrantProcessor struct {
+	*mockProcessor
+	previous processors.IFrameProcessor
+	frame    func() frames.Frame
+}
+
+func (p *reentrantProcessor) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	p.mockProcessor.ProcessFrame(frame, direction)
+	if _, ok := frame.(*frames.TextFrame); ok {
+		p.previous.ProcessFrame(p.frame(), processors.FrameDirectionUpstream)
+	}
+}
+
+func TestTextChunkAggregator_Upstream(t *testing.T) {
+	aggregator := NewTextChunkAggregator().WithMinChars(1)
+	upstream := NewMockProcessor()
+	aggregator.SetPrev(upstream)
+	next := &reentrantProcessor{mockProcessor: NewMockProcessor(), previous: aggregator,
+		frame: func() frames.Frame { return frames.NewTextFrame("Echo.") }}
+	aggregator.Link(next)
+	aggregator.ProcessFrame(startFrame(true), processors.FrameDirectionDownstream)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sendTokens(aggregator, "Hello there.", " Bye.")
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("aggregator deadlocked on a frame sent back while pushing")
+	}
+
+	// The upstream TextFrames are passed through, the downstream chunks stay downstream.
+	assert.Equal(t, []string{"Hello there.", " Bye."}, next.texts())
+	var echoes []string
+	for _, f := range upstream.GetReceivedFrames(processors.FrameDirectionUpstream) {
+		if f, ok := f.(*frames.TextFrame); ok {
+			echoes = append(echoes, f.Text)
+		}
+	}
+	assert.Equal(t, []string{"Echo.", "Echo."}, echoes)
+}
+
+func TestTextChunkAggregator_ReentrantInterruption(t *testing.T) {
+	aggregator := NewTextChunkAggregator().WithMinChars(1)
+	next := &reentrantProcessor{mockProcessor: NewMockProcessor(), previous: aggregator,
+		frame: func() frames.Frame { return frames.NewStartInterruptionFrame() }}
+	aggregator.Link(next)
+	aggregator.ProcessFrame(startFrame(true), processors.FrameDirectionDownstream)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sendTokens(aggregator, "Hello there.")
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("aggregator deadlocked on an interruption sent back while pushing")
+	}
+	assert.Equal(t, []string{"Hello there."}, next.texts())
+}
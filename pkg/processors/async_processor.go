@@ -2,8 +2,8 @@ package processors
 
 import (
 	"context"
-	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/weedge/pipeline-go/pkg/frames"
@@ -11,28 +11,49 @@ import (
 )
 
 // AsyncFrameProcessor is a processor that handles frames asynchronously using a queue.
+//
+// Queued frames are pushed by worker goroutines, one per direction by default.
+// The MetricsFrames of the processor are queued too, after the frames queued
+// before them.
+// With more than one worker per direction frames are pushed concurrently and
+// their order is no longer guaranteed.
 type AsyncFrameProcessor struct {
 	*FrameProcessor
-	ctx                   context.Context
-	cancel                context.CancelFunc
 	pushQueueSize         int
-	pushQueue             chan pushItem
-	pushFrameTask         *sync.WaitGroup
 	pushUpQueueSize       int
-	pushUpQueue           chan pushItem
-	pushUpFrameTask       *sync.WaitGroup
+	pushWorkers           int
+	pushUpWorkers         int
+	tasks                 atomic.Pointer[pushTasks]
 	interruptionMu        sync.Mutex
 	porcessFrameAllowPush bool
 	passText              bool
 	passRawAudio          bool
 	isPushBlock           bool
 	isUpPushBlock         bool
+	queueHandler          QueueHandler
 }
 
+// QueueHandler handles a frame queued, on a worker goroutine, until done or ctx
+// is cancelled by an interruption or a CancelFrame.
+type QueueHandler func(ctx context.Context, frame frames.Frame, direction FrameDirection)
+
 // pushItem represents an item in the push queue.
 type pushItem struct {
 	frame     frames.Frame
 	direction FrameDirection
+	queuedAt  time.Time
+}
+
+// pushTasks is one generation of push queues and the workers draining them.
+// An interruption cancels the generation and starts a new one.
+type pushTasks struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	pushQueue chan pushItem
+	upQueue   chan pushItem // nil without an upstream queue
+	drain     chan struct{} // closed to stop the workers once the queues are empty
+	drainOnce sync.Once
+	wg        sync.WaitGroup
 }
 
 // NewAsyncFrameProcessor creates a new AsyncFrameProcessor.
@@ -45,25 +66,19 @@ func NewAsyncFrameProcessorWithPushQueueSize(name string, pushQueueSize, pushUpQ
 	if pushQueueSize <= 0 {
 		pushQueueSize = 1024
 	}
-	ctx, cancel := context.WithCancel(context.Background())
 	p := &AsyncFrameProcessor{
 		FrameProcessor:        NewFrameProcessor(name),
-		ctx:                   ctx,
-		cancel:                cancel,
 		pushQueueSize:         pushQueueSize,
-		pushQueue:             make(chan pushItem, pushQueueSize),
-		pushFrameTask:         &sync.WaitGroup{},
+		pushWorkers:           1,
+		pushUpWorkers:         1,
 		porcessFrameAllowPush: false,
 		passText:              false,
 		passRawAudio:          false,
 		isPushBlock:           false,
 		isUpPushBlock:         false,
 	}
-
 	if pushUpQueueSize > 0 {
 		p.pushUpQueueSize = pushUpQueueSize
-		p.pushUpQueue = make(chan pushItem, pushUpQueueSize)
-		p.pushUpFrameTask = &sync.WaitGroup{}
 	}
 
 	p.createPushTask()
@@ -88,7 +103,7 @@ func (p *AsyncFrameProcessor) WithPassRawAudio(passRawAudio bool) *AsyncFramePro
 	return p
 }
 
-// ProcessFrameAllowPush returns whether verbose mode is enabled.
+// ProcessFrameAllowPush returns whether ProcessFrame queues the frames it receives.
 func (p *AsyncFrameProcessor) ProcessFrameAllowPush() bool {
 	return p.porcessFrameAllowPush
 }
@@ -98,22 +113,73 @@ func (p *AsyncFrameProcessor) WithPorcessFrameAllowPush(porcessFrameAllowPush bo
 	return p
 }
 
+// WithQueueHandler sets the handler the workers call with the frames queued
+// instead of pushing them, e.g. to process them in order in the background.
+// A panic of the handler is handled by the panic policy.
+func (p *AsyncFrameProcessor) WithQueueHandler(handler QueueHandler) *AsyncFrameProcessor {
+	p.queueHandler = handler
+	return p
+}
+
+// PushWorkers returns the number of workers pushing the (downstream) push queue.
+func (p *AsyncFrameProcessor) PushWorkers() int {
+	return p.pushWorkers
+}
+
+// WithPushWorkers sets the number of workers pushing the (downstream) push queue, and restarts them.
+func (p *AsyncFrameProcessor) WithPushWorkers(n int) *AsyncFrameProcessor {
+	p.pushWorkers = max(n, 1)
+	p.restartPushTask()
+	return p
+}
+
+// PushUpWorkers returns the number of workers pushing the upstream queue.
+func (p *AsyncFrameProcessor) PushUpWorkers() int {
+	return p.pushUpWorkers
+}
+
+// WithPushUpWorkers sets the number of workers pushing the upstream queue, and restarts them.
+func (p *AsyncFrameProcessor) WithPushUpWorkers(n int) *AsyncFrameProcessor {
+	p.pushUpWorkers = max(n, 1)
+	p.restartPushTask()
+	return p
+}
+
 // ProcessFrame implements the IFrameProcessor interface.
 func (p *AsyncFrameProcessor) ProcessFrame(frame frames.Frame, direction FrameDirection) {
 	// Call base implementation if needed
 	p.FrameProcessor.ProcessFrame(frame, direction)
 
-	// Handle interruption frames
 	switch f := frame.(type) {
 	case *frames.StartFrame:
 		p.isPushBlock = f.IsPushBlock
 		p.isUpPushBlock = f.IsUpPushBlock
 	case *frames.EndFrame:
+		// The EndFrame is pushed after the frames already queued, then the workers stop.
+		if p.porcessFrameAllowPush {
+			p.QueueFrame(frame, direction)
+		}
 		p.Cleanup()
+		return
 	case *frames.CancelFrame:
-		p.Cleanup()
+		// Queued frames are dropped.
+		p.interruptionMu.Lock()
+		p.stopPushTask()
+		p.interruptionMu.Unlock()
+		if p.porcessFrameAllowPush {
+			p.PushFrame(frame, direction)
+		}
+		return
 	case *frames.StartInterruptionFrame, frames.StartInterruptionFrame:
-		p.HandleInterruptions(frame)
+		if p.InterruptionsAllowed() {
+			// HandleInterruptions pushes the frame out-of-band, don't queue it again.
+			p.HandleInterruptions(frame)
+			return
+		}
+		// Not allowed: keep queued frames, the interruption is just passed along.
+		if !p.porcessFrameAllowPush {
+			p.PushFrame(frame, direction)
+		}
 	}
 
 	if p.porcessFrameAllowPush {
@@ -122,100 +188,167 @@ func (p *AsyncFrameProcessor) ProcessFrame(frame frames.Frame, direction FrameDi
 }
 
 // Cleanup implements the IFrameProcessor interface.
+// The workers push the frames still queued, then stop.
 func (p *AsyncFrameProcessor) Cleanup() {
 	logger.Info("Cleanuping", "name", p.Name())
 	p.interruptionMu.Lock()
 	defer p.interruptionMu.Unlock()
 
-	// Cancel the push frame task
-	p.cancel()
-
-	// Wait for the task to finish
-	p.pushFrameTask.Wait()
-	if p.pushUpQueueSize > 0 {
-		p.pushUpFrameTask.Wait()
-	}
-
+	p.tasks.Load().drainAndStop()
 	logger.Info("Cleanup Done", "name", p.Name())
 }
 
-// HandleInterruptions handles interruption frames.
+// HandleInterruptions handles interruption frames: frames still queued are dropped,
+// the interruption frame is pushed out-of-band and the push tasks are restarted.
+// It does nothing if the StartFrame didn't allow interruptions.
 func (p *AsyncFrameProcessor) HandleInterruptions(frame frames.Frame) {
-	// out-of-band interruption handling
-	//if !p.allowInterruptions {
-	//	log.Printf("Warning: interruption frames are not allowed for processor %s", p.name)
-	//	return
-	//}
+	if !p.InterruptionsAllowed() {
+		logger.Warnf("interruption frames are not allowed for processor %s", p.Name())
+		return
+	}
 
 	p.interruptionMu.Lock()
 	defer p.interruptionMu.Unlock()
 
-	// Cancel the current task
-	p.cancel()
-
-	// Wait for the task to finish
-	p.pushFrameTask.Wait()
-	if p.pushUpQueueSize > 0 {
-		p.pushUpFrameTask.Wait()
-	}
-
-	// Reset the task
-	p.ctx, p.cancel = context.WithCancel(context.Background())
+	// Cancel the current tasks and wait for them to finish
+	p.stopPushTask()
 
 	// Push an out-of-band frame (not using the ordered push frame task)
 	p.PushFrame(frame, FrameDirectionDownstream)
 
-	// Create a new queue and task
-	p.pushQueue = make(chan pushItem, p.pushQueueSize)
-	p.pushFrameTask = &sync.WaitGroup{}
+	// Create new queues and tasks
 	p.createPushTask()
-	logger.Info("AsyncFrameProcessor createPushTask is OK!")
+}
 
-	if p.pushUpQueueSize > 0 {
-		// Create a new upstream queue and task
-		p.pushUpQueue = make(chan pushItem, p.pushUpQueueSize)
-		p.pushUpFrameTask = &sync.WaitGroup{}
-		p.createPushTask()
-		logger.Info("AsyncFrameProcessor createPushTask is OK!")
+// restartPushTask replaces the push tasks, dropping queued frames.
+func (p *AsyncFrameProcessor) restartPushTask() {
+	p.interruptionMu.Lock()
+	defer p.interruptionMu.Unlock()
+
+	p.stopPushTask()
+	p.createPushTask()
+}
+
+// stopPushTask stops the push tasks, the frames still queued are dropped.
+func (p *AsyncFrameProcessor) stopPushTask() {
+	tasks := p.tasks.Load()
+	tasks.stop()
+	for _, queue := range []chan pushItem{tasks.pushQueue, tasks.upQueue} {
+		for len(queue) > 0 {
+			item := <-queue
+			p.notifyDrop(item.frame, item.direction, DropReasonInterrupted)
+		}
 	}
 }
 
-// createPushTask creates a new push frame task.
+// createPushTask creates new push queues and starts their workers.
 func (p *AsyncFrameProcessor) createPushTask() {
-	if p.pushFrameTask != nil {
-		p.pushFrameTask.Add(1)
-		go p.pushFrameTaskHandler()
-		logger.Infof("%s create pushFrameTaskHandler", p.Name())
+	ctx, cancel := context.WithCancel(context.Background())
+	tasks := &pushTasks{
+		ctx:       ctx,
+		cancel:    cancel,
+		pushQueue: make(chan pushItem, p.pushQueueSize),
+		drain:     make(chan struct{}),
+	}
+	if p.pushUpQueueSize > 0 {
+		tasks.upQueue = make(chan pushItem, p.pushUpQueueSize)
 	}
 
-	if p.pushUpFrameTask != nil {
-		p.pushUpFrameTask.Add(1)
-		go p.pushUpFrameTaskHandler()
-		logger.Infof("%s create pushUpFrameTaskHandler", p.Name())
+	for i := 0; i < p.pushWorkers; i++ {
+		tasks.wg.Add(1)
+		go p.pushFrameTaskHandler(tasks, tasks.pushQueue)
+	}
+	logger.Infof("%s create %d pushFrameTaskHandler", p.Name(), p.pushWorkers)
+
+	if tasks.upQueue != nil {
+		for i := 0; i < p.pushUpWorkers; i++ {
+			tasks.wg.Add(1)
+			go p.pushFrameTaskHandler(tasks, tasks.upQueue)
+		}
+		logger.Infof("%s create %d pushUpFrameTaskHandler", p.Name(), p.pushUpWorkers)
 	}
+	p.tasks.Store(tasks)
 }
 
 // QueueFrame queues a frame for processing.
 func (p *AsyncFrameProcessor) QueueFrame(frame frames.Frame, direction FrameDirection) {
-	queue := p.pushQueue
-	if p.pushUpQueueSize > 0 && direction == FrameDirectionUpstream {
-		queue = p.pushUpQueue
+	tasks := p.tasks.Load()
+	queue, block := tasks.pushQueue, p.isPushBlock
+	if tasks.upQueue != nil && direction == FrameDirectionUpstream {
+		queue, block = tasks.upQueue, p.isUpPushBlock
 	}
+	item := pushItem{frame: frame, direction: direction, queuedAt: p.Clock().Now()}
 
-	if p.isPushBlock {
-		queue <- pushItem{frame: frame, direction: direction}
-	} else {
+	if block {
 		select {
-		case queue <- pushItem{frame: frame, direction: direction}:
-		default:
-			if p.pushUpQueueSize > 0 && direction == FrameDirectionUpstream {
-				logger.Warnf("Warning: pushUpQueue is full for %s, loss frame: %+v direction: %s", p.name, frame, direction)
-			} else {
-				logger.Warnf("Warning: pushQueue is full for %s, loss frame: %+v direction: %s", p.name, frame, direction)
-			}
+		case queue <- item:
+		case <-tasks.ctx.Done():
+			logger.Warnf("Warning: push tasks of %s are stopped, loss frame: %+v direction: %s", p.name, frame, direction)
+			p.notifyDrop(frame, direction, DropReasonStopped)
+		}
+		return
+	}
+	select {
+	case queue <- item:
+	default:
+		p.notifyDrop(frame, direction, DropReasonQueueFull)
+		if tasks.upQueue != nil && direction == FrameDirectionUpstream {
+			logger.Warnf("Warning: pushUpQueue is full for %s, loss frame: %+v direction: %s", p.name, frame, direction)
+		} else {
+			logger.Warnf("Warning: pushQueue is full for %s, loss frame: %+v direction: %s", p.name, frame, direction)
 		}
 	}
 }
+
+// QueueLen returns the number of frames queued to be pushed in direction.
+func (p *AsyncFrameProcessor) QueueLen(direction FrameDirection) int {
+	tasks := p.tasks.Load()
+	if tasks.upQueue != nil && direction == FrameDirectionUpstream {
+		return len(tasks.upQueue)
+	}
+	return len(tasks.pushQueue)
+}
+
+// StopTTFBMetrics stops the TTFB metrics collection and queues a MetricsFrame
+// if applicable, after the frames queued before.
+func (p *AsyncFrameProcessor) StopTTFBMetrics() {
+	if p.MetricsEnabled() {
+		if frame := p.metrics.StopTTFBMetrics(); frame != nil {
+			p.QueueFrame(frame, FrameDirectionDownstream)
+		}
+	}
+}
+
+// StopProcessingMetrics stops the processing time metrics collection and queues
+// a MetricsFrame if applicable, after the frames queued before.
+func (p *AsyncFrameProcessor) StopProcessingMetrics() {
+	if p.MetricsEnabled() {
+		if frame := p.metrics.StopProcessingMetrics(); frame != nil {
+			p.QueueFrame(frame, FrameDirectionDownstream)
+		}
+	}
+}
+
+// StopAllMetrics stops all metrics collection, queueing their MetricsFrames.
+func (p *AsyncFrameProcessor) StopAllMetrics() {
+	p.StopTTFBMetrics()
+	p.StopProcessingMetrics()
+}
+
+// StartLLMUsageMetrics queues a MetricsFrame with the token usage of a LLM completion if usage metrics are enabled.
+func (p *AsyncFrameProcessor) StartLLMUsageMetrics(usage frames.LLMTokenUsage) {
+	if p.UsageMetricsEnabled() {
+		p.QueueFrame(p.metrics.LLMUsageMetrics(usage), FrameDirectionDownstream)
+	}
+}
+
+// StartTTSUsageMetrics queues a MetricsFrame with the number of characters of text to synthesize if usage metrics are enabled.
+func (p *AsyncFrameProcessor) StartTTSUsageMetrics(text string) {
+	if p.UsageMetricsEnabled() {
+		p.QueueFrame(p.metrics.TTSUsageMetrics(text), FrameDirectionDownstream)
+	}
+}
+
 func (p *AsyncFrameProcessor) QueueUpStreamFrame(frame frames.Frame) {
 	p.QueueFrame(frame, FrameDirectionUpstream)
 }
@@ -223,73 +356,62 @@ func (p *AsyncFrameProcessor) QueueDownStreamFrame(frame frames.Frame) {
 	p.QueueFrame(frame, FrameDirectionDownstream)
 }
 
-// pushFrameTaskHandler is the handler for the push frame task.
-func (p *AsyncFrameProcessor) pushFrameTaskHandler() {
-	defer p.pushFrameTask.Done()
+// pushFrameTaskHandler pushes the frames of queue until the tasks are cancelled,
+// or drained once the tasks are stopping.
+// !NOTE:
+//   - if PushFrame is Slow(e.g.: local llm gen token slow),
+//     pushQueue maybe is full when push BotSpeakingFrame to upstream
+//   - use param pushUpQueueSize>0 to create upstream task queue
+func (p *AsyncFrameProcessor) pushFrameTaskHandler(tasks *pushTasks, queue chan pushItem) {
+	defer tasks.wg.Done()
 
-	running := true
-	for running {
+	for {
 		select {
-		case <-p.ctx.Done():
-			logger.Info(fmt.Sprintf("%s pushFrameTaskHandler cancelled", p.name))
+		case <-tasks.ctx.Done():
 			return
-		case item, ok := <-p.pushQueue:
-			if !ok {
-				// Channel closed
-				logger.Warn(fmt.Sprintf("%s push queue closed", p.name))
-				return
+		case item := <-queue:
+			p.handleQueued(tasks, item)
+		case <-tasks.drain:
+			for {
+				select {
+				case <-tasks.ctx.Done():
+					return
+				case item := <-queue:
+					p.handleQueued(tasks, item)
+				default:
+					return
+				}
 			}
-
-			// Push the frame
-			// !NOTE:
-			// - if PushFrame is Slow(e.g.: local llm gen token slow),
-			// 	pushQueue maybe is full when push BotSpeakingFrame to upstream
-			// - use param pushUpQueueSize>0 to create upstream task queue
-			// !TODONE:
-			// so need have two task queue: upstreamTask and downstreamTask,
-			// But need think one Prcessor to do Up/Down Frame at the same time
-			// if no shared stat, is ok.
-			p.PushFrame(item.frame, item.direction)
-
-			// Check if this is an End/Cancel frame
-			switch item.frame.(type) {
-			case *frames.EndFrame, *frames.CancelFrame:
-				running = false
-			}
-		case <-time.After(1 * time.Second):
-			// Timeout, continue the loop
-			continue
 		}
 	}
 }
 
-// pushUpFrameTaskHandler is the handler for the push upstream frame task.
-func (p *AsyncFrameProcessor) pushUpFrameTaskHandler() {
-	defer p.pushUpFrameTask.Done()
-
-	running := true
-	for running {
-		select {
-		case <-p.ctx.Done():
-			logger.Info(fmt.Sprintf("%s pushUpFrameTaskHandler cancelled", p.name))
-			return
-		case item, ok := <-p.pushUpQueue:
-			if !ok {
-				// Channel closed
-				logger.Warn(fmt.Sprintf("%s push UpQueue closed", p.name))
-				return
-			}
+// handleQueued pushes item, or passes it to the queue handler if any.
+func (p *AsyncFrameProcessor) handleQueued(tasks *pushTasks, item pushItem) {
+	// An item received as the tasks are cancelled is dropped like the ones still queued.
+	if tasks.ctx.Err() != nil {
+		p.notifyDrop(item.frame, item.direction, DropReasonInterrupted)
+		return
+	}
+	if p.queueHandler == nil {
+		p.pushFrame(item.frame, item.direction, item.queuedAt)
+		return
+	}
+	defer p.RecoverPanic(item.frame)
+	p.queueHandler(tasks.ctx, item.frame, item.direction)
+}
 
-			p.PushUpstreamFrame(item.frame)
+// drainAndStop lets the workers push the queued frames, waits for them and cancels the tasks.
+func (t *pushTasks) drainAndStop() {
+	t.drainOnce.Do(func() {
+		close(t.drain)
+	})
+	t.wg.Wait()
+	t.cancel()
+}
 
-			// Check if this is an End/Cancel frame
-			switch item.frame.(type) {
-			case *frames.EndFrame, *frames.CancelFrame:
-				running = false
-			}
-		case <-time.After(1 * time.Second):
-			// Timeout, continue the loop
-			continue
-		}
-	}
+// stop cancels the workers, dropping queued frames, and waits for them.
+func (t *pushTasks) stop() {
+	t.cancel()
+	t.wg.Wait()
 }
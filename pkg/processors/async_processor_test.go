@@ -1,17 +1,23 @@
 package processors
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/weedge/pipeline-go/pkg/frames"
+	"go.uber.org/goleak"
 )
 
 // mockProcessor is a simple processor for testing.
 type mockProcessor struct {
 	*FrameProcessor
+	mu             sync.Mutex
 	receivedFrames map[FrameDirection][]frames.Frame
 }
 
@@ -43,8 +49,11 @@ func (p *mockProcessor) SetPrev(prev IFrameProcessor) {
 }
 
 func (p *mockProcessor) ProcessFrame(frame frames.Frame, direction FrameDirection) {
+	p.mu.Lock()
 	p.receivedFrames[direction] = append(p.receivedFrames[direction], frame)
-	println("Added frame to receivedFrames, direction:", direction, "count:", len(p.receivedFrames[direction]), "processor:", p.Name())
+	count := len(p.receivedFrames[direction])
+	p.mu.Unlock()
+	println("Added frame to receivedFrames, direction:", direction, "count:", count, "processor:", p.Name())
 	p.PushFrame(frame, direction)
 }
 
@@ -183,3 +192,223 @@ func TestInterruptionAsyncFrameProcessor(t *testing.T) {
 	println(len(received_down))
 	assert.GreaterOrEqual(t, len(received_down), 1) // At least the interruption frame should be received
 }
+
+// slowCollector records downstream frames, taking delay for each one.
+type slowCollector struct {
+	*FrameProcessor
+	delay       time.Duration
+	mu          sync.Mutex
+	frames      []frames.Frame
+	inFlight    int
+	maxInFlight int
+}
+
+func (p *slowCollector) ProcessFrame(frame frames.Frame, direction FrameDirection) {
+	p.mu.Lock()
+	p.inFlight++
+	p.maxInFlight = max(p.maxInFlight, p.inFlight)
+	p.mu.Unlock()
+
+	time.Sleep(p.delay)
+
+	p.mu.Lock()
+	p.inFlight--
+	p.frames = append(p.frames, frame)
+	p.mu.Unlock()
+}
+
+func (p *slowCollector) received() []frames.Frame {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]frames.Frame(nil), p.frames...)
+}
+
+// dropObserver records the frames dropped by the observed processors.
+type dropObserver struct {
+	mu      sync.Mutex
+	dropped []FrameDropEvent
+}
+
+func (o *dropObserver) OnPushFrame(event FramePushEvent) {}
+
+func (o *dropObserver) OnDropFrame(event FrameDropEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.dropped = append(o.dropped, event)
+}
+
+func TestAsyncFrameProcessor_InterruptionDropsQueuedFrames(t *testing.T) {
+	collector := &slowCollector{FrameProcessor: NewFrameProcessor("slow_collector"), delay: 5 * time.Millisecond}
+	asyncProc := NewAsyncFrameProcessor("interrupt_processor").WithPorcessFrameAllowPush(true)
+	asyncProc.Link(collector)
+	observer := &dropObserver{}
+	asyncProc.AddObserver(observer)
+
+	startFrame := frames.NewStartFrame()
+	startFrame.AllowInterruptions = true
+	asyncProc.ProcessFrame(startFrame, FrameDirectionDownstream)
+	for i := 0; i < 50; i++ {
+		asyncProc.ProcessFrame(frames.NewTextFrame(fmt.Sprintf("stale %d", i)), FrameDirectionDownstream)
+	}
+	asyncProc.ProcessFrame(frames.NewStartInterruptionFrame(), FrameDirectionDownstream)
+	asyncProc.ProcessFrame(frames.NewTextFrame("fresh"), FrameDirectionDownstream)
+
+	assert.Eventually(t, func() bool {
+		received := collector.received()
+		if len(received) == 0 {
+			return false
+		}
+		last, ok := received[len(received)-1].(*frames.TextFrame)
+		return ok && last.Text == "fresh"
+	}, 2*time.Second, 10*time.Millisecond)
+	asyncProc.Cleanup()
+
+	received := collector.received()
+	interruptions, stale := 0, 0
+	var after []frames.Frame
+	for _, f := range received {
+		if isType[*frames.StartInterruptionFrame](f) {
+			interruptions++
+			continue
+		}
+		if interruptions > 0 {
+			after = append(after, f)
+		} else if isType[*frames.TextFrame](f) {
+			stale++
+		}
+	}
+	// The interruption is pushed once, queued stale text is dropped rather than delivered.
+	assert.Equal(t, 1, interruptions)
+	assert.Less(t, stale, 50)
+	assert.Equal(t, 1, len(after))
+	assert.Equal(t, "fresh", after[0].(*frames.TextFrame).Text)
+	// The dropped frames are reported to the observers.
+	observer.mu.Lock()
+	defer observer.mu.Unlock()
+	droppedText := 0
+	for _, event := range observer.dropped {
+		assert.Equal(t, DropReasonInterrupted, event.Reason)
+		assert.Equal(t, "interrupt_processor", event.Processor.Name())
+		if isType[*frames.TextFrame](event.Frame) {
+			droppedText++
+		}
+	}
+	assert.Equal(t, 50-stale, droppedText)
+}
+
+func TestAsyncFrameProcessor_InterruptionNotAllowed(t *testing.T) {
+	collector := &slowCollector{FrameProcessor: NewFrameProcessor("slow_collector")}
+	asyncProc := NewAsyncFrameProcessor("no_interrupt_processor").WithPorcessFrameAllowPush(true)
+	asyncProc.Link(collector)
+
+	asyncProc.ProcessFrame(frames.NewStartFrame(), FrameDirectionDownstream)
+	asyncProc.ProcessFrame(frames.NewTextFrame("kept"), FrameDirectionDownstream)
+	asyncProc.ProcessFrame(frames.NewStartInterruptionFrame(), FrameDirectionDownstream)
+	asyncProc.ProcessFrame(frames.NewTextFrame("after"), FrameDirectionDownstream)
+
+	assert.Eventually(t, func() bool { return len(collector.received()) == 4 }, 2*time.Second, 10*time.Millisecond)
+	asyncProc.Cleanup()
+
+	// Nothing is dropped, the interruption keeps its place in the stream.
+	received := collector.received()
+	assert.IsType(t, &frames.TextFrame{}, received[1])
+	assert.IsType(t, &frames.StartInterruptionFrame{}, received[2])
+	assert.IsType(t, &frames.TextFrame{}, received[3])
+}
+
+func isType[T frames.Frame](frame frames.Frame) bool {
+	_, ok := frame.(T)
+	return ok
+}
+
+func TestAsyncFrameProcessor_NoGoroutineLeak(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	collector := &slowCollector{FrameProcessor: NewFrameProcessor("collector")}
+	asyncProc := NewAsyncFrameProcessor("leak_processor").
+		WithPorcessFrameAllowPush(true).
+		WithPushWorkers(3).
+		WithPushUpWorkers(2)
+	asyncProc.Link(collector)
+
+	startFrame := frames.NewStartFrame()
+	startFrame.AllowInterruptions = true
+	asyncProc.ProcessFrame(startFrame, FrameDirectionDownstream)
+	for i := 0; i < 20; i++ {
+		for j := 0; j < 10; j++ {
+			asyncProc.ProcessFrame(frames.NewTextFrame(fmt.Sprintf("%d-%d", i, j)), FrameDirectionDownstream)
+			asyncProc.QueueUpStreamFrame(frames.NewTextFrame("up"))
+		}
+		asyncProc.ProcessFrame(frames.NewStartInterruptionFrame(), FrameDirectionDownstream)
+	}
+	asyncProc.ProcessFrame(frames.NewEndFrame(), FrameDirectionDownstream)
+	// A second Cleanup (e.g. from the pipeline) is a no-op.
+	asyncProc.Cleanup()
+
+	received := collector.received()
+	assert.IsType(t, &frames.EndFrame{}, received[len(received)-1])
+}
+
+func TestAsyncFrameProcessor_Workers(t *testing.T) {
+	collector := &slowCollector{FrameProcessor: NewFrameProcessor("collector"), delay: time.Millisecond}
+	asyncProc := NewAsyncFrameProcessor("workers_processor").WithPorcessFrameAllowPush(true).WithPushWorkers(4)
+	asyncProc.Link(collector)
+	assert.Equal(t, 4, asyncProc.PushWorkers())
+	assert.Equal(t, 1, asyncProc.PushUpWorkers())
+
+	for i := 0; i < 40; i++ {
+		asyncProc.ProcessFrame(frames.NewTextFrame(fmt.Sprintf("%d", i)), FrameDirectionDownstream)
+	}
+	asyncProc.Cleanup()
+
+	// Every queued frame is pushed before Cleanup returns, by workers running concurrently.
+	assert.Equal(t, 40, len(collector.received()))
+	assert.Greater(t, collector.maxInFlight, 1)
+}
+
+func TestAsyncFrameProcessor_CancelDropsQueuedFrames(t *testing.T) {
+	collector := &slowCollector{FrameProcessor: NewFrameProcessor("collector"), delay: 5 * time.Millisecond}
+	asyncProc := NewAsyncFrameProcessor("cancel_processor").WithPorcessFrameAllowPush(true)
+	asyncProc.Link(collector)
+
+	for i := 0; i < 50; i++ {
+		asyncProc.ProcessFrame(frames.NewTextFrame(fmt.Sprintf("%d", i)), FrameDirectionDownstream)
+	}
+	asyncProc.ProcessFrame(frames.NewCancelFrame(), FrameDirectionDownstream)
+
+	received := collector.received()
+	assert.Less(t, len(received), 51)
+	assert.IsType(t, &frames.CancelFrame{}, received[len(received)-1])
+}
+
+func TestAsyncFrameProcessor_QueueHandler(t *testing.T) {
+	collector := &slowCollector{FrameProcessor: NewFrameProcessor("collector")}
+	asyncProc := NewAsyncFrameProcessor("handler_processor")
+	asyncProc.Link(collector)
+	var handled []string
+	asyncProc.WithQueueHandler(func(ctx context.Context, frame frames.Frame, direction FrameDirection) {
+		text := frame.(*frames.TextFrame).Text
+		if text == "boom" {
+			panic(text)
+		}
+		handled = append(handled, text)
+	})
+
+	// The frames are handled in order, a panic goes upstream as an ErrorFrame.
+	up := NewMockProcessorWithName("up")
+	asyncProc.SetPrev(up)
+	for _, text := range []string{"one", "boom", "two"} {
+		asyncProc.QueueFrame(frames.NewTextFrame(text), FrameDirectionDownstream)
+	}
+	asyncProc.Cleanup()
+
+	assert.Equal(t, []string{"one", "two"}, handled)
+	assert.Empty(t, collector.received())
+	assert.Equal(t, uint64(1), asyncProc.PanicCount())
+	received := up.GetReceivedDirectionUpstreamFrames()
+	if assert.Equal(t, 1, len(received)) {
+		var panicErr *PanicError
+		assert.True(t, errors.As(received[0].(*frames.ErrorFrame).Error, &panicErr))
+		assert.Equal(t, "handler_processor", panicErr.Processor)
+	}
+}
@@ -0,0 +1,124 @@
+package audio
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+// AudioChunker re-slices downstream AudioRawFrames into frames of a fixed duration.
+// Remainders are carried over to the next frame; on EndFrame the tail is either
+// padded with silence to a full chunk or flushed as a shorter frame. The buffered
+// audio is dropped on an allowed StartInterruptionFrame.
+type AudioChunker struct {
+	*processors.FrameProcessor
+	duration    time.Duration
+	padLast     bool
+	buffer      []byte
+	sampleRate  int
+	numChannels int
+	sampleWidth int
+}
+
+// NewAudioChunker creates a new AudioChunker emitting frames of the given duration (e.g. 20ms).
+func NewAudioChunker(duration time.Duration) *AudioChunker {
+	return &AudioChunker{
+		FrameProcessor: processors.NewFrameProcessor("AudioChunker"),
+		duration:       duration,
+	}
+}
+
+// WithPadLast pads the tail with silence to a full chunk on EndFrame instead of flushing a short frame.
+func (p *AudioChunker) WithPadLast(padLast bool) *AudioChunker {
+	p.padLast = padLast
+	return p
+}
+
+// Duration returns the duration of the emitted frames.
+func (p *AudioChunker) Duration() time.Duration {
+	return p.duration
+}
+
+// BufferedBytes returns the number of audio bytes waiting for a full chunk.
+func (p *AudioChunker) BufferedBytes() int {
+	return len(p.buffer)
+}
+
+// ProcessFrame buffers downstream audio and pushes fixed size chunks.
+func (p *AudioChunker) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	p.FrameProcessor.ProcessFrame(frame, direction)
+	if p.ShouldInterrupt(frame) {
+		p.buffer = nil
+	}
+
+	switch f := frame.(type) {
+	case *frames.AudioRawFrame:
+		if direction != processors.FrameDirectionDownstream {
+			p.PushFrame(f, direction)
+			return
+		}
+		if f.SampleRate != p.sampleRate || f.NumChannels != p.numChannels || f.SampleWidth != p.sampleWidth {
+			// Don't mix audio formats in one chunk.
+			p.flush(direction)
+			p.sampleRate, p.numChannels, p.sampleWidth = f.SampleRate, f.NumChannels, f.SampleWidth
+		}
+		p.buffer = append(p.buffer, f.Audio...)
+		size := p.chunkSize()
+		if size <= 0 {
+			p.PushError(frames.NewErrorFrame(fmt.Errorf("%s invalid audio format: %s", p.Name(), f), false))
+			p.buffer = nil
+			return
+		}
+		for len(p.buffer) >= size {
+			p.pushChunk(p.buffer[:size], direction)
+			p.buffer = p.buffer[size:]
+		}
+		if len(p.buffer) == 0 {
+			p.buffer = nil
+		}
+	case *frames.EndFrame:
+		p.flush(direction)
+		p.PushFrame(f, direction)
+	default:
+		p.PushFrame(frame, direction)
+	}
+}
+
+// chunkSize returns the chunk size in bytes for the current audio format.
+func (p *AudioChunker) chunkSize() int {
+	numFrames := int(int64(p.sampleRate) * int64(p.duration) / int64(time.Second))
+	return numFrames * p.numChannels * p.sampleWidth
+}
+
+// flush pushes the buffered remainder, padded with silence if configured.
+func (p *AudioChunker) flush(direction processors.FrameDirection) {
+	if len(p.buffer) == 0 {
+		return
+	}
+	tail := p.buffer
+	p.buffer = nil
+
+	if size := p.chunkSize(); p.padLast && len(tail) < size {
+		silence := byte(0)
+		if p.sampleWidth == 1 {
+			silence = 0x80 // 8-bit PCM is unsigned
+		}
+		tail = append(tail, bytes.Repeat([]byte{silence}, size-len(tail))...)
+	}
+	// Drop a partial sample, it can't be represented in the output format.
+	if frameSize := p.numChannels * p.sampleWidth; frameSize > 0 {
+		tail = tail[:len(tail)/frameSize*frameSize]
+	}
+	if len(tail) > 0 {
+		p.pushChunk(tail, direction)
+	}
+}
+
+func (p *AudioChunker) pushChunk(chunk []byte, direction processors.FrameDirection) {
+	audio := make([]byte, len(chunk))
+	copy(audio, chunk)
+	p.PushFrame(frames.NewAudioRawFrame(audio, p.sampleRate, p.numChannels, p.sampleWidth), direction)
+}
@@ -0,0 +1,118 @@
+package audio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+// ramp returns n bytes 0,1,2... so chunk boundaries can be checked.
+func ramp(start, n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(start + i)
+	}
+	return b
+}
+
+func TestAudioChunker_FixedDuration(t *testing.T) {
+	mockProc := NewMockProcessor()
+	chunker := NewAudioChunker(20 * time.Millisecond)
+	chunker.Link(mockProc)
+
+	// 16kHz mono 16-bit: 20ms = 320 frames = 640 bytes.
+	var sent []byte
+	for _, n := range []int{100, 1000, 250, 1210} {
+		data := ramp(len(sent), n)
+		sent = append(sent, data...)
+		chunker.ProcessFrame(frames.NewAudioRawFrame(data, 16000, 1, 2), processors.FrameDirectionDownstream)
+	}
+	assert.Equal(t, 2560-4*640, chunker.BufferedBytes())
+
+	audio := mockProc.audioFrames()
+	assert.Equal(t, 4, len(audio))
+	var received []byte
+	for _, f := range audio {
+		assert.Equal(t, 640, len(f.Audio))
+		assert.Equal(t, 320, f.NumFrames)
+		assert.Equal(t, 16000, f.SampleRate)
+		received = append(received, f.Audio...)
+	}
+	assert.Equal(t, sent[:len(received)], received)
+}
+
+func TestAudioChunker_EndFrameFlush(t *testing.T) {
+	mockProc := NewMockProcessor()
+	chunker := NewAudioChunker(30 * time.Millisecond)
+	chunker.Link(mockProc)
+
+	// 48kHz stereo 16-bit: 30ms = 1440 frames = 5760 bytes.
+	chunker.ProcessFrame(frames.NewAudioRawFrame(ramp(0, 6000), 48000, 2, 2), processors.FrameDirectionDownstream)
+	chunker.ProcessFrame(frames.NewEndFrame(), processors.FrameDirectionDownstream)
+
+	received := mockProc.GetReceivedFrames(processors.FrameDirectionDownstream)
+	assert.Equal(t, 3, len(received))
+	assert.Equal(t, 1440, received[0].(*frames.AudioRawFrame).NumFrames)
+	assert.Equal(t, 60, received[1].(*frames.AudioRawFrame).NumFrames)
+	assert.IsType(t, &frames.EndFrame{}, received[2])
+	assert.Equal(t, 0, chunker.BufferedBytes())
+}
+
+func TestAudioChunker_EndFramePad(t *testing.T) {
+	mockProc := NewMockProcessor()
+	chunker := NewAudioChunker(10 * time.Millisecond).WithPadLast(true)
+	chunker.Link(mockProc)
+
+	chunker.ProcessFrame(frames.NewAudioRawFrame([]byte{1, 2, 3}, 8000, 1, 1), processors.FrameDirectionDownstream)
+	chunker.ProcessFrame(frames.NewEndFrame(), processors.FrameDirectionDownstream)
+
+	audio := mockProc.audioFrames()
+	assert.Equal(t, 1, len(audio))
+	assert.Equal(t, 80, audio[0].NumFrames)
+	assert.Equal(t, []byte{1, 2, 3, 0x80}, audio[0].Audio[:4])
+	assert.Equal(t, byte(0x80), audio[0].Audio[79])
+}
+
+func TestAudioChunker_Interruption(t *testing.T) {
+	mockProc := NewMockProcessor()
+	chunker := NewAudioChunker(20 * time.Millisecond)
+	chunker.Link(mockProc)
+	allowInterruptions(chunker)
+
+	chunker.ProcessFrame(frames.NewAudioRawFrame(ramp(0, 600), 16000, 1, 2), processors.FrameDirectionDownstream)
+	chunker.ProcessFrame(frames.NewStartInterruptionFrame(), processors.FrameDirectionDownstream)
+	assert.Equal(t, 0, chunker.BufferedBytes())
+
+	chunker.ProcessFrame(frames.NewAudioRawFrame(ramp(100, 640), 16000, 1, 2), processors.FrameDirectionDownstream)
+
+	received := mockProc.GetReceivedFrames(processors.FrameDirectionDownstream)
+	assert.Equal(t, 3, len(received))
+	assert.IsType(t, &frames.StartInterruptionFrame{}, received[1])
+	// No pre-interruption audio leaks into the next chunk.
+	assert.Equal(t, ramp(100, 640), received[2].(*frames.AudioRawFrame).Audio)
+
+	// Interruptions not allowed: the buffer is kept.
+	chunker = NewAudioChunker(20 * time.Millisecond)
+	chunker.ProcessFrame(frames.NewAudioRawFrame(ramp(0, 600), 16000, 1, 2), processors.FrameDirectionDownstream)
+	chunker.ProcessFrame(frames.NewStartInterruptionFrame(), processors.FrameDirectionDownstream)
+	assert.Equal(t, 600, chunker.BufferedBytes())
+}
+
+func TestAudioChunker_FormatChange(t *testing.T) {
+	mockProc := NewMockProcessor()
+	chunker := NewAudioChunker(20 * time.Millisecond)
+	chunker.Link(mockProc)
+
+	chunker.ProcessFrame(frames.NewAudioRawFrame(ramp(0, 100), 16000, 1, 2), processors.FrameDirectionDownstream)
+	chunker.ProcessFrame(frames.NewAudioRawFrame(ramp(0, 1280), 16000, 2, 2), processors.FrameDirectionDownstream)
+
+	audio := mockProc.audioFrames()
+	assert.Equal(t, 2, len(audio))
+	assert.Equal(t, 1, audio[0].NumChannels)
+	assert.Equal(t, 50, audio[0].NumFrames)
+	assert.Equal(t, 2, audio[1].NumChannels)
+	assert.Equal(t, 320, audio[1].NumFrames)
+}
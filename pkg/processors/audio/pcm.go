@@ -0,0 +1,176 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// SampleFormat describes how PCM samples are encoded in AudioRawFrame.Audio.
+// All multi-byte formats are little endian.
+type SampleFormat int
+
+const (
+	// SampleFormatAuto derives the format from the frame's SampleWidth.
+	SampleFormatAuto SampleFormat = iota
+	// SampleFormatU8 is 8-bit unsigned PCM (128 is silence).
+	SampleFormatU8
+	// SampleFormatS16 is 16-bit signed PCM.
+	SampleFormatS16
+	// SampleFormatS32 is 32-bit signed PCM.
+	SampleFormatS32
+	// SampleFormatF32 is 32-bit IEEE float PCM in [-1, 1].
+	SampleFormatF32
+)
+
+// String returns the string representation of SampleFormat
+func (f SampleFormat) String() string {
+	switch f {
+	case SampleFormatAuto:
+		return "auto"
+	case SampleFormatU8:
+		return "u8"
+	case SampleFormatS16:
+		return "s16le"
+	case SampleFormatS32:
+		return "s32le"
+	case SampleFormatF32:
+		return "f32le"
+	default:
+		return "unknown"
+	}
+}
+
+// Width returns the number of bytes per sample, 0 for SampleFormatAuto.
+func (f SampleFormat) Width() int {
+	switch f {
+	case SampleFormatU8:
+		return 1
+	case SampleFormatS16:
+		return 2
+	case SampleFormatS32, SampleFormatF32:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// SampleFormatFromWidth maps a sample width in bytes to an integer PCM format.
+// A width of 4 is treated as SampleFormatS32; use SampleFormatF32 explicitly for float audio.
+func SampleFormatFromWidth(width int) SampleFormat {
+	switch width {
+	case 1:
+		return SampleFormatU8
+	case 2:
+		return SampleFormatS16
+	case 4:
+		return SampleFormatS32
+	default:
+		return SampleFormatAuto
+	}
+}
+
+// resolve returns f, or the format derived from width when f is SampleFormatAuto.
+func (f SampleFormat) resolve(width int) SampleFormat {
+	if f == SampleFormatAuto {
+		return SampleFormatFromWidth(width)
+	}
+	return f
+}
+
+// DecodeSamples converts interleaved PCM bytes into normalized float samples in [-1, 1].
+// Trailing bytes that do not form a whole sample are ignored.
+func DecodeSamples(data []byte, format SampleFormat) ([]float64, error) {
+	width := format.Width()
+	if width == 0 {
+		return nil, fmt.Errorf("unsupported sample format: %s", format)
+	}
+
+	n := len(data) / width
+	samples := make([]float64, n)
+	for i := 0; i < n; i++ {
+		b := data[i*width:]
+		switch format {
+		case SampleFormatU8:
+			samples[i] = (float64(b[0]) - 128) / 128
+		case SampleFormatS16:
+			samples[i] = float64(int16(binary.LittleEndian.Uint16(b))) / 32768
+		case SampleFormatS32:
+			samples[i] = float64(int32(binary.LittleEndian.Uint32(b))) / 2147483648
+		case SampleFormatF32:
+			samples[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+		}
+	}
+	return samples, nil
+}
+
+// EncodeSamples converts normalized float samples into interleaved PCM bytes.
+// Samples outside [-1, 1] are clipped.
+func EncodeSamples(samples []float64, format SampleFormat) ([]byte, error) {
+	width := format.Width()
+	if width == 0 {
+		return nil, fmt.Errorf("unsupported sample format: %s", format)
+	}
+
+	data := make([]byte, len(samples)*width)
+	for i, s := range samples {
+		b := data[i*width:]
+		switch format {
+		case SampleFormatU8:
+			b[0] = uint8(quantize(s, 128) + 128)
+		case SampleFormatS16:
+			binary.LittleEndian.PutUint16(b, uint16(int16(quantize(s, 32768))))
+		case SampleFormatS32:
+			binary.LittleEndian.PutUint32(b, uint32(int32(quantize(s, 2147483648))))
+		case SampleFormatF32:
+			binary.LittleEndian.PutUint32(b, math.Float32bits(float32(clip(s))))
+		}
+	}
+	return data, nil
+}
+
+// quantize scales s to an integer in [-scale, scale-1].
+func quantize(s float64, scale float64) int64 {
+	v := math.Round(clip(s) * scale)
+	if v > scale-1 {
+		v = scale - 1
+	}
+	return int64(v)
+}
+
+func clip(s float64) float64 {
+	if s > 1 {
+		return 1
+	}
+	if s < -1 {
+		return -1
+	}
+	return s
+}
+
+// ConvertChannels remixes interleaved samples from inChannels to outChannels.
+// Down-mixing to mono averages all channels, up-mixing from mono duplicates the
+// channel, and any other layout maps output channel i to input channel i%inChannels.
+func ConvertChannels(samples []float64, inChannels, outChannels int) []float64 {
+	if inChannels == outChannels || inChannels <= 0 || outChannels <= 0 {
+		return samples
+	}
+
+	numFrames := len(samples) / inChannels
+	out := make([]float64, numFrames*outChannels)
+	for i := 0; i < numFrames; i++ {
+		in := samples[i*inChannels : (i+1)*inChannels]
+		if outChannels == 1 {
+			sum := 0.0
+			for _, s := range in {
+				sum += s
+			}
+			out[i] = sum / float64(inChannels)
+			continue
+		}
+		for c := 0; c < outChannels; c++ {
+			out[i*outChannels+c] = in[c%inChannels]
+		}
+	}
+	return out
+}
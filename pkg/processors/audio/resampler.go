@@ -0,0 +1,212 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// resamplerZeroCrossings is the number of sinc zero crossings kept on each side of the kernel.
+	resamplerZeroCrossings = 16
+	// resamplerRolloff moves the low-pass cutoff slightly below Nyquist to leave room for the transition band.
+	resamplerRolloff = 0.95
+	// resamplerKaiserBeta trades main-lobe width for ~80dB stop-band attenuation.
+	resamplerKaiserBeta = 8.6
+	// resamplerMaxTableSize bounds the precomputed polyphase table (phases * taps).
+	resamplerMaxTableSize = 1 << 18
+)
+
+// Resampler is a streaming band-limited (windowed sinc) sample rate converter.
+// It keeps its filter history between calls to Process, so feeding a signal in
+// arbitrary chunks yields exactly the same output as feeding it at once.
+type Resampler struct {
+	inRate   int64 // reduced by gcd
+	outRate  int64 // reduced by gcd
+	channels int
+	width    int64   // taps on each side of the kernel center, in input samples
+	cutoff   float64 // normalized to the input Nyquist frequency
+	table    [][]float64
+
+	history  [][]float64 // per channel, history[c][0] is input sample index base
+	base     int64
+	received int64
+	produced int64
+}
+
+// NewResampler creates a Resampler converting interleaved audio with the given
+// number of channels from inRate to outRate, both positive.
+func NewResampler(inRate, outRate, channels int) (*Resampler, error) {
+	if inRate <= 0 || outRate <= 0 {
+		return nil, fmt.Errorf("invalid resampler rates %d -> %d", inRate, outRate)
+	}
+	if channels <= 0 {
+		channels = 1
+	}
+	g := gcd(int64(inRate), int64(outRate))
+	r := &Resampler{
+		inRate:   int64(inRate) / g,
+		outRate:  int64(outRate) / g,
+		channels: channels,
+		cutoff:   math.Min(1, float64(outRate)/float64(inRate)) * resamplerRolloff,
+	}
+	r.width = int64(math.Ceil(resamplerZeroCrossings / r.cutoff))
+	if r.outRate*2*r.width <= resamplerMaxTableSize {
+		r.table = make([][]float64, r.outRate)
+	}
+	r.Reset()
+	return r, nil
+}
+
+// Reset drops all buffered history, as if the resampler had just been created.
+func (r *Resampler) Reset() {
+	r.history = make([][]float64, r.channels)
+	for c := range r.history {
+		// Pre-pad with silence so the first output sample is centered on input sample 0.
+		r.history[c] = make([]float64, r.width)
+	}
+	r.base = -r.width
+	r.received = 0
+	r.produced = 0
+}
+
+// Process consumes interleaved input samples and returns the interleaved output
+// samples that can be computed so far. Output lags the input by the kernel
+// half-width; call Flush at the end of the stream to drain it.
+func (r *Resampler) Process(samples []float64) []float64 {
+	numFrames := len(samples) / r.channels
+	for c := 0; c < r.channels; c++ {
+		h := r.history[c]
+		for i := 0; i < numFrames; i++ {
+			h = append(h, samples[i*r.channels+c])
+		}
+		r.history[c] = h
+	}
+	r.received += int64(numFrames)
+
+	var out []float64
+	for {
+		center := r.produced * r.inRate / r.outRate
+		if center+r.width >= r.received {
+			break
+		}
+		out = r.emit(out)
+	}
+	r.trim()
+	return out
+}
+
+// Flush returns the remaining output samples, treating input after the end of
+// the stream as silence, and resets the resampler.
+func (r *Resampler) Flush() []float64 {
+	var out []float64
+	for r.produced*r.inRate < r.received*r.outRate {
+		out = r.emit(out)
+	}
+	r.Reset()
+	return out
+}
+
+// emit computes one output frame (all channels) and appends it to out.
+func (r *Resampler) emit(out []float64) []float64 {
+	pos := r.produced * r.inRate
+	center := pos / r.outRate
+	kernel := r.kernel(pos % r.outRate)
+	first := center - r.width + 1
+	for c := 0; c < r.channels; c++ {
+		h := r.history[c]
+		sum := 0.0
+		for k, w := range kernel {
+			idx := first + int64(k) - r.base
+			if idx < 0 || idx >= int64(len(h)) {
+				continue
+			}
+			sum += h[idx] * w
+		}
+		out = append(out, sum)
+	}
+	r.produced++
+	return out
+}
+
+// trim drops history that no future output sample depends on.
+func (r *Resampler) trim() {
+	center := r.produced * r.inRate / r.outRate
+	drop := center - r.width + 1 - r.base
+	if drop <= 0 {
+		return
+	}
+	for c := range r.history {
+		if drop >= int64(len(r.history[c])) {
+			r.history[c] = r.history[c][:0]
+		} else {
+			r.history[c] = append(r.history[c][:0], r.history[c][drop:]...)
+		}
+	}
+	r.base += drop
+}
+
+// kernel returns the 2*width filter taps for the given phase (the fractional
+// input position phase/outRate), normalized to unit DC gain.
+func (r *Resampler) kernel(phase int64) []float64 {
+	if r.table != nil && r.table[phase] != nil {
+		return r.table[phase]
+	}
+
+	frac := float64(phase) / float64(r.outRate)
+	taps := make([]float64, 2*r.width)
+	sum := 0.0
+	for k := range taps {
+		// distance from the output position to input sample (center-width+1+k)
+		x := float64(int64(k)-r.width+1) - frac
+		taps[k] = r.cutoff * sinc(r.cutoff*x) * kaiser(x/float64(r.width), resamplerKaiserBeta)
+		sum += taps[k]
+	}
+	if sum != 0 {
+		for k := range taps {
+			taps[k] /= sum
+		}
+	}
+
+	if r.table != nil {
+		r.table[phase] = taps
+	}
+	return taps
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// kaiser evaluates a Kaiser window at x in [-1, 1].
+func kaiser(x, beta float64) float64 {
+	if x <= -1 || x >= 1 {
+		return 0
+	}
+	return besselI0(beta*math.Sqrt(1-x*x)) / besselI0(beta)
+}
+
+// besselI0 is the zeroth order modified Bessel function of the first kind.
+func besselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; k < 50; k++ {
+		term *= (x / (2 * float64(k))) * (x / (2 * float64(k)))
+		sum += term
+		if term < sum*1e-12 {
+			break
+		}
+	}
+	return sum
+}
+
+func gcd(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	if a == 0 {
+		return 1
+	}
+	return a
+}
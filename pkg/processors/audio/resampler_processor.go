@@ -0,0 +1,191 @@
+package audio
+
+import (
+	"fmt"
+
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/logger"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+// AudioResampler converts downstream AudioRawFrames to a target sample rate,
+// channel count and sample format.
+//
+// By default the target sample rate is read from StartFrame.AudioInSampleRate
+// (or AudioOutSampleRate, see WithUseAudioOutSampleRate), and the channel count
+// and sample format of the input are kept. An allowed StartInterruptionFrame
+// drops the resampler's buffered input.
+type AudioResampler struct {
+	*processors.FrameProcessor
+	sampleRate        int
+	useOutSampleRate  bool
+	startFrameRate    int
+	numChannels       int
+	inFormat          SampleFormat
+	outFormat         SampleFormat
+	resampler         *Resampler
+	resamplerInRate   int
+	resamplerOutRate  int
+	resamplerChannels int
+	resamplerFormat   SampleFormat
+}
+
+// NewAudioResampler creates a new AudioResampler.
+func NewAudioResampler() *AudioResampler {
+	return &AudioResampler{
+		FrameProcessor: processors.NewFrameProcessor("AudioResampler"),
+	}
+}
+
+// WithSampleRate sets a fixed target sample rate, ignoring the StartFrame.
+func (p *AudioResampler) WithSampleRate(sampleRate int) *AudioResampler {
+	p.sampleRate = sampleRate
+	return p
+}
+
+// WithUseAudioOutSampleRate makes the resampler follow StartFrame.AudioOutSampleRate
+// instead of StartFrame.AudioInSampleRate.
+func (p *AudioResampler) WithUseAudioOutSampleRate(useOutSampleRate bool) *AudioResampler {
+	p.useOutSampleRate = useOutSampleRate
+	return p
+}
+
+// WithNumChannels sets the target channel count (1 mono, 2 stereo), 0 keeps the input layout.
+func (p *AudioResampler) WithNumChannels(numChannels int) *AudioResampler {
+	p.numChannels = numChannels
+	return p
+}
+
+// WithInputFormat sets how input samples are decoded, needed to tell SampleFormatF32 from SampleFormatS32.
+func (p *AudioResampler) WithInputFormat(format SampleFormat) *AudioResampler {
+	p.inFormat = format
+	return p
+}
+
+// WithOutputFormat sets the target sample format, SampleFormatAuto keeps the input format.
+func (p *AudioResampler) WithOutputFormat(format SampleFormat) *AudioResampler {
+	p.outFormat = format
+	return p
+}
+
+// TargetSampleRate returns the sample rate output frames are converted to, 0 if not known yet.
+func (p *AudioResampler) TargetSampleRate() int {
+	if p.sampleRate > 0 {
+		return p.sampleRate
+	}
+	return p.startFrameRate
+}
+
+// ProcessFrame resamples downstream AudioRawFrames and passes everything else through.
+func (p *AudioResampler) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	p.FrameProcessor.ProcessFrame(frame, direction)
+	if p.ShouldInterrupt(frame) {
+		p.resampler = nil
+	}
+
+	switch f := frame.(type) {
+	case *frames.StartFrame:
+		if p.useOutSampleRate {
+			p.startFrameRate = f.AudioOutSampleRate
+		} else {
+			p.startFrameRate = f.AudioInSampleRate
+		}
+		p.PushFrame(f, direction)
+	case *frames.AudioRawFrame:
+		if direction != processors.FrameDirectionDownstream {
+			p.PushFrame(f, direction)
+			return
+		}
+		out, err := p.convert(f)
+		if err != nil {
+			p.PushError(frames.NewErrorFrame(err, false))
+			return
+		}
+		if out != nil {
+			p.PushFrame(out, direction)
+		}
+	case *frames.EndFrame:
+		p.flush(direction)
+		p.PushFrame(f, direction)
+	default:
+		p.PushFrame(frame, direction)
+	}
+}
+
+// convert returns the converted frame, nil while the resampler is still filling its history.
+func (p *AudioResampler) convert(frame *frames.AudioRawFrame) (*frames.AudioRawFrame, error) {
+	inFormat := p.inFormat.resolve(frame.SampleWidth)
+	outFormat := inFormat
+	if p.outFormat != SampleFormatAuto {
+		outFormat = p.outFormat
+	}
+	outRate := p.TargetSampleRate()
+	if outRate <= 0 {
+		outRate = frame.SampleRate
+	}
+	inChannels := max(frame.NumChannels, 1)
+	outChannels := inChannels
+	if p.numChannels > 0 {
+		outChannels = p.numChannels
+	}
+
+	if p.resampler != nil && (p.resamplerInRate != frame.SampleRate || p.resamplerOutRate != outRate ||
+		p.resamplerChannels != outChannels || p.resamplerFormat != outFormat) {
+		// Input layout changed mid-stream: drain the old filter state first.
+		logger.Warnf("%s input changed to %s, resetting resampler", p.Name(), frame)
+		p.flush(processors.FrameDirectionDownstream)
+	}
+
+	if outRate == frame.SampleRate && outChannels == inChannels && outFormat == inFormat {
+		return frame, nil
+	}
+
+	samples, err := DecodeSamples(frame.Audio, inFormat)
+	if err != nil {
+		return nil, fmt.Errorf("%s decode %s: %w", p.Name(), frame, err)
+	}
+	samples = ConvertChannels(samples, inChannels, outChannels)
+
+	if outRate != frame.SampleRate {
+		if p.resampler == nil {
+			resampler, err := NewResampler(frame.SampleRate, outRate, outChannels)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", p.Name(), frame, err)
+			}
+			p.resampler = resampler
+			p.resamplerInRate = frame.SampleRate
+			p.resamplerOutRate = outRate
+			p.resamplerChannels = outChannels
+			p.resamplerFormat = outFormat
+		}
+		samples = p.resampler.Process(samples)
+	}
+	if len(samples) == 0 {
+		return nil, nil
+	}
+
+	audio, err := EncodeSamples(samples, outFormat)
+	if err != nil {
+		return nil, fmt.Errorf("%s encode %s: %w", p.Name(), frame, err)
+	}
+	return frames.NewAudioRawFrame(audio, outRate, outChannels, outFormat.Width()), nil
+}
+
+// flush pushes the audio still held in the filter history.
+func (p *AudioResampler) flush(direction processors.FrameDirection) {
+	if p.resampler == nil {
+		return
+	}
+	samples := p.resampler.Flush()
+	p.resampler = nil
+	if len(samples) == 0 {
+		return
+	}
+
+	audio, err := EncodeSamples(samples, p.resamplerFormat)
+	if err != nil {
+		p.PushError(frames.NewErrorFrame(err, false))
+		return
+	}
+	p.PushFrame(frames.NewAudioRawFrame(audio, p.resamplerOutRate, p.resamplerChannels, p.resamplerFormat.Width()), direction)
+}
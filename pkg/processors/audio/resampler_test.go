@@ -0,0 +1,276 @@
+package audio
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/pipeline/pipelinetest"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+// mockProcessor is a simple processor for testing.
+type mockProcessor struct {
+	*processors.FrameProcessor
+	receivedFrames map[processors.FrameDirection][]frames.Frame
+}
+
+func NewMockProcessor() *mockProcessor {
+	return &mockProcessor{
+		FrameProcessor: processors.NewFrameProcessor("mock_processor"),
+		receivedFrames: make(map[processors.FrameDirection][]frames.Frame),
+	}
+}
+
+func (p *mockProcessor) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	p.receivedFrames[direction] = append(p.receivedFrames[direction], frame)
+	p.FrameProcessor.PushFrame(frame, direction)
+}
+
+func (p *mockProcessor) GetReceivedFrames(direction processors.FrameDirection) []frames.Frame {
+	return p.receivedFrames[direction]
+}
+
+// audioFrames returns the AudioRawFrames received downstream.
+func (p *mockProcessor) audioFrames() []*frames.AudioRawFrame {
+	var out []*frames.AudioRawFrame
+	for _, f := range p.receivedFrames[processors.FrameDirectionDownstream] {
+		if a, ok := f.(*frames.AudioRawFrame); ok {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// sine returns numFrames interleaved samples of a sine wave, identical on every channel.
+func sine(freq float64, sampleRate, numFrames, channels int, amplitude float64) []float64 {
+	out := make([]float64, 0, numFrames*channels)
+	for i := 0; i < numFrames; i++ {
+		v := amplitude * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate))
+		for c := 0; c < channels; c++ {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// snr returns the signal to noise ratio (dB) of got against want, skipping edge samples.
+func snr(want, got []float64, skip int) float64 {
+	var signal, noise float64
+	n := min(len(want), len(got))
+	for i := skip; i < n-skip; i++ {
+		signal += want[i] * want[i]
+		d := want[i] - got[i]
+		noise += d * d
+	}
+	if noise == 0 {
+		return math.Inf(1)
+	}
+	return 10 * math.Log10(signal/noise)
+}
+
+func rms(samples []float64, skip int) float64 {
+	var sum float64
+	n := 0
+	for i := skip; i < len(samples)-skip; i++ {
+		sum += samples[i] * samples[i]
+		n++
+	}
+	return math.Sqrt(sum / float64(n))
+}
+
+func resampleAll(r *Resampler, in []float64, chunk int) []float64 {
+	var out []float64
+	for i := 0; i < len(in); i += chunk {
+		end := min(i+chunk, len(in))
+		out = append(out, r.Process(in[i:end])...)
+	}
+	return append(out, r.Flush()...)
+}
+
+func newResampler(t *testing.T, inRate, outRate, channels int) *Resampler {
+	r, err := NewResampler(inRate, outRate, channels)
+	assert.NoError(t, err)
+	return r
+}
+
+func TestResampler_Quality(t *testing.T) {
+	tests := []struct {
+		name    string
+		inRate  int
+		outRate int
+		freq    float64
+	}{
+		{"downsample 48k to 16k", 48000, 16000, 1000},
+		{"upsample 16k to 48k", 16000, 48000, 1000},
+		{"upsample 16k to 44.1k", 16000, 44100, 3000},
+		{"downsample 44.1k to 16k", 44100, 16000, 440},
+		{"downsample 24k to 8k", 24000, 8000, 300},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := sine(tt.freq, tt.inRate, tt.inRate/2, 1, 0.8)
+			out := resampleAll(newResampler(t, tt.inRate, tt.outRate, 1), in, 4096)
+
+			// The output covers exactly the same duration as the input.
+			expectedLen := int(math.Ceil(float64(len(in)) * float64(tt.outRate) / float64(tt.inRate)))
+			assert.Equal(t, expectedLen, len(out))
+
+			want := sine(tt.freq, tt.outRate, len(out), 1, 0.8)
+			assert.Greater(t, snr(want, out, tt.outRate/100), 60.0)
+		})
+	}
+}
+
+func TestResampler_AntiAliasing(t *testing.T) {
+	// A 7kHz tone is above the 4kHz Nyquist frequency of 8kHz audio and must be filtered out.
+	in := sine(7000, 48000, 48000, 1, 0.8)
+	out := resampleAll(newResampler(t, 48000, 8000, 1), in, 4800)
+
+	level := 20 * math.Log10(rms(out, 80)/(0.8/math.Sqrt2))
+	assert.Less(t, level, -60.0)
+}
+
+func TestResampler_ChunkBoundaries(t *testing.T) {
+	in := sine(1000, 48000, 9600, 2, 0.5)
+
+	whole := resampleAll(newResampler(t, 48000, 16000, 2), in, len(in))
+	for _, chunk := range []int{2, 160, 962, 1920} {
+		chunked := resampleAll(newResampler(t, 48000, 16000, 2), in, chunk)
+		assert.Equal(t, whole, chunked, "chunk size %d", chunk)
+	}
+}
+
+func TestResampler_InvalidRates(t *testing.T) {
+	for _, rates := range [][2]int{{0, 16000}, {16000, 0}, {-8000, 16000}} {
+		_, err := NewResampler(rates[0], rates[1], 1)
+		assert.Error(t, err, "rates %v", rates)
+	}
+}
+
+func TestSampleFormats_RoundTrip(t *testing.T) {
+	in := sine(440, 16000, 160, 1, 0.9)
+
+	for _, format := range []SampleFormat{SampleFormatU8, SampleFormatS16, SampleFormatS32, SampleFormatF32} {
+		data, err := EncodeSamples(in, format)
+		assert.NoError(t, err)
+		assert.Equal(t, len(in)*format.Width(), len(data))
+
+		out, err := DecodeSamples(data, format)
+		assert.NoError(t, err)
+		// Quantization noise of each format: 8-bit is the coarsest.
+		minSNR := map[SampleFormat]float64{
+			SampleFormatU8: 40, SampleFormatS16: 85, SampleFormatS32: 120, SampleFormatF32: 120,
+		}[format]
+		assert.Greater(t, snr(in, out, 0), minSNR, format.String())
+	}
+
+	_, err := DecodeSamples([]byte{1, 2}, SampleFormatAuto)
+	assert.Error(t, err)
+}
+
+func TestConvertChannels(t *testing.T) {
+	stereo := []float64{0.2, 0.4, -0.2, -0.6}
+	assert.InDeltaSlice(t, []float64{0.3, -0.4}, ConvertChannels(stereo, 2, 1), 1e-12)
+
+	mono := []float64{0.1, -0.1}
+	assert.Equal(t, []float64{0.1, 0.1, -0.1, -0.1}, ConvertChannels(mono, 1, 2))
+}
+
+func TestAudioResampler_ProcessFrame(t *testing.T) {
+	mockProc := NewMockProcessor()
+	resampler := NewAudioResampler().WithNumChannels(1).WithOutputFormat(SampleFormatF32)
+	resampler.Link(mockProc)
+
+	startFrame := frames.NewStartFrame()
+	startFrame.AudioInSampleRate = 16000
+	resampler.ProcessFrame(startFrame, processors.FrameDirectionDownstream)
+	assert.Equal(t, 16000, resampler.TargetSampleRate())
+
+	// 48kHz stereo 16-bit input in uneven chunks.
+	in := sine(1000, 48000, 4800, 2, 0.5)
+	data, err := EncodeSamples(in, SampleFormatS16)
+	assert.NoError(t, err)
+	chunk := 4 * 997
+	for i := 0; i < len(data); i += chunk {
+		end := min(i+chunk, len(data))
+		resampler.ProcessFrame(frames.NewAudioRawFrame(data[i:end], 48000, 2, 2), processors.FrameDirectionDownstream)
+	}
+	resampler.ProcessFrame(frames.NewEndFrame(), processors.FrameDirectionDownstream)
+
+	received := mockProc.GetReceivedFrames(processors.FrameDirectionDownstream)
+	assert.IsType(t, &frames.StartFrame{}, received[0])
+	assert.IsType(t, &frames.EndFrame{}, received[len(received)-1])
+
+	var out []float64
+	for _, f := range mockProc.audioFrames() {
+		assert.Equal(t, 16000, f.SampleRate)
+		assert.Equal(t, 1, f.NumChannels)
+		assert.Equal(t, 4, f.SampleWidth)
+		assert.Equal(t, len(f.Audio)/4, f.NumFrames)
+		samples, err := DecodeSamples(f.Audio, SampleFormatF32)
+		assert.NoError(t, err)
+		out = append(out, samples...)
+	}
+	assert.Equal(t, 1600, len(out))
+	assert.Greater(t, snr(sine(1000, 16000, len(out), 1, 0.5), out, 160), 60.0)
+}
+
+func TestAudioResampler_PassThrough(t *testing.T) {
+	mockProc := NewMockProcessor()
+	resampler := NewAudioResampler()
+	resampler.Link(mockProc)
+
+	resampler.ProcessFrame(frames.NewStartFrame(), processors.FrameDirectionDownstream)
+	frame := frames.NewAudioRawFrame(make([]byte, 320), 16000, 1, 2)
+	resampler.ProcessFrame(frame, processors.FrameDirectionDownstream)
+
+	audio := mockProc.audioFrames()
+	assert.Equal(t, 1, len(audio))
+	assert.Same(t, frame, audio[0])
+}
+
+func TestAudioResampler_InvalidSampleRate(t *testing.T) {
+	// A frame without sample rate is dropped with an error, not resampled forever.
+	result := pipelinetest.RunTest(t,
+		[]processors.IFrameProcessor{NewAudioResampler().WithSampleRate(16000)},
+		[]frames.Frame{frames.NewAudioRawFrame(make([]byte, 3200), 0, 1, 2)},
+		nil,
+		[]frames.Frame{frames.NewErrorFrame(nil, false)},
+		pipelinetest.IgnoreFields("Error"),
+	)
+	if assert.Len(t, result.Up, 1) {
+		assert.ErrorContains(t, result.Up[0].Frame.(*frames.ErrorFrame).Error, "invalid resampler rates 0 -> 16000")
+	}
+}
+
+func TestAudioResampler_Interruption(t *testing.T) {
+	mockProc := NewMockProcessor()
+	resampler := NewAudioResampler().WithSampleRate(16000)
+	resampler.Link(mockProc)
+	allowInterruptions(resampler)
+
+	loud, err := EncodeSamples(sine(1000, 48000, 4800, 1, 0.9), SampleFormatS16)
+	assert.NoError(t, err)
+	resampler.ProcessFrame(frames.NewAudioRawFrame(loud, 48000, 1, 2), processors.FrameDirectionDownstream)
+	resampler.ProcessFrame(frames.NewStartInterruptionFrame(), processors.FrameDirectionDownstream)
+	resampler.ProcessFrame(frames.NewAudioRawFrame(make([]byte, 9600), 48000, 1, 2), processors.FrameDirectionDownstream)
+	resampler.ProcessFrame(frames.NewEndFrame(), processors.FrameDirectionDownstream)
+
+	// Only silence follows the interruption: the filter history of the loud tone is gone.
+	received := mockProc.GetReceivedFrames(processors.FrameDirectionDownstream)
+	interrupted := false
+	for _, f := range received {
+		switch f := f.(type) {
+		case *frames.StartInterruptionFrame:
+			interrupted = true
+		case *frames.AudioRawFrame:
+			if interrupted {
+				assert.Equal(t, make([]byte, len(f.Audio)), f.Audio)
+			}
+		}
+	}
+	assert.True(t, interrupted)
+}
@@ -0,0 +1,224 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+const (
+	// vadWindowSecs is the analysis window; incoming frames are split into windows of this size.
+	vadWindowSecs = 0.01
+	// vadEpsilon absorbs float rounding when summing window durations.
+	vadEpsilon = 1e-9
+)
+
+// VADState is the state of the voice activity detector.
+type VADState int
+
+const (
+	// VADStateQuiet means no speech.
+	VADStateQuiet VADState = iota
+	// VADStateStarting means speech energy was detected but not for StartSecs yet.
+	VADStateStarting
+	// VADStateSpeaking means the user is speaking.
+	VADStateSpeaking
+	// VADStateStopping means silence was detected but not for StopSecs yet.
+	VADStateStopping
+)
+
+// String returns the string representation of VADState
+func (s VADState) String() string {
+	switch s {
+	case VADStateQuiet:
+		return "Quiet"
+	case VADStateStarting:
+		return "Starting"
+	case VADStateSpeaking:
+		return "Speaking"
+	case VADStateStopping:
+		return "Stopping"
+	default:
+		return "Unknown"
+	}
+}
+
+// VADParams configures the energy based voice activity detector.
+type VADParams struct {
+	// StartThresholdDB is the RMS level (dBFS) above which a window counts as speech.
+	StartThresholdDB float64
+	// StopThresholdDB is the RMS level (dBFS) below which a window counts as silence
+	// while speaking. Keep it below StartThresholdDB for hysteresis.
+	StopThresholdDB float64
+	// StartSecs is the start hangover: how long speech must last before the user is speaking.
+	StartSecs float64
+	// StopSecs is the stop hangover: how long silence must last before the user has stopped.
+	StopSecs float64
+	// MinSpeechSecs is how long the user must have been speaking before an interruption
+	// is started, so short noises (coughs, clicks) don't barge in.
+	MinSpeechSecs float64
+}
+
+// DefaultVADParams returns VADParams suited for 16-bit speech audio.
+func DefaultVADParams() VADParams {
+	return VADParams{
+		StartThresholdDB: -35,
+		StopThresholdDB:  -40,
+		StartSecs:        0.2,
+		StopSecs:         0.8,
+		MinSpeechSecs:    0.2,
+	}
+}
+
+// VADProcessor analyzes downstream AudioRawFrame RMS energy and emits
+// StartInterruptionFrame/StopInterruptionFrame when the user starts and stops
+// speaking, plus UserStartedSpeakingFrame/UserStoppedSpeakingFrame if enabled.
+// Interruption frames are only emitted if the StartFrame allowed interruptions.
+// Events are pushed downstream before the audio frame that triggered them.
+type VADProcessor struct {
+	*processors.FrameProcessor
+	params                 VADParams
+	inFormat               SampleFormat
+	emitUserSpeakingFrames bool
+
+	state         VADState
+	stateSecs     float64 // time spent in the current Starting/Stopping state
+	speechSecs    float64 // time since the user started speaking
+	interrupting  bool
+	lastLevelDB   float64
+	pendingEvents []frames.Frame
+}
+
+// NewVADProcessor creates a new VADProcessor.
+func NewVADProcessor(params VADParams) *VADProcessor {
+	return &VADProcessor{
+		FrameProcessor: processors.NewFrameProcessor("VADProcessor"),
+		params:         params,
+		lastLevelDB:    math.Inf(-1),
+	}
+}
+
+// WithEmitUserSpeakingFrames enables UserStartedSpeakingFrame/UserStoppedSpeakingFrame output.
+func (p *VADProcessor) WithEmitUserSpeakingFrames(emit bool) *VADProcessor {
+	p.emitUserSpeakingFrames = emit
+	return p
+}
+
+// WithInputFormat sets how input samples are decoded, needed for SampleFormatF32 audio.
+func (p *VADProcessor) WithInputFormat(format SampleFormat) *VADProcessor {
+	p.inFormat = format
+	return p
+}
+
+// State returns the current VAD state.
+func (p *VADProcessor) State() VADState {
+	return p.state
+}
+
+// LastLevelDB returns the RMS level (dBFS) of the last analyzed window.
+func (p *VADProcessor) LastLevelDB() float64 {
+	return p.lastLevelDB
+}
+
+// ProcessFrame analyzes downstream audio and passes every frame through.
+func (p *VADProcessor) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	p.FrameProcessor.ProcessFrame(frame, direction)
+
+	if f, ok := frame.(*frames.AudioRawFrame); ok && direction == processors.FrameDirectionDownstream {
+		if err := p.analyze(f); err != nil {
+			p.PushError(frames.NewErrorFrame(err, false))
+		}
+		for _, event := range p.pendingEvents {
+			p.PushFrame(event, direction)
+		}
+		p.pendingEvents = p.pendingEvents[:0]
+	}
+
+	p.PushFrame(frame, direction)
+}
+
+// analyze splits the frame into analysis windows and feeds their level into the state machine.
+func (p *VADProcessor) analyze(frame *frames.AudioRawFrame) error {
+	if frame.SampleRate <= 0 {
+		return fmt.Errorf("%s invalid sample rate in %s", p.Name(), frame)
+	}
+	samples, err := DecodeSamples(frame.Audio, p.inFormat.resolve(frame.SampleWidth))
+	if err != nil {
+		return fmt.Errorf("%s decode %s: %w", p.Name(), frame, err)
+	}
+
+	channels := max(frame.NumChannels, 1)
+	window := max(int(vadWindowSecs*float64(frame.SampleRate)), 1) * channels
+	for start := 0; start < len(samples); start += window {
+		end := min(start+window, len(samples))
+		secs := float64((end-start)/channels) / float64(frame.SampleRate)
+		p.lastLevelDB = levelDB(samples[start:end])
+		p.update(p.lastLevelDB, secs)
+	}
+	return nil
+}
+
+// update advances the state machine by one analysis window.
+func (p *VADProcessor) update(level, secs float64) {
+	switch p.state {
+	case VADStateQuiet, VADStateStarting:
+		if level < p.params.StartThresholdDB {
+			p.state, p.stateSecs = VADStateQuiet, 0
+			return
+		}
+		if p.state == VADStateQuiet {
+			p.state, p.stateSecs = VADStateStarting, 0
+		}
+		p.stateSecs += secs
+		if p.stateSecs+vadEpsilon >= p.params.StartSecs {
+			p.speechSecs = p.stateSecs
+			p.state, p.stateSecs = VADStateSpeaking, 0
+			if p.emitUserSpeakingFrames {
+				p.pendingEvents = append(p.pendingEvents, frames.NewUserStartedSpeakingFrame())
+			}
+			p.maybeStartInterruption()
+		}
+	case VADStateSpeaking, VADStateStopping:
+		p.speechSecs += secs
+		if level >= p.params.StopThresholdDB {
+			p.state, p.stateSecs = VADStateSpeaking, 0
+			p.maybeStartInterruption()
+			return
+		}
+		if p.state == VADStateSpeaking {
+			p.state, p.stateSecs = VADStateStopping, 0
+		}
+		p.stateSecs += secs
+		if p.stateSecs+vadEpsilon >= p.params.StopSecs {
+			p.state, p.stateSecs, p.speechSecs = VADStateQuiet, 0, 0
+			if p.emitUserSpeakingFrames {
+				p.pendingEvents = append(p.pendingEvents, frames.NewUserStoppedSpeakingFrame())
+			}
+			if p.interrupting {
+				p.interrupting = false
+				p.pendingEvents = append(p.pendingEvents, frames.NewStopInterruptionFrame())
+			}
+		}
+	}
+}
+
+func (p *VADProcessor) maybeStartInterruption() {
+	if !p.interrupting && p.InterruptionsAllowed() && p.speechSecs+vadEpsilon >= p.params.MinSpeechSecs {
+		p.interrupting = true
+		p.pendingEvents = append(p.pendingEvents, frames.NewStartInterruptionFrame())
+	}
+}
+
+// levelDB returns the RMS level of samples in dBFS, -Inf for digital silence.
+func levelDB(samples []float64) float64 {
+	if len(samples) == 0 {
+		return math.Inf(-1)
+	}
+	sum := 0.0
+	for _, s := range samples {
+		sum += s * s
+	}
+	return 20 * math.Log10(math.Sqrt(sum/float64(len(samples))))
+}
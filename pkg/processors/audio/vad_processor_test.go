@@ -0,0 +1,141 @@
+package audio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+// pushAudio feeds secs of a tone (amplitude 0 for silence) to p as 20ms 16kHz frames.
+func pushAudio(t *testing.T, p processors.IFrameProcessor, secs float64, amplitude float64) {
+	numFrames := int(secs * 16000)
+	samples := sine(440, 16000, numFrames, 1, amplitude)
+	data, err := EncodeSamples(samples, SampleFormatS16)
+	assert.NoError(t, err)
+	for i := 0; i < len(data); i += 640 {
+		end := min(i+640, len(data))
+		p.ProcessFrame(frames.NewAudioRawFrame(data[i:end], 16000, 1, 2), processors.FrameDirectionDownstream)
+	}
+}
+
+// allowInterruptions sends p a StartFrame allowing interruptions.
+func allowInterruptions(p processors.IFrameProcessor) {
+	startFrame := frames.NewStartFrame()
+	startFrame.AllowInterruptions = true
+	p.ProcessFrame(startFrame, processors.FrameDirectionDownstream)
+}
+
+// eventFrames returns the non audio frames received downstream, StartFrame excluded.
+func (p *mockProcessor) eventFrames() []frames.Frame {
+	var out []frames.Frame
+	for _, f := range p.receivedFrames[processors.FrameDirectionDownstream] {
+		switch f.(type) {
+		case *frames.AudioRawFrame, *frames.StartFrame:
+		default:
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func TestVADProcessor_SpeechSegment(t *testing.T) {
+	mockProc := NewMockProcessor()
+	vad := NewVADProcessor(DefaultVADParams()).WithEmitUserSpeakingFrames(true)
+	vad.Link(mockProc)
+	allowInterruptions(vad)
+
+	pushAudio(t, vad, 0.5, 0)
+	assert.Equal(t, VADStateQuiet, vad.State())
+	assert.Empty(t, mockProc.eventFrames())
+
+	pushAudio(t, vad, 1.0, 0.3)
+	assert.Equal(t, VADStateSpeaking, vad.State())
+
+	// A short pause is bridged by the stop hangover.
+	pushAudio(t, vad, 0.3, 0)
+	assert.Equal(t, VADStateStopping, vad.State())
+	pushAudio(t, vad, 0.5, 0.3)
+	assert.Equal(t, VADStateSpeaking, vad.State())
+
+	pushAudio(t, vad, 1.0, 0)
+	assert.Equal(t, VADStateQuiet, vad.State())
+
+	events := mockProc.eventFrames()
+	assert.Equal(t, 4, len(events))
+	assert.IsType(t, &frames.UserStartedSpeakingFrame{}, events[0])
+	assert.IsType(t, &frames.StartInterruptionFrame{}, events[1])
+	assert.IsType(t, &frames.UserStoppedSpeakingFrame{}, events[2])
+	assert.IsType(t, &frames.StopInterruptionFrame{}, events[3])
+
+	// Audio is never dropped: 3.3s of 20ms frames.
+	assert.Equal(t, 165, len(mockProc.audioFrames()))
+}
+
+func TestVADProcessor_EventsBeforeTriggeringAudio(t *testing.T) {
+	mockProc := NewMockProcessor()
+	params := DefaultVADParams()
+	params.StartSecs, params.MinSpeechSecs = 0.02, 0
+	vad := NewVADProcessor(params)
+	vad.Link(mockProc)
+	allowInterruptions(vad)
+
+	pushAudio(t, vad, 0.02, 0.3)
+
+	received := mockProc.GetReceivedFrames(processors.FrameDirectionDownstream)
+	assert.Equal(t, 3, len(received))
+	assert.IsType(t, &frames.StartInterruptionFrame{}, received[1])
+	assert.IsType(t, &frames.AudioRawFrame{}, received[2])
+}
+
+func TestVADProcessor_InterruptionsNotAllowed(t *testing.T) {
+	mockProc := NewMockProcessor()
+	vad := NewVADProcessor(DefaultVADParams()).WithEmitUserSpeakingFrames(true)
+	vad.Link(mockProc)
+	vad.ProcessFrame(frames.NewStartFrame(), processors.FrameDirectionDownstream)
+
+	pushAudio(t, vad, 1.0, 0.3)
+	pushAudio(t, vad, 1.0, 0)
+
+	// Speaking is still reported, but nothing is interrupted.
+	events := mockProc.eventFrames()
+	assert.Equal(t, 2, len(events))
+	assert.IsType(t, &frames.UserStartedSpeakingFrame{}, events[0])
+	assert.IsType(t, &frames.UserStoppedSpeakingFrame{}, events[1])
+}
+
+func TestVADProcessor_ShortNoiseDoesNotInterrupt(t *testing.T) {
+	mockProc := NewMockProcessor()
+	params := DefaultVADParams()
+	params.MinSpeechSecs = 0.5
+	vad := NewVADProcessor(params).WithEmitUserSpeakingFrames(true)
+	vad.Link(mockProc)
+	allowInterruptions(vad)
+
+	// Too short to start speaking at all.
+	pushAudio(t, vad, 0.1, 0.3)
+	pushAudio(t, vad, 0.5, 0)
+	assert.Empty(t, mockProc.eventFrames())
+
+	// Long enough to speak, too short to interrupt.
+	pushAudio(t, vad, 0.3, 0.3)
+	pushAudio(t, vad, 1.0, 0)
+
+	events := mockProc.eventFrames()
+	assert.Equal(t, 2, len(events))
+	assert.IsType(t, &frames.UserStartedSpeakingFrame{}, events[0])
+	assert.IsType(t, &frames.UserStoppedSpeakingFrame{}, events[1])
+}
+
+func TestVADProcessor_Thresholds(t *testing.T) {
+	mockProc := NewMockProcessor()
+	vad := NewVADProcessor(DefaultVADParams())
+	vad.Link(mockProc)
+
+	// 0.01 amplitude sine is about -43dBFS: below the start threshold.
+	pushAudio(t, vad, 1.0, 0.01)
+	assert.Equal(t, VADStateQuiet, vad.State())
+	assert.InDelta(t, -43, vad.LastLevelDB(), 0.5)
+	assert.Empty(t, mockProc.eventFrames())
+}
@@ -24,6 +24,11 @@ func NewConcurrentProcessor(processor IFrameProcessor) *ConcurrentProcessor {
 	}
 }
 
+// Wrapped returns the processor run in its own goroutine.
+func (p *ConcurrentProcessor) Wrapped() IFrameProcessor {
+	return p.wrappedProcessor
+}
+
 // startWorker starts the internal goroutine that processes frames from the queue.
 func (p *ConcurrentProcessor) startWorker() {
 	p.wrappedProcessor.Link(&p.FrameProcessor)
@@ -52,3 +57,12 @@ func (p *ConcurrentProcessor) ProcessFrame(frame frames.Frame, direction FrameDi
 		p.inQueue <- frame
 	}
 }
+
+// QueueLen returns the number of frames waiting for the wrapped processor.
+// The frames of both directions share the queue, they're counted downstream.
+func (p *ConcurrentProcessor) QueueLen(direction FrameDirection) int {
+	if direction != FrameDirectionDownstream {
+		return 0
+	}
+	return len(p.inQueue)
+}
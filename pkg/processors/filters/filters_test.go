@@ -0,0 +1,72 @@
+package filters
+
+import (
+	"testing"
+
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/pipeline/pipelinetest"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+func newImageFrame(format string) *frames.ImageRawFrame {
+	return frames.NewImageRawFrame([]byte{}, frames.ImageSize{Width: 0, Height: 0}, format, "RGB")
+}
+
+func TestFrameFilter(t *testing.T) {
+	text := "你好"
+	textFilter := func(frame frames.Frame) bool {
+		if textFrame, ok := frame.(*frames.TextFrame); ok {
+			return textFrame.Text != text
+		}
+		return true
+	}
+	imageFilter := func(frame frames.Frame) bool {
+		_, ok := frame.(*frames.ImageRawFrame)
+		return !ok
+	}
+
+	pipelinetest.RunTest(t,
+		[]processors.IFrameProcessor{NewFrameFilter(textFilter), NewFrameFilter(imageFilter)},
+		[]frames.Frame{
+			frames.NewTextFrame(text),
+			frames.NewTextFrame("你好!"),
+			newImageFrame("PNG"),
+			frames.NewAudioRawFrame([]byte{}, 16000, 1, 2),
+		},
+		[]frames.Frame{
+			frames.NewTextFrame("你好!"),
+			frames.NewAudioRawFrame([]byte{}, 16000, 1, 2),
+		},
+		nil,
+	)
+}
+
+func TestTypeFilter(t *testing.T) {
+	pipelinetest.RunTest(t,
+		// Only TextFrames and AudioRawFrames pass, and the control frames.
+		[]processors.IFrameProcessor{NewTypeFilter([]any{&frames.TextFrame{}, &frames.AudioRawFrame{}})},
+		[]frames.Frame{
+			frames.NewTextFrame("one"),
+			newImageFrame("PNG"),
+			frames.NewAudioRawFrame([]byte{}, 16000, 1, 2),
+			frames.NewSyncFrame(),
+			frames.NewTextFrame("two"),
+		},
+		[]frames.Frame{
+			frames.NewTextFrame("one"),
+			frames.NewAudioRawFrame([]byte{}, 16000, 1, 2),
+			frames.NewSyncFrame(),
+			frames.NewTextFrame("two"),
+		},
+		nil,
+	)
+}
+
+func TestNullFilter(t *testing.T) {
+	pipelinetest.RunTest(t,
+		[]processors.IFrameProcessor{NewNullFilter()},
+		[]frames.Frame{frames.NewTextFrame("one"), newImageFrame("PNG"), frames.NewSyncFrame()},
+		[]frames.Frame{frames.NewSyncFrame()},
+		nil,
+	)
+}
@@ -2,9 +2,11 @@ package processors
 
 import (
 	"fmt"
-	"runtime"
 	"slices"
+	"sync/atomic"
+	"time"
 
+	"github.com/weedge/pipeline-go/pkg/clock"
 	"github.com/weedge/pipeline-go/pkg/frames"
 	"github.com/weedge/pipeline-go/pkg/logger"
 )
@@ -31,6 +33,19 @@ func (d FrameDirection) String() string {
 	}
 }
 
+// Label returns the lower case name of the direction, e.g. for metric labels
+// and span attributes: "upstream", "downstream" or "unknown".
+func (d FrameDirection) Label() string {
+	switch d {
+	case FrameDirectionUpstream:
+		return "upstream"
+	case FrameDirectionDownstream:
+		return "downstream"
+	default:
+		return "unknown"
+	}
+}
+
 // IFrameProcessor is the interface for all components that process frames.
 type IFrameProcessor interface {
 	Name() string
@@ -56,6 +71,11 @@ type FrameProcessor struct {
 	metrics               *MetricsProcessor
 	skipFrames            []frames.Frame
 	verbose               bool
+	panicPolicy           PanicPolicy
+	panics                atomic.Uint64
+	observers             []FrameObserver
+	stats                 processorStats
+	clock                 clock.Clock
 }
 
 // NewFrameProcessor creates a new FrameProcessor.
@@ -67,6 +87,24 @@ func NewFrameProcessor(name string) *FrameProcessor {
 	}
 }
 
+// baseProcessor gives access to the FrameProcessor embedded in a processor.
+type baseProcessor interface {
+	frameProcessor() *FrameProcessor
+}
+
+func (p *FrameProcessor) frameProcessor() *FrameProcessor {
+	return p
+}
+
+// BaseProcessor returns the FrameProcessor embedded in processor, nil if it has none.
+// It's the Source of the FramePushEvents of the frames processor pushes.
+func BaseProcessor(processor IFrameProcessor) *FrameProcessor {
+	if b, ok := processor.(baseProcessor); ok {
+		return b.frameProcessor()
+	}
+	return nil
+}
+
 // ID returns the processor's ID.
 func (p *FrameProcessor) ID() int64 {
 	return p.id
@@ -82,6 +120,19 @@ func (p *FrameProcessor) InterruptionsAllowed() bool {
 	return p.allowInterruptions
 }
 
+// ShouldInterrupt reports whether frame is a StartInterruptionFrame this processor
+// has to act on, i.e. the StartFrame allowed interruptions. Stateful processors
+// drop their buffered (pre-interruption) state when it returns true and forward
+// the interruption frame in any case.
+func (p *FrameProcessor) ShouldInterrupt(frame frames.Frame) bool {
+	switch frame.(type) {
+	case *frames.StartInterruptionFrame, frames.StartInterruptionFrame:
+		return p.allowInterruptions
+	default:
+		return false
+	}
+}
+
 // MetricsEnabled returns whether metrics are enabled.
 func (p *FrameProcessor) MetricsEnabled() bool {
 	return p.enableMetrics
@@ -111,6 +162,23 @@ func (p *FrameProcessor) AddSkipFrame(frame frames.Frame) {
 	p.skipFrames = append(p.skipFrames, frame)
 }
 
+// Clock returns the clock of the processor, clock.Real unless set.
+func (p *FrameProcessor) Clock() clock.Clock {
+	if p.clock == nil {
+		return clock.Real
+	}
+	return p.clock
+}
+
+// SetClock sets the clock of the processor and of its metrics, e.g. a clock.Fake
+// in tests. It must be set before frames flow through the processor.
+func (p *FrameProcessor) SetClock(c clock.Clock) {
+	p.clock = c
+	if p.metrics != nil {
+		p.metrics.SetClock(c)
+	}
+}
+
 // StartTTFBMetrics starts TTFB metrics collection.
 func (p *FrameProcessor) StartTTFBMetrics() {
 	if p.MetricsEnabled() {
@@ -145,6 +213,35 @@ func (p *FrameProcessor) StopProcessingMetrics() {
 	}
 }
 
+// SetModelName sets the model reported with the processor metrics.
+func (p *FrameProcessor) SetModelName(model string) {
+	if p.metrics != nil {
+		p.metrics.SetModel(model)
+	}
+}
+
+// ModelName returns the model reported with the processor metrics.
+func (p *FrameProcessor) ModelName() string {
+	if p.metrics == nil {
+		return ""
+	}
+	return p.metrics.Model()
+}
+
+// StartLLMUsageMetrics pushes a MetricsFrame with the token usage of a LLM completion if usage metrics are enabled.
+func (p *FrameProcessor) StartLLMUsageMetrics(usage frames.LLMTokenUsage) {
+	if p.UsageMetricsEnabled() {
+		p.PushFrame(p.metrics.LLMUsageMetrics(usage), FrameDirectionDownstream)
+	}
+}
+
+// StartTTSUsageMetrics pushes a MetricsFrame with the number of characters of text to synthesize if usage metrics are enabled.
+func (p *FrameProcessor) StartTTSUsageMetrics(text string) {
+	if p.UsageMetricsEnabled() {
+		p.PushFrame(p.metrics.TTSUsageMetrics(text), FrameDirectionDownstream)
+	}
+}
+
 // StopAllMetrics stops all metrics collection.
 func (p *FrameProcessor) StopAllMetrics() {
 	p.StopTTFBMetrics()
@@ -162,7 +259,7 @@ func (p *FrameProcessor) GetParentPipeline() IFrameProcessor {
 }
 
 // ProcessFrame implements the IFrameProcessor interface.
-// Handle StartFrame to init and StartInterruptionFrame to stop all metrics
+// Handle StartFrame to init and an allowed StartInterruptionFrame to stop all metrics
 func (p *FrameProcessor) ProcessFrame(frame frames.Frame, direction FrameDirection) {
 	// Check if frame should be skipped
 	if slices.Contains(p.skipFrames, frame) {
@@ -175,15 +272,14 @@ func (p *FrameProcessor) ProcessFrame(frame frames.Frame, direction FrameDirecti
 		p.enableMetrics = startFrame.EnableMetrics
 		p.enableUsageMetrics = startFrame.EnableUsageMetrics
 		p.reportOnlyInitialTTFB = startFrame.ReportOnlyInitialTTFB
-	} else if _, ok := frame.(*frames.StartInterruptionFrame); ok {
-		// Handle StartInterruptionFrame
+	} else if p.ShouldInterrupt(frame) {
 		p.StopAllMetrics()
 	}
-
 }
 
 // PushError pushes an error frame upstream.
 func (p *FrameProcessor) PushError(errorFrame *frames.ErrorFrame) {
+	p.stats.errors.Add(1)
 	p.PushFrame(errorFrame, FrameDirectionUpstream)
 }
 
@@ -198,25 +294,35 @@ func (p *FrameProcessor) PushUpstreamFrame(frame frames.Frame) {
 }
 
 // PushFrame pushes a frame in the specified direction.
+// A panic in the next processor's ProcessFrame is recovered and handled by its PanicPolicy.
 func (p *FrameProcessor) PushFrame(frame frames.Frame, direction FrameDirection) {
+	p.pushFrame(frame, direction, time.Time{})
+}
+
+// pushFrame pushes a frame queued at queuedAt, zero if it wasn't queued.
+func (p *FrameProcessor) pushFrame(frame frames.Frame, direction FrameDirection, queuedAt time.Time) {
+	var dest IFrameProcessor
+	observeLatency, processed := func() {}, func() {}
 	defer func() {
+		observeLatency()
+		processed()
 		if r := recover(); r != nil {
-			// 获取调用栈信息
-			buf := make([]byte, 10240)
-			n := runtime.Stack(buf, false)
-			stackTrace := string(buf[:n])
-			println(stackTrace) // print in stderr for panic stack
-
-			logger.Info(fmt.Sprintf("Uncaught panic in %s(%T): %v\nStack trace:\n%s", p.name, p, r, stackTrace))
+			p.handlePanic(r, dest, frame)
 		}
 	}()
 
 	if direction == FrameDirectionDownstream && p.next != nil {
+		dest = p.next
+		observeLatency = p.countPush(dest, direction)
+		processed = p.notifyPush(dest, frame, direction, queuedAt)
 		if p.verbose {
 			logger.Info(fmt.Sprintf("Downstream %d Pushing %s  %s(%T) -> %s (Calling ProcessFrame on next: %T)", direction, frame.String(), p.name, p, p.next.Name(), p.next))
 		}
 		p.next.ProcessFrame(frame, direction)
 	} else if direction == FrameDirectionUpstream && p.prev != nil {
+		dest = p.prev
+		observeLatency = p.countPush(dest, direction)
+		processed = p.notifyPush(dest, frame, direction, queuedAt)
 		if p.verbose {
 			logger.Info(fmt.Sprintf("Upstream %d Pushing %s  %s(%T) -> %s (Calling ProcessFrame on prev: %T)", direction, frame.String(), p.name, p, p.prev.Name(), p.prev))
 		}
@@ -231,6 +337,21 @@ func (p *FrameProcessor) PushFrame(frame frames.Frame, direction FrameDirection)
 	}
 }
 
+// countPush counts frame pushed to dest and returns the function observing its processing latency.
+func (p *FrameProcessor) countPush(dest IFrameProcessor, direction FrameDirection) func() {
+	clk := p.Clock()
+	now := clk.Now()
+	p.stats.countOut(direction, now)
+	destBase := BaseProcessor(dest)
+	if destBase == nil {
+		return func() {}
+	}
+	destBase.stats.countIn(direction, now)
+	return func() {
+		destBase.stats.observeLatency(clk.Since(now))
+	}
+}
+
 // Link implements the IFrameProcessor interface.
 func (p *FrameProcessor) Link(next IFrameProcessor) {
 	p.next = next
@@ -0,0 +1,49 @@
+package processors
+
+import (
+	"slices"
+
+	"github.com/weedge/pipeline-go/pkg/frames"
+)
+
+// FrameTypeFilter selects frames by type, e.g. the frames logged by a
+// FrameLoggerProcessor: the frames of an ignored type are rejected, the frames
+// of an included type accepted. Types are compared by name (frames.TypeName),
+// so a filter can be set from the frame types or from their names, e.g. the
+// query of an inspector stream.
+type FrameTypeFilter struct {
+	Include []string
+	Ignore  []string
+}
+
+// NewFrameTypeFilter creates a FrameTypeFilter of the types of the include and ignore frames.
+func NewFrameTypeFilter(include, ignore []frames.Frame) FrameTypeFilter {
+	return FrameTypeFilter{Include: typeNames(include), Ignore: typeNames(ignore)}
+}
+
+func typeNames(fs []frames.Frame) []string {
+	names := make([]string, 0, len(fs))
+	for _, frame := range fs {
+		names = append(names, frames.TypeName(frame))
+	}
+	return names
+}
+
+// Ignored returns whether the type of frame is ignored.
+func (f FrameTypeFilter) Ignored(frame frames.Frame) bool {
+	return len(f.Ignore) > 0 && slices.Contains(f.Ignore, frames.TypeName(frame))
+}
+
+// IncludedIndex returns the index of the type of frame in the included types, -1 if it isn't included.
+func (f FrameTypeFilter) IncludedIndex(frame frames.Frame) int {
+	if len(f.Include) == 0 {
+		return -1
+	}
+	return slices.Index(f.Include, frames.TypeName(frame))
+}
+
+// Match returns whether frame isn't ignored and is included, all frames are
+// included if no type is.
+func (f FrameTypeFilter) Match(frame frames.Frame) bool {
+	return !f.Ignored(frame) && (len(f.Include) == 0 || f.IncludedIndex(frame) >= 0)
+}
@@ -0,0 +1,26 @@
+package processors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weedge/pipeline-go/pkg/frames"
+)
+
+func TestFrameTypeFilter(t *testing.T) {
+	text, audio := frames.NewTextFrame("hi"), frames.NewAudioRawFrame(nil, 16000, 1, 2)
+
+	all := FrameTypeFilter{}
+	assert.True(t, all.Match(text))
+	assert.Equal(t, -1, all.IncludedIndex(text))
+
+	filter := NewFrameTypeFilter([]frames.Frame{&frames.AudioRawFrame{}, &frames.TextFrame{}}, nil)
+	assert.Equal(t, []string{"AudioRawFrame", "TextFrame"}, filter.Include)
+	assert.Equal(t, 1, filter.IncludedIndex(text))
+	assert.False(t, filter.Match(frames.NewEndFrame()))
+
+	// By name, e.g. from a query; the ignored types win.
+	filter = FrameTypeFilter{Include: []string{"TextFrame"}, Ignore: []string{"TextFrame", "AudioRawFrame"}}
+	assert.False(t, filter.Match(text))
+	assert.True(t, filter.Ignored(audio))
+}
@@ -5,6 +5,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/weedge/pipeline-go/pkg/clock"
 	"github.com/weedge/pipeline-go/pkg/frames"
 )
 
@@ -13,7 +14,7 @@ type IdleProcessor struct {
 	FrameProcessor
 	timeout       time.Duration
 	resetTypes    map[reflect.Type]bool
-	timer         *time.Timer
+	timer         clock.Timer
 	lock          sync.Mutex
 	once          sync.Once
 	downstreamDir FrameDirection
@@ -33,7 +34,7 @@ func NewIdleProcessor(timeout time.Duration, resetTypes ...reflect.Type) *IdlePr
 }
 
 func (p *IdleProcessor) startTimer() {
-	p.timer = time.AfterFunc(p.timeout, func() {
+	p.timer = p.Clock().AfterFunc(p.timeout, func() {
 		p.PushFrame(&frames.IdleFrame{}, p.downstreamDir)
 		// After firing, the timer is stopped. We restart it to detect the next idle period.
 		p.lock.Lock()
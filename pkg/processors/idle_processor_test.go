@@ -0,0 +1,44 @@
+package processors
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weedge/pipeline-go/pkg/clock"
+	"github.com/weedge/pipeline-go/pkg/frames"
+)
+
+func idleFrames(fs []frames.Frame) int {
+	n := 0
+	for _, f := range fs {
+		if _, ok := f.(*frames.IdleFrame); ok {
+			n++
+		}
+	}
+	return n
+}
+
+func TestIdleProcessor(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	idle := NewIdleProcessor(100*time.Millisecond, reflect.TypeOf(&frames.TextFrame{}))
+	idle.SetClock(fake)
+	down := NewMockProcessorWithName("down")
+	idle.Link(down)
+
+	idle.ProcessFrame(frames.NewTextFrame("hi"), FrameDirectionDownstream)
+	fake.Advance(99 * time.Millisecond)
+	assert.Equal(t, 0, idleFrames(down.GetReceivedDirectionDownstreamFrames()))
+	fake.Advance(time.Millisecond)
+	assert.Equal(t, 1, idleFrames(down.GetReceivedDirectionDownstreamFrames()))
+
+	// A TextFrame resets the timer, other frames don't.
+	fake.Advance(50 * time.Millisecond)
+	idle.ProcessFrame(frames.NewTextFrame("again"), FrameDirectionDownstream)
+	fake.Advance(99 * time.Millisecond)
+	assert.Equal(t, 1, idleFrames(down.GetReceivedDirectionDownstreamFrames()))
+	idle.ProcessFrame(frames.NewSyncFrame(), FrameDirectionDownstream)
+	fake.Advance(time.Millisecond)
+	assert.Equal(t, 2, idleFrames(down.GetReceivedDirectionDownstreamFrames()))
+}
@@ -0,0 +1,26 @@
+package processors
+
+import "fmt"
+
+// ProcessorContainer is a processor made of processors, e.g. a pipeline.Pipeline.
+type ProcessorContainer interface {
+	Processors() []IFrameProcessor
+}
+
+// ProcessorWrapper is a processor wrapping another one, e.g. a WatchdogProcessor.
+type ProcessorWrapper interface {
+	Wrapped() IFrameProcessor
+}
+
+// QueueProcessor is a processor queueing the frames it pushes, e.g. an AsyncFrameProcessor.
+type QueueProcessor interface {
+	QueueLen(direction FrameDirection) int
+}
+
+// ProcessorName returns the name of processor, its type if it's unnamed.
+func ProcessorName(processor IFrameProcessor) string {
+	if name := processor.Name(); name != "" {
+		return name
+	}
+	return fmt.Sprintf("%T", processor)
+}
@@ -0,0 +1,21 @@
+package processors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weedge/pipeline-go/pkg/frames"
+)
+
+func TestProcessorName(t *testing.T) {
+	assert.Equal(t, "named", ProcessorName(NewFrameProcessor("named")))
+	assert.Equal(t, "*processors.FrameProcessor", ProcessorName(&FrameProcessor{}))
+}
+
+func TestLabels(t *testing.T) {
+	assert.Equal(t, "upstream", FrameDirectionUpstream.Label())
+	assert.Equal(t, "downstream", FrameDirectionDownstream.Label())
+	assert.Equal(t, "unknown", FrameDirection(7).Label())
+	assert.Equal(t, "TextFrame", frames.TypeName(frames.NewTextFrame("hi")))
+	assert.Equal(t, "nil", frames.TypeName(nil))
+}
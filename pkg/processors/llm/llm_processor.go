@@ -0,0 +1,377 @@
+// Package llm calls LLM services from a pipeline.
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/logger"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+const (
+	// DefaultBaseURL is the default base URL of the OpenAI-compatible API.
+	DefaultBaseURL = "https://api.openai.com/v1"
+	// DefaultToolTimeout is the default time a tool call has to return.
+	DefaultToolTimeout = 30 * time.Second
+	// DefaultMaxToolRounds is the default number of times the tools are called for a response.
+	DefaultMaxToolRounds = 5
+)
+
+// LLMProcessor completes conversations with an OpenAI-compatible
+// /chat/completions endpoint, streaming the response.
+//
+// A LLMContextFrame is completed with the messages of its context, a downstream
+// TextFrame is a prompt completed as a user message after the system prompt.
+// The response is pushed as a LLMFullResponseStartFrame, a TextFrame per delta
+// of content and a LLMFullResponseEndFrame. The TTFB is measured until the first
+// delta and the token usage is reported in a MetricsFrame, with the metrics
+// enabled. A failed request pushes a non-fatal ErrorFrame upstream.
+//
+// With tools (WithTools), the streamed tool calls are run once the stream ends,
+// in parallel with WithParallelToolCalls, each pushing a
+// FunctionCallInProgressFrame and a FunctionCallResultFrame downstream. The
+// calls and their results are added to the context, and the model is called
+// again with it, at most maxToolRounds times (the tools aren't offered after).
+// A failed call gives the LLM an error result: {"error": "..."}.
+//
+// The completion runs in the background, its frames and the downstream frames
+// passed through are queued and pushed in order by the AsyncFrameProcessor: a
+// new completion stops the one running, an EndFrame is pushed once it is done,
+// and an allowed StartInterruptionFrame or a CancelFrame aborts the HTTP stream
+// and the tool calls, without a LLMFullResponseEndFrame, and drops the frames
+// queued. A panic of the completion is handled by the panic policy.
+type LLMProcessor struct {
+	*processors.AsyncFrameProcessor
+	model        string
+	baseURL      string
+	apiKey       string
+	client       *http.Client
+	systemPrompt string
+	temperature  *float64
+	maxTokens    int
+
+	tools             *ToolRegistry
+	parallelToolCalls bool
+	toolTimeout       time.Duration
+	maxToolRounds     int
+
+	lock   sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewLLMProcessor creates a new LLMProcessor completing with model at DefaultBaseURL.
+func NewLLMProcessor(model string) *LLMProcessor {
+	p := &LLMProcessor{
+		AsyncFrameProcessor: processors.NewAsyncFrameProcessor("LLMProcessor"),
+		model:               model,
+		baseURL:             DefaultBaseURL,
+		client:              http.DefaultClient,
+		toolTimeout:         DefaultToolTimeout,
+		maxToolRounds:       DefaultMaxToolRounds,
+	}
+	p.SetModelName(model)
+	return p
+}
+
+// WithBaseURL sets the base URL of the API, e.g. "http://localhost:11434/v1".
+func (p *LLMProcessor) WithBaseURL(baseURL string) *LLMProcessor {
+	p.baseURL = baseURL
+	return p
+}
+
+// WithAPIKey sets the API key sent as a bearer token.
+func (p *LLMProcessor) WithAPIKey(apiKey string) *LLMProcessor {
+	p.apiKey = apiKey
+	return p
+}
+
+// WithHTTPClient sets the HTTP client of the requests.
+func (p *LLMProcessor) WithHTTPClient(client *http.Client) *LLMProcessor {
+	p.client = client
+	return p
+}
+
+// WithSystemPrompt sets the system message the TextFrame prompts are completed after.
+func (p *LLMProcessor) WithSystemPrompt(systemPrompt string) *LLMProcessor {
+	p.systemPrompt = systemPrompt
+	return p
+}
+
+// WithTemperature sets the sampling temperature, the API default otherwise.
+func (p *LLMProcessor) WithTemperature(temperature float64) *LLMProcessor {
+	p.temperature = &temperature
+	return p
+}
+
+// WithMaxTokens sets the maximum number of tokens of a response, 0 for the API default.
+func (p *LLMProcessor) WithMaxTokens(maxTokens int) *LLMProcessor {
+	p.maxTokens = maxTokens
+	return p
+}
+
+// WithTools sets the tools offered to the LLM.
+func (p *LLMProcessor) WithTools(tools *ToolRegistry) *LLMProcessor {
+	p.tools = tools
+	return p
+}
+
+// WithParallelToolCalls sets whether the tool calls of a response run in parallel, else one after the other.
+func (p *LLMProcessor) WithParallelToolCalls(parallelToolCalls bool) *LLMProcessor {
+	p.parallelToolCalls = parallelToolCalls
+	return p
+}
+
+// WithToolTimeout sets the time a tool call has to return, 0 for no timeout.
+func (p *LLMProcessor) WithToolTimeout(toolTimeout time.Duration) *LLMProcessor {
+	p.toolTimeout = toolTimeout
+	return p
+}
+
+// WithMaxToolRounds sets the number of times the tools are called for a response.
+func (p *LLMProcessor) WithMaxToolRounds(maxToolRounds int) *LLMProcessor {
+	p.maxToolRounds = maxToolRounds
+	return p
+}
+
+// ProcessFrame completes the LLMContextFrames and the downstream TextFrames, and
+// passes the other frames through.
+func (p *LLMProcessor) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	if direction != processors.FrameDirectionDownstream {
+		if p.ShouldInterrupt(frame) {
+			p.stop()
+		}
+		p.FrameProcessor.ProcessFrame(frame, direction)
+		p.PushFrame(frame, direction)
+		return
+	}
+
+	// The completion is stopped before the metrics are, it measures them, and
+	// before the frames queued are dropped.
+	switch frame.(type) {
+	case *frames.EndFrame:
+		// The EndFrame is queued after the response, the queue is drained then.
+		p.wait()
+		p.QueueFrame(frame, direction)
+	case *frames.CancelFrame:
+		p.stop()
+	default:
+		if p.ShouldInterrupt(frame) {
+			p.stop()
+		}
+	}
+	p.AsyncFrameProcessor.ProcessFrame(frame, direction)
+
+	switch f := frame.(type) {
+	case *frames.LLMContextFrame:
+		p.start(f, f.Context)
+	case *frames.TextFrame:
+		llmContext := frames.NewLLMContext()
+		if p.systemPrompt != "" {
+			llmContext.AddMessage(frames.LLMRoleSystem, p.systemPrompt)
+		}
+		llmContext.AddMessage(frames.LLMRoleUser, f.Text)
+		p.start(f, llmContext)
+	case *frames.CancelFrame:
+		// The queued frames are dropped, the CancelFrame is pushed right away.
+		p.PushFrame(f, direction)
+	case *frames.EndFrame, *frames.StartInterruptionFrame, frames.StartInterruptionFrame:
+		// Queued above, or pushed by the AsyncFrameProcessor.
+	default:
+		p.QueueFrame(frame, direction)
+	}
+}
+
+// start stops the completion running and starts completing llmContext of frame in the background.
+func (p *LLMProcessor) start(frame frames.Frame, llmContext *frames.LLMContext) {
+	p.stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	p.lock.Lock()
+	p.cancel, p.done = cancel, done
+	p.lock.Unlock()
+	go func() {
+		defer close(done)
+		defer p.RecoverPanic(frame)
+		p.complete(ctx, llmContext)
+	}()
+}
+
+// stop aborts the completion running, if any, and waits for it.
+func (p *LLMProcessor) stop() {
+	p.lock.Lock()
+	cancel, done := p.cancel, p.done
+	p.cancel, p.done = nil, nil
+	p.lock.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// wait waits for the completion running, if any.
+func (p *LLMProcessor) wait() {
+	p.lock.Lock()
+	done := p.done
+	p.lock.Unlock()
+	if done != nil {
+		<-done
+	}
+}
+
+// complete streams the completion of llmContext, calling the tools, until done
+// or ctx is canceled.
+func (p *LLMProcessor) complete(ctx context.Context, llmContext *frames.LLMContext) {
+	p.StartTTFBMetrics()
+	p.StartProcessingMetrics()
+	p.QueueFrame(frames.NewLLMFullResponseStartFrame(), processors.FrameDirectionDownstream)
+	for round := 0; ; round++ {
+		toolCalls, err := p.stream(ctx, llmContext.Messages(), round < p.maxToolRounds)
+		if ctx.Err() != nil {
+			// Aborted, the metrics are stopped by the interruption.
+			return
+		}
+		if err != nil {
+			p.PushError(frames.NewErrorFrame(err, false))
+			break
+		}
+		if len(toolCalls) == 0 || p.tools == nil || round >= p.maxToolRounds {
+			break
+		}
+		p.callTools(ctx, llmContext, toolCalls)
+		if ctx.Err() != nil {
+			return
+		}
+	}
+	p.StopAllMetrics()
+	p.QueueFrame(frames.NewLLMFullResponseEndFrame(), processors.FrameDirectionDownstream)
+}
+
+// stream streams a completion of messages, pushing its content, and returns its tool calls.
+func (p *LLMProcessor) stream(ctx context.Context, messages []frames.LLMMessage, withTools bool) ([]frames.LLMToolCall, error) {
+	request := &chatCompletionRequest{
+		Model:         p.model,
+		Messages:      messages,
+		Stream:        true,
+		StreamOptions: &streamOptions{IncludeUsage: true},
+		Temperature:   p.temperature,
+		MaxTokens:     p.maxTokens,
+	}
+	if withTools && p.tools != nil {
+		request.Tools = p.tools.toolDefinitions()
+	}
+
+	var toolCalls []frames.LLMToolCall
+	var usage *frames.LLMTokenUsage
+	err := streamChatCompletion(ctx, p.client, p.baseURL, p.apiKey, request, func(chunk *chatCompletionChunk) error {
+		if chunk.Usage != nil {
+			usage = &frames.LLMTokenUsage{
+				PromptTokens:     chunk.Usage.PromptTokens,
+				CompletionTokens: chunk.Usage.CompletionTokens,
+				TotalTokens:      chunk.Usage.TotalTokens,
+			}
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" || len(choice.Delta.ToolCalls) > 0 {
+				p.StopTTFBMetrics()
+			}
+			if choice.Delta.Content != "" {
+				p.QueueFrame(frames.NewTextFrame(choice.Delta.Content), processors.FrameDirectionDownstream)
+			}
+			for _, delta := range choice.Delta.ToolCalls {
+				var err error
+				if toolCalls, err = mergeToolCall(toolCalls, delta); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if usage != nil && ctx.Err() == nil {
+		p.StartLLMUsageMetrics(*usage)
+	}
+	return toolCalls, err
+}
+
+// mergeToolCall merges delta into the tool call of its index, which is either
+// one of toolCalls or the next one.
+func mergeToolCall(toolCalls []frames.LLMToolCall, delta toolCallDelta) ([]frames.LLMToolCall, error) {
+	if delta.Index < 0 || delta.Index > len(toolCalls) {
+		return toolCalls, fmt.Errorf("invalid tool call index %d of %d tool calls", delta.Index, len(toolCalls))
+	}
+	for len(toolCalls) <= delta.Index {
+		toolCalls = append(toolCalls, frames.LLMToolCall{
+			ID:   fmt.Sprintf("call_%d", len(toolCalls)),
+			Type: "function",
+		})
+	}
+	call := &toolCalls[delta.Index]
+	if delta.ID != "" {
+		call.ID = delta.ID
+	}
+	if delta.Type != "" {
+		call.Type = delta.Type
+	}
+	call.Function.Name += delta.Function.Name
+	call.Function.Arguments += delta.Function.Arguments
+	return toolCalls, nil
+}
+
+// callTools runs toolCalls and adds them and their results to llmContext.
+func (p *LLMProcessor) callTools(ctx context.Context, llmContext *frames.LLMContext, toolCalls []frames.LLMToolCall) {
+	results := make([]string, len(toolCalls))
+	call := func(i int) {
+		toolCall := toolCalls[i]
+		callCtx, cancel := ctx, context.CancelFunc(func() {})
+		if p.toolTimeout > 0 {
+			callCtx, cancel = context.WithTimeout(ctx, p.toolTimeout)
+		}
+		defer cancel()
+		result, err := p.tools.Call(callCtx, toolCall.Function.Name, toolCall.Function.Arguments)
+		if err != nil {
+			logger.Warnf("%s call of %s failed: %v", p.Name(), toolCall.Function.Name, err)
+			message, _ := json.Marshal(map[string]string{"error": err.Error()})
+			result = string(message)
+		}
+		results[i] = result
+	}
+
+	for _, toolCall := range toolCalls {
+		p.QueueFrame(frames.NewFunctionCallInProgressFrame(toolCall.ID, toolCall.Function.Name, toolCall.Function.Arguments),
+			processors.FrameDirectionDownstream)
+	}
+	if p.parallelToolCalls {
+		var wg sync.WaitGroup
+		for i := range toolCalls {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				call(i)
+			}(i)
+		}
+		wg.Wait()
+	} else {
+		for i := range toolCalls {
+			call(i)
+		}
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	messages := []frames.LLMMessage{{Role: frames.LLMRoleAssistant, ToolCalls: toolCalls}}
+	for i, toolCall := range toolCalls {
+		messages = append(messages, frames.LLMMessage{Role: frames.LLMRoleTool, Content: results[i], ToolCallID: toolCall.ID})
+	}
+	llmContext.AddMessages(messages...)
+	for i, toolCall := range toolCalls {
+		p.QueueFrame(frames.NewFunctionCallResultFrame(toolCall.ID, toolCall.Function.Name, toolCall.Function.Arguments, results[i]),
+			processors.FrameDirectionDownstream)
+	}
+}
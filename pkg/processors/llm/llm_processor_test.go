@@ -0,0 +1,423 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weedge/pipeline-go/pkg/clock"
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/logger"
+	"github.com/weedge/pipeline-go/pkg/pipeline"
+	"github.com/weedge/pipeline-go/pkg/pipeline/pipelinetest"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+// newSSEServer returns a server replaying chunks as the events of a streamed
+// response, and the requests it received.
+func newSSEServer(t *testing.T, chunks ...string) (*httptest.Server, *[]chatCompletionRequest) {
+	return newScriptedServer(t, chunks)
+}
+
+// newScriptedServer returns a server replaying the chunks of responses[i] as the
+// events of the streamed response to the request i, and the requests it received.
+func newScriptedServer(t *testing.T, responses ...[]string) (*httptest.Server, *[]chatCompletionRequest) {
+	var requests []chatCompletionRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		var request chatCompletionRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		requests = append(requests, request)
+		if !assert.LessOrEqual(t, len(requests), len(responses), "unexpected request") {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range responses[len(requests)-1] {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func newTestProcessor(server *httptest.Server) *LLMProcessor {
+	return NewLLMProcessor("test-model").WithBaseURL(server.URL + "/v1").WithAPIKey("test-key")
+}
+
+func TestLLMProcessor_Stream(t *testing.T) {
+	server, requests := newSSEServer(t,
+		`{"choices":[{"delta":{"role":"assistant"}}]}`,
+		`{"choices":[{"delta":{"content":"Hello"}}]}`,
+		`{"choices":[{"delta":{"content":" world"},"finish_reason":"stop"}]}`,
+		`{"choices":[],"usage":{"prompt_tokens":9,"completion_tokens":2,"total_tokens":11}}`,
+	)
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	data := frames.MetricsData{Processor: "LLMProcessor", Model: "test-model", Timestamp: fake.Now()}
+	ttfb := frames.NewMetricsFrameWithTTFB(frames.TTFBMetricsData{MetricsData: data})
+	tokens := frames.NewMetricsFrameWithTokens(frames.LLMUsageMetricsData{MetricsData: data,
+		Value: frames.LLMTokenUsage{PromptTokens: 9, CompletionTokens: 2, TotalTokens: 11}})
+	processing := frames.NewMetricsFrameWithProcessing(frames.ProcessingMetricsData{MetricsData: data})
+
+	pipelinetest.RunTest(t,
+		[]processors.IFrameProcessor{newTestProcessor(server).WithSystemPrompt("Be brief.")},
+		[]frames.Frame{frames.NewTextFrame("Hi")},
+		[]frames.Frame{
+			frames.NewLLMFullResponseStartFrame(),
+			ttfb,
+			frames.NewTextFrame("Hello"),
+			frames.NewTextFrame(" world"),
+			tokens,
+			processing,
+			frames.NewLLMFullResponseEndFrame(),
+		},
+		nil,
+		pipelinetest.WithParams(pipeline.PipelineParams{EnableMetrics: true, EnableUsageMetrics: true}),
+		pipelinetest.WithClock(fake),
+	)
+
+	if assert.Len(t, *requests, 1) {
+		request := (*requests)[0]
+		assert.Equal(t, "test-model", request.Model)
+		assert.True(t, request.Stream)
+		assert.Equal(t, []frames.LLMMessage{
+			{Role: frames.LLMRoleSystem, Content: "Be brief."},
+			{Role: frames.LLMRoleUser, Content: "Hi"},
+		}, request.Messages)
+	}
+}
+
+func TestLLMProcessor_Context(t *testing.T) {
+	server, requests := newSSEServer(t, `{"choices":[{"delta":{"content":"Fine."}}]}`)
+	llmContext := frames.NewLLMContext(
+		frames.LLMMessage{Role: frames.LLMRoleUser, Content: "Hi"},
+		frames.LLMMessage{Role: frames.LLMRoleAssistant, Content: "Hello!"},
+		frames.LLMMessage{Role: frames.LLMRoleUser, Content: "How are you?"},
+	)
+
+	pipelinetest.RunTest(t,
+		[]processors.IFrameProcessor{newTestProcessor(server)},
+		[]frames.Frame{frames.NewLLMContextFrame(llmContext)},
+		[]frames.Frame{
+			frames.NewLLMFullResponseStartFrame(),
+			frames.NewTextFrame("Fine."),
+			frames.NewLLMFullResponseEndFrame(),
+		},
+		nil,
+	)
+
+	if assert.Len(t, *requests, 1) {
+		assert.Equal(t, llmContext.Messages(), (*requests)[0].Messages)
+	}
+}
+
+func TestLLMProcessor_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model overloaded", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	pipelinetest.RunTest(t,
+		[]processors.IFrameProcessor{newTestProcessor(server)},
+		[]frames.Frame{frames.NewTextFrame("Hi")},
+		[]frames.Frame{frames.NewLLMFullResponseStartFrame(), frames.NewLLMFullResponseEndFrame()},
+		[]frames.Frame{frames.NewErrorFrame(errors.New("chat completion request: status 503: model overloaded"), false)},
+	)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestLLMProcessor_Panic(t *testing.T) {
+	client := &http.Client{Transport: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		panic("transport bug")
+	})}
+	prompt := frames.NewTextFrame("Hi")
+
+	// The panic of the completion is an ErrorFrame, the pipeline goes on.
+	result := pipelinetest.RunTest(t,
+		[]processors.IFrameProcessor{NewLLMProcessor("test-model").WithHTTPClient(client)},
+		[]frames.Frame{prompt, frames.NewTextFrame("Bye")},
+		[]frames.Frame{frames.NewLLMFullResponseStartFrame(), frames.NewLLMFullResponseStartFrame()},
+		[]frames.Frame{frames.NewErrorFrame(nil, false), frames.NewErrorFrame(nil, false)},
+		pipelinetest.IgnoreFields("Error"),
+	)
+	if assert.Len(t, result.Up, 2) {
+		var panicErr *processors.PanicError
+		assert.True(t, errors.As(result.Up[0].Frame.(*frames.ErrorFrame).Error, &panicErr))
+		assert.Equal(t, "transport bug", panicErr.Value)
+		assert.Equal(t, prompt, panicErr.Frame)
+	}
+}
+
+// frameRecorder records the frames pushed to it from any goroutine.
+type frameRecorder struct {
+	*processors.FrameProcessor
+	lock     sync.Mutex
+	received []frames.Frame
+}
+
+func (r *frameRecorder) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.received = append(r.received, frame)
+}
+
+func (r *frameRecorder) names() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	var names []string
+	for _, f := range r.received {
+		names = append(names, fmt.Sprintf("%T", f))
+	}
+	return names
+}
+
+func TestLLMProcessor_Interruption(t *testing.T) {
+	aborted := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Once\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+		close(aborted)
+	}))
+	defer server.Close()
+
+	llm := newTestProcessor(server)
+	recorder := &frameRecorder{FrameProcessor: processors.NewFrameProcessor("recorder")}
+	llm.Link(recorder)
+	start := frames.NewStartFrame()
+	start.AllowInterruptions = true
+	llm.ProcessFrame(start, processors.FrameDirectionDownstream)
+	llm.ProcessFrame(frames.NewTextFrame("Tell me a story"), processors.FrameDirectionDownstream)
+	assert.Eventually(t, func() bool { return len(recorder.names()) == 3 }, time.Second, time.Millisecond)
+
+	llm.ProcessFrame(frames.NewStartInterruptionFrame(), processors.FrameDirectionDownstream)
+	select {
+	case <-aborted:
+	case <-time.After(time.Second):
+		t.Fatal("HTTP stream not aborted by the interruption")
+	}
+	assert.Equal(t, []string{
+		"*frames.StartFrame",
+		"*frames.LLMFullResponseStartFrame",
+		"*frames.TextFrame",
+		"*frames.StartInterruptionFrame",
+	}, recorder.names())
+}
+
+func newWeatherTools(t *testing.T) *ToolRegistry {
+	tools := NewToolRegistry()
+	assert.NoError(t, tools.Register(Tool{
+		Name:        "get_weather",
+		Description: "Get the weather of a city.",
+		Parameters: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"city": map[string]any{"type": "string"}},
+			"required":   []any{"city"},
+		},
+		Handler: func(ctx context.Context, arguments json.RawMessage) (any, error) {
+			var args struct{ City string }
+			if err := json.Unmarshal(arguments, &args); err != nil {
+				return nil, err
+			}
+			return map[string]any{"city": args.City, "celsius": 21}, nil
+		},
+	}))
+	return tools
+}
+
+func TestLLMProcessor_ToolCalls(t *testing.T) {
+	server, requests := newScriptedServer(t,
+		[]string{
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_a","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":1,"id":"call_b","type":"function","function":{"name":"get_time","arguments":"{}"}}]}}]}`,
+			`{"choices":[{"delta":{},"finish_reason":"tool_calls"}]}`,
+		},
+		[]string{`{"choices":[{"delta":{"content":"It is 21 degrees in Paris."}}]}`},
+	)
+	llmContext := frames.NewLLMContext(frames.LLMMessage{Role: frames.LLMRoleUser, Content: "Weather in Paris?"})
+	weather := `{"celsius":21,"city":"Paris"}`
+	unknown := `{"error":"unknown tool: get_time"}`
+
+	pipelinetest.RunTest(t,
+		[]processors.IFrameProcessor{newTestProcessor(server).WithTools(newWeatherTools(t))},
+		[]frames.Frame{frames.NewLLMContextFrame(llmContext)},
+		[]frames.Frame{
+			frames.NewLLMFullResponseStartFrame(),
+			frames.NewFunctionCallInProgressFrame("call_a", "get_weather", `{"city":"Paris"}`),
+			frames.NewFunctionCallInProgressFrame("call_b", "get_time", "{}"),
+			frames.NewFunctionCallResultFrame("call_a", "get_weather", `{"city":"Paris"}`, weather),
+			frames.NewFunctionCallResultFrame("call_b", "get_time", "{}", unknown),
+			frames.NewTextFrame("It is 21 degrees in Paris."),
+			frames.NewLLMFullResponseEndFrame(),
+		},
+		nil,
+	)
+
+	expected := []frames.LLMMessage{
+		{Role: frames.LLMRoleUser, Content: "Weather in Paris?"},
+		{Role: frames.LLMRoleAssistant, ToolCalls: []frames.LLMToolCall{
+			{ID: "call_a", Type: "function", Function: frames.LLMFunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+			{ID: "call_b", Type: "function", Function: frames.LLMFunctionCall{Name: "get_time", Arguments: "{}"}},
+		}},
+		{Role: frames.LLMRoleTool, Content: weather, ToolCallID: "call_a"},
+		{Role: frames.LLMRoleTool, Content: unknown, ToolCallID: "call_b"},
+	}
+	assert.Equal(t, expected, llmContext.Messages())
+	if assert.Len(t, *requests, 2) {
+		assert.Equal(t, []toolDefinition{{Type: "function", Function: functionDefinition{
+			Name:        "get_weather",
+			Description: "Get the weather of a city.",
+			Parameters: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"city": map[string]any{"type": "string"}},
+				"required":   []any{"city"},
+			},
+		}}}, (*requests)[0].Tools)
+		assert.Equal(t, expected, (*requests)[1].Messages)
+	}
+}
+
+func TestLLMProcessor_InvalidToolCallIndex(t *testing.T) {
+	for _, index := range []int{-1, 1, 1 << 30} {
+		server, requests := newSSEServer(t,
+			fmt.Sprintf(`{"choices":[{"delta":{"tool_calls":[{"index":%d,"id":"call_a","type":"function","function":{"name":"get_weather","arguments":"{}"}}]}}]}`, index),
+		)
+
+		pipelinetest.RunTest(t,
+			[]processors.IFrameProcessor{newTestProcessor(server).WithTools(newWeatherTools(t))},
+			[]frames.Frame{frames.NewTextFrame("Weather in Paris?")},
+			[]frames.Frame{frames.NewLLMFullResponseStartFrame(), frames.NewLLMFullResponseEndFrame()},
+			[]frames.Frame{frames.NewErrorFrame(fmt.Errorf("invalid tool call index %d of 0 tool calls", index), false)},
+		)
+		assert.Len(t, *requests, 1)
+	}
+}
+
+func TestLLMProcessor_ParallelToolCalls(t *testing.T) {
+	server, _ := newScriptedServer(t,
+		[]string{`{"choices":[{"delta":{"tool_calls":[` +
+			`{"index":0,"id":"call_a","type":"function","function":{"name":"wait","arguments":"{}"}},` +
+			`{"index":1,"id":"call_b","type":"function","function":{"name":"wait","arguments":"{}"}}]}}]}`},
+		[]string{`{"choices":[{"delta":{"content":"Done."}}]}`},
+	)
+	// Each call waits for the other one to start: they only return run in parallel.
+	var started sync.WaitGroup
+	started.Add(2)
+	tools := NewToolRegistry()
+	assert.NoError(t, tools.Register(Tool{Name: "wait", Handler: func(ctx context.Context, _ json.RawMessage) (any, error) {
+		started.Done()
+		started.Wait()
+		return "ok", nil
+	}}))
+
+	pipelinetest.RunTest(t,
+		[]processors.IFrameProcessor{newTestProcessor(server).WithTools(tools).WithParallelToolCalls(true).WithToolTimeout(time.Second)},
+		[]frames.Frame{frames.NewTextFrame("Wait twice")},
+		[]frames.Frame{
+			frames.NewLLMFullResponseStartFrame(),
+			frames.NewFunctionCallInProgressFrame("call_a", "wait", "{}"),
+			frames.NewFunctionCallInProgressFrame("call_b", "wait", "{}"),
+			frames.NewFunctionCallResultFrame("call_a", "wait", "{}", "ok"),
+			frames.NewFunctionCallResultFrame("call_b", "wait", "{}", "ok"),
+			frames.NewTextFrame("Done."),
+			frames.NewLLMFullResponseEndFrame(),
+		},
+		nil,
+	)
+}
+
+func TestLLMProcessor_ToolTimeout(t *testing.T) {
+	server, requests := newScriptedServer(t,
+		[]string{`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_a","type":"function","function":{"name":"hang","arguments":"{}"}}]}}]}`},
+		[]string{`{"choices":[{"delta":{"content":"Sorry."}}]}`},
+	)
+	release := make(chan struct{})
+	defer close(release)
+	tools := NewToolRegistry()
+	assert.NoError(t, tools.Register(Tool{Name: "hang", Handler: func(ctx context.Context, _ json.RawMessage) (any, error) {
+		// The call returns on timeout, even if the handler doesn't.
+		<-release
+		return "late", nil
+	}}))
+
+	pipelinetest.RunTest(t,
+		[]processors.IFrameProcessor{newTestProcessor(server).WithTools(tools).WithToolTimeout(10 * time.Millisecond)},
+		[]frames.Frame{frames.NewTextFrame("Hang")},
+		[]frames.Frame{
+			frames.NewLLMFullResponseStartFrame(),
+			frames.NewFunctionCallInProgressFrame("call_a", "hang", "{}"),
+			frames.NewFunctionCallResultFrame("call_a", "hang", "{}", `{"error":"tool hang: context deadline exceeded"}`),
+			frames.NewTextFrame("Sorry."),
+			frames.NewLLMFullResponseEndFrame(),
+		},
+		nil,
+	)
+	assert.Len(t, *requests, 2)
+}
+
+func TestLLMProcessor_MaxToolRounds(t *testing.T) {
+	toolCall := `{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_a","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Oslo\"}"}}]}}]}`
+	server, requests := newScriptedServer(t, []string{toolCall}, []string{`{"choices":[{"delta":{"content":"Cold."}}]}`})
+
+	pipelinetest.RunTest(t,
+		[]processors.IFrameProcessor{newTestProcessor(server).WithTools(newWeatherTools(t)).WithMaxToolRounds(1)},
+		[]frames.Frame{frames.NewTextFrame("Weather in Oslo?")},
+		[]frames.Frame{
+			frames.NewLLMFullResponseStartFrame(),
+			frames.NewFunctionCallInProgressFrame("call_a", "get_weather", `{"city":"Oslo"}`),
+			frames.NewFunctionCallResultFrame("call_a", "get_weather", `{"city":"Oslo"}`, `{"celsius":21,"city":"Oslo"}`),
+			frames.NewTextFrame("Cold."),
+			frames.NewLLMFullResponseEndFrame(),
+		},
+		nil,
+	)
+	// The tools aren't offered anymore after the last round.
+	if assert.Len(t, *requests, 2) {
+		assert.NotEmpty(t, (*requests)[0].Tools)
+		assert.Empty(t, (*requests)[1].Tools)
+	}
+}
+
+func TestLLMProcessor_ToolResultsLogged(t *testing.T) {
+	server, _ := newScriptedServer(t,
+		[]string{`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_a","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Rome\"}"}}]}}]}`},
+		[]string{`{"choices":[{"delta":{"content":"Warm."}}]}`},
+	)
+	var logs bytes.Buffer
+	defaultLogger := logger.Logger
+	logger.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+	defer func() { logger.Logger = defaultLogger }()
+
+	frameLogger := processors.NewDefaultFrameLoggerProcessorWithIncludeFrame([]frames.Frame{&frames.FunctionCallResultFrame{}})
+	pipelinetest.RunTest(t,
+		[]processors.IFrameProcessor{newTestProcessor(server).WithTools(newWeatherTools(t)), frameLogger},
+		[]frames.Frame{frames.NewTextFrame("Weather in Rome?")},
+		[]frames.Frame{
+			frames.NewLLMFullResponseStartFrame(),
+			frames.NewFunctionCallInProgressFrame("call_a", "get_weather", `{"city":"Rome"}`),
+			frames.NewFunctionCallResultFrame("call_a", "get_weather", `{"city":"Rome"}`, `{"celsius":21,"city":"Rome"}`),
+			frames.NewTextFrame("Warm."),
+			frames.NewLLMFullResponseEndFrame(),
+		},
+		nil,
+	)
+	assert.Contains(t, logs.String(), `function: get_weather, arguments: {\"city\":\"Rome\"}, result: {\"celsius\":21,\"city\":\"Rome\"}`)
+}
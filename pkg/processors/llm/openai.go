@@ -0,0 +1,126 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/weedge/pipeline-go/pkg/frames"
+)
+
+// chatCompletionRequest is the body of a /chat/completions request.
+type chatCompletionRequest struct {
+	Model         string              `json:"model"`
+	Messages      []frames.LLMMessage `json:"messages"`
+	Stream        bool                `json:"stream"`
+	StreamOptions *streamOptions      `json:"stream_options,omitempty"`
+	Temperature   *float64            `json:"temperature,omitempty"`
+	MaxTokens     int                 `json:"max_tokens,omitempty"`
+	Tools         []toolDefinition    `json:"tools,omitempty"`
+}
+
+type toolDefinition struct {
+	Type     string             `json:"type"`
+	Function functionDefinition `json:"function"`
+}
+
+type functionDefinition struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// chatCompletionChunk is an event of a streamed /chat/completions response.
+type chatCompletionChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content   string          `json:"content"`
+			ToolCalls []toolCallDelta `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// toolCallDelta is a part of a streamed tool call: the first part of a call has
+// its ID and function name, the arguments are streamed in parts.
+type toolCallDelta struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// maxErrorBody is how much of an error response body is kept in the error.
+const maxErrorBody = 1024
+
+// streamChatCompletion posts request to the /chat/completions endpoint of
+// baseURL and calls onChunk with the events of the streamed response, until
+// the stream ends, onChunk fails or ctx is done.
+func streamChatCompletion(ctx context.Context, client *http.Client, baseURL, apiKey string,
+	request *chatCompletionRequest, onChunk func(*chatCompletionChunk) error) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("marshal chat completion request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(baseURL, "/")+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create chat completion request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("chat completion request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("chat completion request: status %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			// Blank lines, comments and the other fields of the events.
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			return nil
+		}
+		chunk := &chatCompletionChunk{}
+		if err := json.Unmarshal([]byte(data), chunk); err != nil {
+			return fmt.Errorf("invalid chat completion chunk %q: %w", data, err)
+		}
+		if err := onChunk(chunk); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read chat completion stream: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ToolHandler runs a function tool with its arguments encoded in JSON. The
+// result is given to the LLM as is if it is a string, else encoded in JSON.
+// ctx is done when the call times out or the completion is interrupted.
+type ToolHandler func(ctx context.Context, arguments json.RawMessage) (any, error)
+
+// Tool is a function tool the LLM can call.
+type Tool struct {
+	Name        string
+	Description string
+	// Parameters is the JSON schema of the arguments, e.g.
+	// {"type": "object", "properties": {"city": {"type": "string"}}, "required": ["city"]}.
+	Parameters map[string]any
+	Handler    ToolHandler
+}
+
+// ErrUnknownTool is the error of a call of a tool not registered.
+var ErrUnknownTool = errors.New("unknown tool")
+
+// ToolRegistry holds the tools offered to the LLM, by name. It is safe for concurrent use.
+type ToolRegistry struct {
+	lock  sync.RWMutex
+	tools map[string]Tool
+	names []string
+}
+
+// NewToolRegistry creates a new empty ToolRegistry.
+func NewToolRegistry() *ToolRegistry {
+	return &ToolRegistry{tools: make(map[string]Tool)}
+}
+
+// Register adds tool, its name must be unique.
+func (r *ToolRegistry) Register(tool Tool) error {
+	if tool.Name == "" {
+		return errors.New("register tool: empty name")
+	}
+	if tool.Handler == nil {
+		return fmt.Errorf("register tool %s: nil handler", tool.Name)
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, ok := r.tools[tool.Name]; ok {
+		return fmt.Errorf("register tool %s: already registered", tool.Name)
+	}
+	r.tools[tool.Name] = tool
+	r.names = append(r.names, tool.Name)
+	return nil
+}
+
+// Tool returns the tool registered with name.
+func (r *ToolRegistry) Tool(name string) (Tool, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	tool, ok := r.tools[name]
+	return tool, ok
+}
+
+// Tools returns the tools in the order they were registered.
+func (r *ToolRegistry) Tools() []Tool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	tools := make([]Tool, 0, len(r.names))
+	for _, name := range r.names {
+		tools = append(tools, r.tools[name])
+	}
+	return tools
+}
+
+// Call runs the tool name with arguments and returns its result for the LLM.
+// It returns once ctx is done, even if the handler doesn't; a handler panic is
+// returned as an error.
+func (r *ToolRegistry) Call(ctx context.Context, name, arguments string) (string, error) {
+	tool, ok := r.Tool(name)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownTool, name)
+	}
+	if arguments == "" {
+		arguments = "{}"
+	}
+	if !json.Valid([]byte(arguments)) {
+		return "", fmt.Errorf("invalid arguments of tool %s: %s", name, arguments)
+	}
+
+	type outcome struct {
+		result any
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- outcome{err: fmt.Errorf("panicked: %v", r)}
+			}
+		}()
+		result, err := tool.Handler(ctx, json.RawMessage(arguments))
+		done <- outcome{result, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return "", fmt.Errorf("tool %s: %w", name, ctx.Err())
+	case o := <-done:
+		if o.err != nil {
+			return "", fmt.Errorf("tool %s: %w", name, o.err)
+		}
+		if s, ok := o.result.(string); ok {
+			return s, nil
+		}
+		result, err := json.Marshal(o.result)
+		if err != nil {
+			return "", fmt.Errorf("marshal result of tool %s: %w", name, err)
+		}
+		return string(result), nil
+	}
+}
+
+// toolDefinitions returns the definitions of the tools of r for a request.
+func (r *ToolRegistry) toolDefinitions() []toolDefinition {
+	var definitions []toolDefinition
+	for _, tool := range r.Tools() {
+		definitions = append(definitions, toolDefinition{
+			Type: "function",
+			Function: functionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+	}
+	return definitions
+}
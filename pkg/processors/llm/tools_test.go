@@ -0,0 +1,57 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToolRegistry_Register(t *testing.T) {
+	handler := func(ctx context.Context, _ json.RawMessage) (any, error) { return "ok", nil }
+	tools := NewToolRegistry()
+
+	assert.NoError(t, tools.Register(Tool{Name: "b", Handler: handler}))
+	assert.NoError(t, tools.Register(Tool{Name: "a", Handler: handler}))
+	assert.EqualError(t, tools.Register(Tool{Name: "a", Handler: handler}), "register tool a: already registered")
+	assert.EqualError(t, tools.Register(Tool{Handler: handler}), "register tool: empty name")
+	assert.EqualError(t, tools.Register(Tool{Name: "c"}), "register tool c: nil handler")
+
+	var names []string
+	for _, tool := range tools.Tools() {
+		names = append(names, tool.Name)
+	}
+	assert.Equal(t, []string{"b", "a"}, names)
+}
+
+func TestToolRegistry_Call(t *testing.T) {
+	tools := NewToolRegistry()
+	assert.NoError(t, tools.Register(Tool{Name: "echo", Handler: func(ctx context.Context, arguments json.RawMessage) (any, error) {
+		return arguments, nil
+	}}))
+	assert.NoError(t, tools.Register(Tool{Name: "fail", Handler: func(ctx context.Context, _ json.RawMessage) (any, error) {
+		return nil, errors.New("no luck")
+	}}))
+	assert.NoError(t, tools.Register(Tool{Name: "panic", Handler: func(ctx context.Context, _ json.RawMessage) (any, error) {
+		panic("boom")
+	}}))
+
+	result, err := tools.Call(context.Background(), "echo", `{"a":1}`)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, result)
+	// No arguments are an empty object.
+	result, err = tools.Call(context.Background(), "echo", "")
+	assert.NoError(t, err)
+	assert.Equal(t, `{}`, result)
+
+	_, err = tools.Call(context.Background(), "echo", `{"a":`)
+	assert.EqualError(t, err, `invalid arguments of tool echo: {"a":`)
+	_, err = tools.Call(context.Background(), "missing", "{}")
+	assert.ErrorIs(t, err, ErrUnknownTool)
+	_, err = tools.Call(context.Background(), "fail", "{}")
+	assert.EqualError(t, err, "tool fail: no luck")
+	_, err = tools.Call(context.Background(), "panic", "{}")
+	assert.EqualError(t, err, "tool panic: panicked: boom")
+}
@@ -2,8 +2,6 @@ package processors
 
 import (
 	"fmt"
-	"reflect"
-	"slices"
 
 	"github.com/weedge/pipeline-go/pkg/frames"
 	"github.com/weedge/pipeline-go/pkg/logger"
@@ -29,10 +27,9 @@ func (p *LoggerProcessor) ProcessFrame(frame frames.Frame, direction FrameDirect
 // FrameLoggerProcessor 是一个更高级的日志处理器，可以过滤特定类型的帧
 type FrameLoggerProcessor struct {
 	FrameProcessor
-	prefix            string
-	ignoredFrameTypes []reflect.Type
-	includeFrameTypes []reflect.Type
-	maxIdToLogs       []uint64
+	prefix      string
+	filter      FrameTypeFilter
+	maxIdToLogs []uint64
 }
 
 // NewFrameLoggerProcessor 创建一个新的 FrameLoggerProcessor 实例
@@ -43,22 +40,11 @@ func NewFrameLoggerProcessor(
 	includeFrameTypes []frames.Frame,
 	maxIdToLogs []uint64,
 ) *FrameLoggerProcessor {
-	ignoredTypes := make([]reflect.Type, len(ignoredFrameTypes))
-	for i, frame := range ignoredFrameTypes {
-		ignoredTypes[i] = reflect.TypeOf(frame)
-	}
-
-	includeTypes := make([]reflect.Type, len(includeFrameTypes))
-	for i, frame := range includeFrameTypes {
-		includeTypes[i] = reflect.TypeOf(frame)
-	}
-
 	return &FrameLoggerProcessor{
-		FrameProcessor:    *NewFrameProcessor(name),
-		prefix:            prefix,
-		ignoredFrameTypes: ignoredTypes,
-		includeFrameTypes: includeTypes,
-		maxIdToLogs:       maxIdToLogs,
+		FrameProcessor: *NewFrameProcessor(name),
+		prefix:         prefix,
+		filter:         NewFrameTypeFilter(includeFrameTypes, ignoredFrameTypes),
+		maxIdToLogs:    maxIdToLogs,
 	}
 }
 
@@ -93,20 +79,12 @@ func (p *FrameLoggerProcessor) WithMaxIdToLogs(maxIdToLogs []uint64) *FrameLogge
 }
 
 func (p *FrameLoggerProcessor) WithIncludeFrame(includeFrameTypes []frames.Frame) *FrameLoggerProcessor {
-	includeTypes := make([]reflect.Type, len(includeFrameTypes))
-	for i, frame := range includeFrameTypes {
-		includeTypes[i] = reflect.TypeOf(frame)
-	}
-	p.includeFrameTypes = includeTypes
+	p.filter.Include = NewFrameTypeFilter(includeFrameTypes, nil).Include
 	return p
 }
 
 func (p *FrameLoggerProcessor) WithIgnoreFrame(ignoreFrameTypes []frames.Frame) *FrameLoggerProcessor {
-	ignoreTypes := make([]reflect.Type, len(ignoreFrameTypes))
-	for i, frame := range ignoreFrameTypes {
-		ignoreTypes[i] = reflect.TypeOf(frame)
-	}
-	p.ignoredFrameTypes = ignoreTypes
+	p.filter.Ignore = NewFrameTypeFilter(nil, ignoreFrameTypes).Ignore
 	return p
 }
 
@@ -119,35 +97,10 @@ func (p *FrameLoggerProcessor) Prefix() string {
 	return p.prefix
 }
 
-// isIgnoredFrame 检查帧是否应该被忽略
-func (p *FrameLoggerProcessor) isIgnoredFrame(frame frames.Frame) bool {
-	if len(p.ignoredFrameTypes) == 0 {
-		return false
-	}
-
-	frameType := reflect.TypeOf(frame)
-	return slices.Contains(p.ignoredFrameTypes, frameType)
-}
-
-// isIncludedFrame 检查帧是否在包含列表中
-func (p *FrameLoggerProcessor) isIncludedFrame(frame frames.Frame) bool {
-	return p.indexIncludedFrame(frame) >= 0
-}
-
-// isIncludedFrame 检查帧是否在包含列表中
-func (p *FrameLoggerProcessor) indexIncludedFrame(frame frames.Frame) int {
-	if len(p.includeFrameTypes) == 0 {
-		return -1
-	}
-
-	frameType := reflect.TypeOf(frame)
-	return slices.Index(p.includeFrameTypes, frameType)
-}
-
 func (p *FrameLoggerProcessor) ProcessFrame(frame frames.Frame, direction FrameDirection) {
 	// 检查是否应该处理此帧
-	if !p.isIgnoredFrame(frame) {
-		index := p.indexIncludedFrame(frame)
+	if !p.filter.Ignored(frame) {
+		index := p.filter.IncludedIndex(frame)
 		if index >= 0 {
 			fromTo := p.name
 
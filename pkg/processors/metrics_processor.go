@@ -2,13 +2,18 @@ package processors
 
 import (
 	"time"
+	"unicode/utf8"
 
+	"github.com/weedge/pipeline-go/pkg/clock"
 	"github.com/weedge/pipeline-go/pkg/frames"
 )
 
-// MetricsProcessor handles processor performance metrics (TTFB and processing time).
+// MetricsProcessor handles processor performance metrics (TTFB and processing time)
+// and usage metrics (LLM tokens, TTS characters).
 type MetricsProcessor struct {
 	name                  string
+	model                 string
+	clock                 clock.Clock
 	startTTFBTime         time.Time
 	startProcessingTime   time.Time
 	shouldReportTTFB      bool
@@ -19,14 +24,34 @@ type MetricsProcessor struct {
 func NewMetricsProcessor(name string) *MetricsProcessor {
 	return &MetricsProcessor{
 		name:             name,
+		clock:            clock.Real,
 		shouldReportTTFB: true,
 	}
 }
 
+// SetClock sets the clock the metrics are measured with.
+func (m *MetricsProcessor) SetClock(c clock.Clock) {
+	m.clock = c
+}
+
+// SetModel sets the model reported with the metrics.
+func (m *MetricsProcessor) SetModel(model string) {
+	m.model = model
+}
+
+// Model returns the model reported with the metrics.
+func (m *MetricsProcessor) Model() string {
+	return m.model
+}
+
+func (m *MetricsProcessor) metricsData() frames.MetricsData {
+	return frames.MetricsData{Processor: m.name, Model: m.model, Timestamp: m.clock.Now()}
+}
+
 // StartTTFBMetrics starts TTFB metrics collection.
 func (m *MetricsProcessor) StartTTFBMetrics(reportOnlyInitialTTFB bool) {
 	if m.shouldReportTTFB {
-		m.startTTFBTime = time.Now()
+		m.startTTFBTime = m.clock.Now()
 		m.reportOnlyInitialTTFB = reportOnlyInitialTTFB
 		m.shouldReportTTFB = !reportOnlyInitialTTFB
 	}
@@ -38,15 +63,14 @@ func (m *MetricsProcessor) StopTTFBMetrics() *frames.MetricsFrame {
 		return nil
 	}
 
-	value := time.Since(m.startTTFBTime).Seconds()
-	ttfb := map[string]any{"processor": m.name, "value": value}
+	ttfb := frames.TTFBMetricsData{MetricsData: m.metricsData(), Value: m.clock.Since(m.startTTFBTime)}
 	m.startTTFBTime = time.Time{} // Reset to zero time
-	return frames.NewMetricsFrameWithTTFB([]map[string]any{ttfb})
+	return frames.NewMetricsFrameWithTTFB(ttfb)
 }
 
 // StartProcessingMetrics starts processing time metrics collection.
 func (m *MetricsProcessor) StartProcessingMetrics() {
-	m.startProcessingTime = time.Now()
+	m.startProcessingTime = m.clock.Now()
 }
 
 // StopProcessingMetrics stops processing time metrics collection and returns a MetricsFrame.
@@ -55,8 +79,17 @@ func (m *MetricsProcessor) StopProcessingMetrics() *frames.MetricsFrame {
 		return nil
 	}
 
-	value := time.Since(m.startProcessingTime).Seconds()
-	processing := map[string]any{"processor": m.name, "value": value}
+	processing := frames.ProcessingMetricsData{MetricsData: m.metricsData(), Value: m.clock.Since(m.startProcessingTime)}
 	m.startProcessingTime = time.Time{} // Reset to zero time
-	return frames.NewMetricsFrameWithProcessing([]map[string]any{processing})
+	return frames.NewMetricsFrameWithProcessing(processing)
+}
+
+// LLMUsageMetrics returns a MetricsFrame with the token usage of a LLM completion.
+func (m *MetricsProcessor) LLMUsageMetrics(usage frames.LLMTokenUsage) *frames.MetricsFrame {
+	return frames.NewMetricsFrameWithTokens(frames.LLMUsageMetricsData{MetricsData: m.metricsData(), Value: usage})
+}
+
+// TTSUsageMetrics returns a MetricsFrame with the number of characters of text synthesized.
+func (m *MetricsProcessor) TTSUsageMetrics(text string) *frames.MetricsFrame {
+	return frames.NewMetricsFrameWithCharacters(frames.TTSUsageMetricsData{MetricsData: m.metricsData(), Value: utf8.RuneCountInString(text)})
 }
@@ -1,26 +1,32 @@
 package processors
 
 import (
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/weedge/pipeline-go/pkg/clock"
 	"github.com/weedge/pipeline-go/pkg/frames"
 )
 
 func TestMetricsProcessor(t *testing.T) {
 	// Create a new metrics processor
 	metrics := NewMetricsProcessor("test-processor")
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	metrics.SetClock(fake)
 
 	// Test TTFB metrics
 	metrics.StartTTFBMetrics(false)
-	time.Sleep(10 * time.Millisecond)
+	fake.Advance(10 * time.Millisecond)
 	frame := metrics.StopTTFBMetrics()
 
 	assert.NotNil(t, frame)
 	assert.NotEmpty(t, frame.TTFB)
-	assert.Equal(t, "test-processor", frame.TTFB[0]["processor"])
-	assert.Greater(t, frame.TTFB[0]["value"], float64(0))
+	assert.Equal(t, "test-processor", frame.TTFB[0].Processor)
+	assert.Equal(t, 10*time.Millisecond, frame.TTFB[0].Value)
+	assert.Equal(t, fake.Now(), frame.TTFB[0].Timestamp)
+	assert.True(t, strings.HasPrefix(frame.Name(), "MetricsFrame#"))
 
 	// Test that stopping without starting returns nil
 	frame = metrics.StopTTFBMetrics()
@@ -28,13 +34,13 @@ func TestMetricsProcessor(t *testing.T) {
 
 	// Test processing metrics
 	metrics.StartProcessingMetrics()
-	time.Sleep(10 * time.Millisecond)
+	fake.Advance(25 * time.Millisecond)
 	frame = metrics.StopProcessingMetrics()
 
 	assert.NotNil(t, frame)
 	assert.NotEmpty(t, frame.Processing)
-	assert.Equal(t, "test-processor", frame.Processing[0]["processor"])
-	assert.Greater(t, frame.Processing[0]["value"], float64(0))
+	assert.Equal(t, "test-processor", frame.Processing[0].Processor)
+	assert.Equal(t, 25*time.Millisecond, frame.Processing[0].Value)
 
 	// Test that stopping without starting returns nil
 	frame = metrics.StopProcessingMetrics()
@@ -88,3 +94,38 @@ func TestFrameProcessorStartFrame(t *testing.T) {
 	assert.True(t, processor.enableUsageMetrics)
 	assert.True(t, processor.reportOnlyInitialTTFB)
 }
+
+func TestFrameProcessorUsageMetrics(t *testing.T) {
+	processor := NewFrameProcessor("llm")
+	processor.SetModelName("gpt-4o")
+	next := NewMockProcessor()
+	processor.Link(next)
+
+	// Usage metrics are only pushed once enabled by the StartFrame.
+	processor.StartLLMUsageMetrics(frames.LLMTokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15})
+	assert.Empty(t, next.GetReceivedDirectionDownstreamFrames())
+
+	startFrame := frames.NewStartFrame()
+	startFrame.EnableUsageMetrics = true
+	processor.ProcessFrame(startFrame, FrameDirectionDownstream)
+	processor.StartLLMUsageMetrics(frames.LLMTokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15})
+	processor.StartTTSUsageMetrics("你好 world")
+
+	received := next.GetReceivedDirectionDownstreamFrames()
+	assert.Equal(t, 2, len(received))
+	tokens := received[0].(*frames.MetricsFrame).Tokens
+	assert.Equal(t, 1, len(tokens))
+	assert.Equal(t, "llm", tokens[0].Processor)
+	assert.Equal(t, "gpt-4o", tokens[0].Model)
+	assert.Equal(t, 15, tokens[0].Value.TotalTokens)
+	characters := received[1].(*frames.MetricsFrame).Characters
+	assert.Equal(t, 1, len(characters))
+	assert.Equal(t, 8, characters[0].Value)
+}
+
+func TestFrameProcessorModelNameWithoutMetrics(t *testing.T) {
+	// A zero FrameProcessor, e.g. embedded by value, has no metrics.
+	processor := &FrameProcessor{}
+	assert.NotPanics(t, func() { processor.SetModelName("gpt-4o") })
+	assert.Equal(t, "", processor.ModelName())
+}
@@ -0,0 +1,105 @@
+package normalizers
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// EmojiMode is how a TextNormalizer reads the emoji.
+type EmojiMode int
+
+const (
+	// EmojiDrop drops the emoji.
+	EmojiDrop EmojiMode = iota
+	// EmojiVerbalize replaces the emoji by their name, the emoji without a name are dropped.
+	EmojiVerbalize
+)
+
+func (m EmojiMode) String() string {
+	switch m {
+	case EmojiDrop:
+		return "Drop"
+	case EmojiVerbalize:
+		return "Verbalize"
+	default:
+		return "Unknown"
+	}
+}
+
+// EmojiNames are the names of the common emoji by language, used by EmojiVerbalize.
+var EmojiNames = map[Language]map[rune]string{
+	LanguageEnglish: {
+		'😀': "grinning face", '😂': "tears of joy", '😊': "smiling face", '🙂': "slightly smiling face",
+		'😉': "winking face", '😍': "heart eyes", '😅': "sweat smile", '🤔': "thinking face",
+		'😢': "crying face", '😭': "loudly crying face", '👍': "thumbs up", '👎': "thumbs down",
+		'👏': "clapping hands", '🙏': "folded hands", '👋': "waving hand", '🎉': "party popper",
+		'❤': "red heart", '🔥': "fire", '✅': "check mark", '❌': "cross mark", '⭐': "star",
+		'🚀': "rocket", '💡': "light bulb", '⚠': "warning", '💯': "hundred points",
+	},
+	LanguageChinese: {
+		'😀': "笑脸", '😂': "笑哭", '😊': "微笑", '🙂': "微笑", '😉': "眨眼", '😍': "花痴", '😅': "苦笑",
+		'🤔': "思考", '😢': "哭泣", '😭': "大哭", '👍': "点赞", '👎': "踩", '👏': "鼓掌", '🙏': "祈祷",
+		'👋': "挥手", '🎉': "庆祝", '❤': "爱心", '🔥': "火", '✅': "对勾", '❌': "叉号", '⭐': "星星",
+		'🚀': "火箭", '💡': "灯泡", '⚠': "警告", '💯': "一百分",
+	},
+}
+
+// isEmoji returns whether r is an emoji (pictograph, symbol or regional indicator).
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF,
+		r >= 0x2600 && r <= 0x27BF,
+		r == 0x231A, r == 0x231B, r >= 0x23E9 && r <= 0x23FA,
+		r >= 0x2B05 && r <= 0x2B07, r == 0x2B1B, r == 0x2B1C, r == 0x2B50, r == 0x2B55:
+		return true
+	}
+	return false
+}
+
+// isEmojiPart returns whether r only goes with an emoji: a joiner, a variation
+// selector, a keycap or a tag.
+func isEmojiPart(r rune) bool {
+	return r == 0x200D || r == 0xFE0F || r == 0x20E3 || r >= 0xE0020 && r <= 0xE007F
+}
+
+// replaceEmoji drops the emoji of text or replaces them by their name.
+// A sequence of emoji joined, with modifiers, is read as its first emoji.
+func replaceEmoji(text string, mode EmojiMode, language Language) string {
+	var b strings.Builder
+	joined := false
+	for i, r := range text {
+		switch {
+		case isEmojiPart(r):
+			joined = r == 0x200D
+		case isEmoji(r) && joined:
+			joined = false
+		case isEmoji(r) && r >= 0x1F3FB && r <= 0x1F3FF:
+			// A skin tone modifier.
+		case isEmoji(r):
+			if mode != EmojiVerbalize {
+				continue
+			}
+			name, ok := EmojiNames[language][r]
+			if !ok {
+				continue
+			}
+			if language == LanguageEnglish {
+				if last, _ := utf8.DecodeLastRuneInString(b.String()); b.Len() > 0 && !unicode.IsSpace(last) {
+					name = " " + name
+				}
+				rest := strings.TrimLeftFunc(text[i+utf8.RuneLen(r):], func(r rune) bool {
+					return isEmojiPart(r) || r >= 0x1F3FB && r <= 0x1F3FF
+				})
+				if next, _ := utf8.DecodeRuneInString(rest); unicode.IsLetter(next) || unicode.IsDigit(next) {
+					name += " "
+				}
+			}
+			b.WriteString(name)
+		default:
+			joined = false
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
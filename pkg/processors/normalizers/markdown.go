@@ -0,0 +1,198 @@
+package normalizers
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	fencePattern      = regexp.MustCompile("^[ \t]*(```|~~~)")
+	rulePattern       = regexp.MustCompile(`^[ \t]*(?:[-*_][ \t]*){3,}$`)
+	headingPattern    = regexp.MustCompile(`^[ \t]*#{1,6}[ \t]+`)
+	quotePattern      = regexp.MustCompile(`^[ \t]*(?:>[ \t]?)+`)
+	listPattern       = regexp.MustCompile(`^[ \t]*(?:[-*+]|\d+[.)])[ \t]+`)
+	imagePattern      = regexp.MustCompile(`!\[([^\]\n]*)\]\([^)\n]*\)`)
+	linkPattern       = regexp.MustCompile(`\[([^\]\n]*)\]\([^)\n]*\)`)
+	inlineCodePattern = regexp.MustCompile("`+([^`\n]*)`+")
+	emphasisPattern   = regexp.MustCompile(`\*+|~~|__+`)
+	underscorePattern = regexp.MustCompile(`(^|[\s(])_([^_\s][^_]*?)_([\s).,!?:;]|$)`)
+	urlPattern        = regexp.MustCompile(`(?:https?://|www\.)[^\s<>()\[\]]+`)
+)
+
+// markdownState is the state of the Markdown stripping across frames.
+type markdownState struct {
+	atLineStart bool
+	inFence     bool
+	// skipLine drops the rest of a code fence line, e.g. its language.
+	skipLine bool
+	// last is the last rune of the line stripped before, utf8.RuneError at a line start.
+	last rune
+}
+
+// stripMarkdown strips the Markdown syntax of text, starting where the previous text ended.
+func (n *TextNormalizer) stripMarkdown(text string) string {
+	var b strings.Builder
+	for _, segment := range strings.SplitAfter(text, "\n") {
+		if segment == "" {
+			continue
+		}
+		line, newline := strings.CutSuffix(segment, "\n")
+		atLineStart := n.md.atLineStart
+		n.md.atLineStart = newline || atLineStart && strings.TrimSpace(line) == ""
+
+		// The lines dropped are dropped with their newline.
+		switch {
+		case n.md.skipLine:
+			n.md.skipLine = !newline
+		case atLineStart && fencePattern.MatchString(line):
+			n.md.inFence = !n.md.inFence
+			n.md.skipLine = !newline
+		case n.md.inFence && n.rules[RuleCodeBlocks]:
+		case n.md.inFence:
+			b.WriteString(segment)
+		case atLineStart && newline && rulePattern.MatchString(line):
+		default:
+			if atLineStart {
+				line = headingPattern.ReplaceAllString(line, "")
+				line = quotePattern.ReplaceAllString(line, "")
+				line = listPattern.ReplaceAllString(line, "")
+			}
+			before := n.md.last
+			if atLineStart {
+				before = utf8.RuneError
+			}
+			b.WriteString(stripInlineMarkdown(line, before))
+			if newline {
+				b.WriteByte('\n')
+			}
+		}
+		n.md.last, _ = utf8.DecodeLastRuneInString(line)
+	}
+	return b.String()
+}
+
+// stripInlineMarkdown strips the images and links (keeping their text), the
+// inline code (keeping the code) and the emphasis markers of text, following
+// the rune before, utf8.RuneError if none.
+func stripInlineMarkdown(text string, before rune) string {
+	text = imagePattern.ReplaceAllString(text, "$1")
+	text = linkPattern.ReplaceAllString(text, "$1")
+	var codes []string
+	text = inlineCodePattern.ReplaceAllStringFunc(text, func(match string) string {
+		// The code is kept as is, emphasis markers included.
+		codes = append(codes, inlineCodePattern.FindStringSubmatch(match)[1])
+		return "\x00"
+	})
+	text = stripEmphasis(text, before)
+	text = underscorePattern.ReplaceAllString(text, "$1$2$3")
+	for _, code := range codes {
+		text = strings.Replace(text, "\x00", code, 1)
+	}
+	return text
+}
+
+// stripEmphasis strips the emphasis markers: the runs of '*', "~~" and "__"
+// flanking a word on one side only, i.e. opening ("**bold") or closing
+// ("bold**,") an emphasis, as in CommonMark. The others are kept, e.g. in
+// "2 * 3" or "2*3". start is the rune before text, utf8.RuneError if none.
+func stripEmphasis(text string, start rune) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range emphasisPattern.FindAllStringIndex(text, -1) {
+		before := start
+		if loc[0] > 0 {
+			before, _ = utf8.DecodeLastRuneInString(text[:loc[0]])
+		}
+		after, _ := utf8.DecodeRuneInString(text[loc[1]:])
+		// The ends of the text are spaces.
+		leftFlanking := !isSpace(after) && (!isPunct(after) || isSpace(before) || isPunct(before))
+		rightFlanking := !isSpace(before) && (!isPunct(before) || isSpace(after) || isPunct(after))
+		if leftFlanking == rightFlanking {
+			continue
+		}
+		b.WriteString(text[last:loc[0]])
+		last = loc[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+func isSpace(r rune) bool {
+	return r == utf8.RuneError || unicode.IsSpace(r)
+}
+
+func isPunct(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+// dropURLs drops the URLs, but the punctuation ending a sentence after them.
+func dropURLs(text string) string {
+	return urlPattern.ReplaceAllStringFunc(text, func(url string) string {
+		return url[len(strings.TrimRight(url, ".,;:!?")):]
+	})
+}
+
+// openIndex returns the index of the first link or inline code of the last line
+// of text not closed in text, or -1.
+func openIndex(text string) int {
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '`':
+			n := len(text[i:]) - len(strings.TrimLeft(text[i:], "`"))
+			if n >= 3 {
+				// A code fence.
+				i += n - 1
+				continue
+			}
+			end, open := closeIndex(text, i+n, strings.Repeat("`", n))
+			if open {
+				return i
+			}
+			if end >= 0 {
+				i = end + n - 1
+			}
+		case '[':
+			end, open := closeIndex(text, i+1, "]")
+			if open || end == len(text)-1 {
+				// The link target may follow.
+				if i > 0 && text[i-1] == '!' {
+					return i - 1
+				}
+				return i
+			}
+			if end < 0 || text[end+1] != '(' {
+				continue
+			}
+			target, open := closeIndex(text, end+2, ")")
+			if open {
+				if i > 0 && text[i-1] == '!' {
+					return i - 1
+				}
+				return i
+			}
+			if target >= 0 {
+				i = target
+			}
+		}
+	}
+	return -1
+}
+
+// closeIndex returns the index of closing in text from i on the same line, or -1;
+// open reports it may still come: the line isn't ended.
+func closeIndex(text string, i int, closing string) (index int, open bool) {
+	line := text[i:]
+	if nl := strings.IndexByte(line, '\n'); nl >= 0 {
+		line = line[:nl]
+		if j := strings.Index(line, closing); j >= 0 {
+			return i + j, false
+		}
+		return -1, false
+	}
+	if j := strings.Index(line, closing); j >= 0 {
+		return i + j, false
+	}
+	return -1, true
+}
@@ -0,0 +1,343 @@
+package normalizers
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	enOnes = []string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine",
+		"ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen"}
+	enTens   = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+	enScales = []string{"", "thousand", "million", "billion", "trillion", "quadrillion"}
+	// enOrdinals are the irregular ordinals, the others add "th" ("twentieth" from "twenty").
+	enOrdinals = map[string]string{"one": "first", "two": "second", "three": "third", "five": "fifth",
+		"eight": "eighth", "nine": "ninth", "twelve": "twelfth"}
+
+	zhDigits = []rune("零一二三四五六七八九")
+	zhUnits  = []string{"", "十", "百", "千"}
+	zhScales = []string{"", "万", "亿", "万亿"}
+)
+
+// enInt spells n in English words.
+func enInt(n uint64) string {
+	if n < 20 {
+		return enOnes[n]
+	}
+	var groups []string
+	for scale := 0; n > 0; scale++ {
+		if group := n % 1000; group > 0 {
+			words := enHundreds(int(group))
+			if enScales[scale] != "" {
+				words += " " + enScales[scale]
+			}
+			groups = append([]string{words}, groups...)
+		}
+		n /= 1000
+	}
+	return strings.Join(groups, " ")
+}
+
+// enHundreds spells 0 < n < 1000 in English words.
+func enHundreds(n int) string {
+	var words []string
+	if n >= 100 {
+		words = append(words, enOnes[n/100], "hundred")
+		n %= 100
+	}
+	switch {
+	case n == 0:
+	case n < 20:
+		words = append(words, enOnes[n])
+	case n%10 == 0:
+		words = append(words, enTens[n/10])
+	default:
+		words = append(words, enTens[n/10]+"-"+enOnes[n%10])
+	}
+	return strings.Join(words, " ")
+}
+
+// enOrdinal spells the ordinal of n in English words, e.g. "twenty-first".
+func enOrdinal(n uint64) string {
+	words := enInt(n)
+	i := strings.LastIndexAny(words, " -") + 1
+	last := words[i:]
+	if ordinal, ok := enOrdinals[last]; ok {
+		return words[:i] + ordinal
+	}
+	if strings.HasSuffix(last, "y") {
+		return words[:i] + strings.TrimSuffix(last, "y") + "ieth"
+	}
+	return words + "th"
+}
+
+// enYear spells year the way years are read, e.g. "nineteen ninety-nine", "two thousand five".
+func enYear(year int) string {
+	if year < 1000 || year >= 10000 || year%1000 < 10 {
+		return enInt(uint64(year))
+	}
+	high, low := year/100, year%100
+	switch {
+	case low == 0:
+		return enInt(uint64(high)) + " hundred"
+	case low < 10:
+		return enInt(uint64(high)) + " oh " + enOnes[low]
+	default:
+		return enInt(uint64(high)) + " " + enHundreds(low)
+	}
+}
+
+// zhInt spells n in Chinese numerals, e.g. "一万零二十".
+func zhInt(n uint64) string {
+	if n == 0 {
+		return string(zhDigits[0])
+	}
+	var b strings.Builder
+	var sections []uint64
+	for ; n > 0; n /= 10000 {
+		sections = append(sections, n%10000)
+	}
+	zero := false
+	for scale := len(sections) - 1; scale >= 0; scale-- {
+		section := sections[scale]
+		if section == 0 {
+			zero = b.Len() > 0
+			continue
+		}
+		if zero || b.Len() > 0 && section < 1000 {
+			b.WriteRune(zhDigits[0])
+		}
+		b.WriteString(zhSection(int(section)))
+		b.WriteString(zhScales[scale])
+		zero = false
+	}
+	s := b.String()
+	// "一十二" is read "十二".
+	if strings.HasPrefix(s, "一十") {
+		s = strings.TrimPrefix(s, "一")
+	}
+	return s
+}
+
+// zhSection spells 0 < n < 10000 in Chinese numerals.
+func zhSection(n int) string {
+	var b strings.Builder
+	zero := false
+	for unit := 3; unit >= 0; unit-- {
+		digit := n / pow10(unit) % 10
+		if digit == 0 {
+			zero = b.Len() > 0
+			continue
+		}
+		if zero {
+			b.WriteRune(zhDigits[0])
+			zero = false
+		}
+		b.WriteRune(zhDigits[digit])
+		b.WriteString(zhUnits[unit])
+	}
+	return b.String()
+}
+
+func pow10(n int) int {
+	p := 1
+	for ; n > 0; n-- {
+		p *= 10
+	}
+	return p
+}
+
+// zhDigitsOf spells the digits of s one by one in Chinese, e.g. a year "二零二四".
+func zhDigitsOf(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(zhDigits[r-'0'])
+		}
+	}
+	return b.String()
+}
+
+// spellNumber spells a number with optional thousands separators and decimals, e.g. "-1,234.5".
+// Numbers too big to be spelled are returned as is.
+func spellNumber(number string, language Language) string {
+	negative := strings.HasPrefix(number, "-")
+	integer, decimals, _ := strings.Cut(strings.ReplaceAll(strings.TrimPrefix(number, "-"), ",", ""), ".")
+	n, err := strconv.ParseUint(integer, 10, 64)
+	// The scales spelled go up to the quadrillions in English and the 万亿 in Chinese.
+	limit := uint64(1e18)
+	if language == LanguageChinese {
+		limit = 1e16
+	}
+	if err != nil || n >= limit {
+		return number
+	}
+
+	switch language {
+	case LanguageChinese:
+		s := zhInt(n)
+		if decimals != "" {
+			s += "点" + zhDigitsOf(decimals)
+		}
+		if negative {
+			s = "负" + s
+		}
+		return s
+	default:
+		words := []string{enInt(n)}
+		if decimals != "" {
+			words = append(words, "point")
+			for _, r := range decimals {
+				words = append(words, enOnes[r-'0'])
+			}
+		}
+		if negative {
+			words = append([]string{"minus"}, words...)
+		}
+		return strings.Join(words, " ")
+	}
+}
+
+// currency is how a currency amount is read.
+type currency struct {
+	enUnit, enUnits, enCent, enCents string
+	zhUnit, zhCent                   string
+}
+
+var currencies = map[string]currency{
+	"$": {"dollar", "dollars", "cent", "cents", "美元", "美分"},
+	"€": {"euro", "euros", "cent", "cents", "欧元", "欧分"},
+	"£": {"pound", "pounds", "penny", "pence", "英镑", "便士"},
+	"¥": {"yuan", "yuan", "fen", "fen", "元", "分"},
+	"￥": {"yuan", "yuan", "fen", "fen", "元", "分"},
+}
+
+const numberPattern = `\d{1,3}(?:,\d{3})+(?:\.\d+)?|\d+(?:\.\d+)?`
+
+var (
+	currencyPattern = regexp.MustCompile(`([$€£¥￥])(` + numberPattern + `)`)
+	isoDatePattern  = regexp.MustCompile(`\b(\d{4})-(\d{2})-(\d{2})\b`)
+	zhDatePattern   = regexp.MustCompile(`(\d{4})年(\d{1,2})月(\d{1,2})[日号]`)
+	percentPattern  = regexp.MustCompile(`(` + numberPattern + `)%`)
+	ordinalPattern  = regexp.MustCompile(`\b(\d+)(?:st|nd|rd|th)\b`)
+	numberRegexp    = regexp.MustCompile(`-?(?:` + numberPattern + `)`)
+)
+
+// expandCurrency spells the currency amounts, e.g. "$3.50" as "three dollars and fifty cents".
+func expandCurrency(text string, language Language) string {
+	return currencyPattern.ReplaceAllStringFunc(text, func(match string) string {
+		groups := currencyPattern.FindStringSubmatch(match)
+		c := currencies[groups[1]]
+		amount := strings.ReplaceAll(groups[2], ",", "")
+		units, cents, _ := strings.Cut(amount, ".")
+		if len(cents) > 2 {
+			// Not cents, e.g. an exchange rate.
+			if language == LanguageChinese {
+				return spellNumber(amount, language) + c.zhUnit
+			}
+			return spellNumber(amount, language) + " " + c.enUnits
+		}
+		if len(cents) == 1 {
+			cents += "0"
+		}
+		n, _ := strconv.ParseUint(units, 10, 64)
+		m, _ := strconv.ParseUint(cents, 10, 64)
+
+		if language == LanguageChinese {
+			s := spellNumber(units, language) + c.zhUnit
+			if m > 0 {
+				s += zhInt(m) + c.zhCent
+			}
+			return s
+		}
+		unit, cent := c.enUnits, c.enCents
+		if n == 1 {
+			unit = c.enUnit
+		}
+		if m == 1 {
+			cent = c.enCent
+		}
+		s := spellNumber(units, language) + " " + unit
+		if m > 0 {
+			s += " and " + enInt(m) + " " + cent
+		}
+		return s
+	})
+}
+
+// expandDates spells the ISO dates (and the Chinese dates in Chinese), e.g.
+// "2024-01-02" as "January second, twenty twenty-four".
+func expandDates(text string, language Language) string {
+	spell := func(year, month, day string) (string, bool) {
+		y, _ := strconv.Atoi(year)
+		m, _ := strconv.Atoi(month)
+		d, _ := strconv.Atoi(day)
+		if m < 1 || m > 12 || d < 1 || d > 31 {
+			return "", false
+		}
+		if language == LanguageChinese {
+			return zhDigitsOf(year) + "年" + zhInt(uint64(m)) + "月" + zhInt(uint64(d)) + "日", true
+		}
+		return time.Month(m).String() + " " + enOrdinal(uint64(d)) + ", " + enYear(y), true
+	}
+	replace := func(pattern *regexp.Regexp) func(string) string {
+		return func(match string) string {
+			groups := pattern.FindStringSubmatch(match)
+			if s, ok := spell(groups[1], groups[2], groups[3]); ok {
+				return s
+			}
+			return match
+		}
+	}
+	text = isoDatePattern.ReplaceAllStringFunc(text, replace(isoDatePattern))
+	if language == LanguageChinese {
+		text = zhDatePattern.ReplaceAllStringFunc(text, replace(zhDatePattern))
+	}
+	return text
+}
+
+// expandNumbers spells the percentages, the English ordinals ("1st") and the numbers.
+func expandNumbers(text string, language Language) string {
+	text = percentPattern.ReplaceAllStringFunc(text, func(match string) string {
+		number := strings.TrimSuffix(match, "%")
+		if language == LanguageChinese {
+			return "百分之" + spellNumber(number, language)
+		}
+		return spellNumber(number, language) + " percent"
+	})
+	if language == LanguageEnglish {
+		text = ordinalPattern.ReplaceAllStringFunc(text, func(match string) string {
+			n, err := strconv.ParseUint(strings.TrimRight(match, "stndrh"), 10, 64)
+			if err != nil || n >= 1e18 {
+				return match
+			}
+			return enOrdinal(n)
+		})
+	}
+	var b strings.Builder
+	last := 0
+	for _, loc := range numberRegexp.FindAllStringIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		number := text[start:end]
+		// A '-' after a word or number is a hyphen, e.g. "COVID-19" or "1-2".
+		if number[0] == '-' && start > 0 && !strings.ContainsRune(" \t\n(", rune(text[start-1])) {
+			start++
+			number = number[1:]
+		}
+		// Leave the numbers in words, e.g. "mp3" or "3D".
+		if start > 0 && isASCIILetter(text[start-1]) || end < len(text) && isASCIILetter(text[end]) {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(spellNumber(number, language))
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
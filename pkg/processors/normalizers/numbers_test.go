@@ -0,0 +1,85 @@
+package normalizers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpellNumber(t *testing.T) {
+	tests := []struct {
+		number   string
+		language Language
+		expected string
+	}{
+		{"0", LanguageEnglish, "zero"},
+		{"13", LanguageEnglish, "thirteen"},
+		{"42", LanguageEnglish, "forty-two"},
+		{"100", LanguageEnglish, "one hundred"},
+		{"1,234", LanguageEnglish, "one thousand two hundred thirty-four"},
+		{"1000001", LanguageEnglish, "one million one"},
+		{"-3.14", LanguageEnglish, "minus three point one four"},
+		{"0", LanguageChinese, "零"},
+		{"10", LanguageChinese, "十"},
+		{"15", LanguageChinese, "十五"},
+		{"105", LanguageChinese, "一百零五"},
+		{"1010", LanguageChinese, "一千零一十"},
+		{"10020", LanguageChinese, "一万零二十"},
+		{"100000", LanguageChinese, "十万"},
+		{"120000000", LanguageChinese, "一亿二千万"},
+		{"100001000", LanguageChinese, "一亿零一千"},
+		{"-3.14", LanguageChinese, "负三点一四"},
+		{"9999999999999999", LanguageChinese, "九千九百九十九万亿九千九百九十九亿九千九百九十九万九千九百九十九"},
+		{"10000000000000000", LanguageChinese, "10000000000000000"},
+		{"-10000000000000000", LanguageChinese, "-10000000000000000"},
+		{"999999999999999999", LanguageEnglish, "nine hundred ninety-nine quadrillion nine hundred ninety-nine trillion nine hundred ninety-nine billion nine hundred ninety-nine million nine hundred ninety-nine thousand nine hundred ninety-nine"},
+		{"1000000000000000000", LanguageEnglish, "1000000000000000000"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, spellNumber(tt.number, tt.language), "%s in %s", tt.number, tt.language)
+	}
+}
+
+func TestEnOrdinalAndYear(t *testing.T) {
+	assert.Equal(t, "first", enOrdinal(1))
+	assert.Equal(t, "twelfth", enOrdinal(12))
+	assert.Equal(t, "twentieth", enOrdinal(20))
+	assert.Equal(t, "twenty-third", enOrdinal(23))
+	assert.Equal(t, "one hundred first", enOrdinal(101))
+
+	assert.Equal(t, "nineteen ninety-nine", enYear(1999))
+	assert.Equal(t, "nineteen hundred", enYear(1900))
+	assert.Equal(t, "nineteen oh five", enYear(1905))
+	assert.Equal(t, "two thousand", enYear(2000))
+	assert.Equal(t, "two thousand five", enYear(2005))
+	assert.Equal(t, "twenty twenty-four", enYear(2024))
+}
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		name     string
+		expand   func(string, Language) string
+		text     string
+		language Language
+		expected string
+	}{
+		{"currency", expandCurrency, "It costs $3.50 or €1.", LanguageEnglish, "It costs three dollars and fifty cents or one euro."},
+		{"currency separators", expandCurrency, "$1,200 only", LanguageEnglish, "one thousand two hundred dollars only"},
+		{"currency zh", expandCurrency, "价格是¥12.05", LanguageChinese, "价格是十二元五分"},
+		{"iso date", expandDates, "Due 2024-03-01.", LanguageEnglish, "Due March first, twenty twenty-four."},
+		{"iso date zh", expandDates, "截止2024-03-01。", LanguageChinese, "截止二零二四年三月一日。"},
+		{"zh date", expandDates, "2024年12月25日见", LanguageChinese, "二零二四年十二月二十五日见"},
+		{"invalid date", expandDates, "ID 2024-13-40", LanguageEnglish, "ID 2024-13-40"},
+		{"numbers", expandNumbers, "I have 3 cats and -2 dogs.", LanguageEnglish, "I have three cats and minus two dogs."},
+		{"hyphen", expandNumbers, "COVID-19 in 2-3 days", LanguageEnglish, "COVID-nineteen in two-three days"},
+		{"words with digits", expandNumbers, "mp3 and 3D", LanguageEnglish, "mp3 and 3D"},
+		{"percent", expandNumbers, "up 12.5%", LanguageEnglish, "up twelve point five percent"},
+		{"ordinal", expandNumbers, "the 21st century", LanguageEnglish, "the twenty-first century"},
+		{"numbers zh", expandNumbers, "第3章有25页，增长10%", LanguageChinese, "第三章有二十五页，增长百分之十"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.expand(tt.text, tt.language))
+		})
+	}
+}
@@ -0,0 +1,245 @@
+// Package normalizers rewrites the text going to a TTS service into text to be read aloud.
+package normalizers
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+// Language is the language a TextNormalizer spells numbers, dates and currency amounts in.
+type Language int
+
+const (
+	LanguageEnglish Language = iota
+	LanguageChinese
+)
+
+func (l Language) String() string {
+	switch l {
+	case LanguageEnglish:
+		return "English"
+	case LanguageChinese:
+		return "Chinese"
+	default:
+		return "Unknown"
+	}
+}
+
+// Rule is a normalization rule of a TextNormalizer.
+type Rule int
+
+const (
+	// RuleMarkdown strips the Markdown syntax: emphasis, headings, lists, quotes,
+	// rules, code fences, links and images (keeping their text) and inline code
+	// (keeping the code).
+	RuleMarkdown Rule = iota
+	// RuleCodeBlocks drops the fenced code blocks, with RuleMarkdown.
+	RuleCodeBlocks
+	// RuleURLs drops the URLs.
+	RuleURLs
+	// RuleEmoji drops or verbalizes the emoji, see WithEmojiMode.
+	RuleEmoji
+	// RuleDates spells the ISO dates, and the Chinese dates in Chinese.
+	RuleDates
+	// RuleCurrency spells the currency amounts, e.g. "$3.50".
+	RuleCurrency
+	// RuleNumbers spells the numbers, percentages and English ordinals.
+	RuleNumbers
+)
+
+// AllRules are the rules of a TextNormalizer, in the order they are applied.
+var AllRules = []Rule{RuleMarkdown, RuleCodeBlocks, RuleURLs, RuleEmoji, RuleDates, RuleCurrency, RuleNumbers}
+
+func (r Rule) String() string {
+	switch r {
+	case RuleMarkdown:
+		return "Markdown"
+	case RuleCodeBlocks:
+		return "CodeBlocks"
+	case RuleURLs:
+		return "URLs"
+	case RuleEmoji:
+		return "Emoji"
+	case RuleDates:
+		return "Dates"
+	case RuleCurrency:
+		return "Currency"
+	case RuleNumbers:
+		return "Numbers"
+	default:
+		return "Unknown"
+	}
+}
+
+// maxHeldBytes is how much text is held at most for a link or an inline code to be closed.
+const maxHeldBytes = 512
+
+// TextNormalizer rewrites the downstream TextFrames, e.g. LLM output, into text
+// to be read aloud by a TTS service, rule by rule: it strips the Markdown syntax,
+// drops the URLs, drops or verbalizes the emoji, and spells the dates, currency
+// amounts and numbers in English or Chinese.
+//
+// Streamed text is normalized once complete enough: up to the last whitespace
+// or CJK character, or a sentence end, and after the links and inline code are
+// closed, so constructs split across TextFrames are normalized as a whole. The
+// held text is pushed on EndFrame, and dropped by an allowed StartInterruptionFrame.
+type TextNormalizer struct {
+	*processors.FrameProcessor
+	language  Language
+	emojiMode EmojiMode
+	rules     map[Rule]bool
+	buffer    string
+	md        markdownState
+}
+
+// NewTextNormalizer creates a new TextNormalizer applying all the rules in English, dropping the emoji.
+func NewTextNormalizer() *TextNormalizer {
+	n := &TextNormalizer{
+		FrameProcessor: processors.NewFrameProcessor("TextNormalizer"),
+		md:             markdownState{atLineStart: true},
+	}
+	return n.WithRules(AllRules...)
+}
+
+// WithLanguage sets the language the numbers, dates and currency amounts are spelled in.
+func (n *TextNormalizer) WithLanguage(language Language) *TextNormalizer {
+	n.language = language
+	return n
+}
+
+// WithEmojiMode sets how the emoji are read.
+func (n *TextNormalizer) WithEmojiMode(emojiMode EmojiMode) *TextNormalizer {
+	n.emojiMode = emojiMode
+	return n
+}
+
+// WithRules sets the rules applied, the others are not.
+func (n *TextNormalizer) WithRules(rules ...Rule) *TextNormalizer {
+	n.rules = make(map[Rule]bool, len(rules))
+	for _, rule := range rules {
+		n.rules[rule] = true
+	}
+	return n
+}
+
+// WithoutRules stops applying rules.
+func (n *TextNormalizer) WithoutRules(rules ...Rule) *TextNormalizer {
+	for _, rule := range rules {
+		delete(n.rules, rule)
+	}
+	return n
+}
+
+// ProcessFrame normalizes the downstream TextFrames and passes the other frames through.
+func (n *TextNormalizer) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	n.FrameProcessor.ProcessFrame(frame, direction)
+	if n.ShouldInterrupt(frame) {
+		n.reset()
+		n.PushFrame(frame, direction)
+		return
+	}
+
+	switch f := frame.(type) {
+	case *frames.TextFrame:
+		if direction != processors.FrameDirectionDownstream {
+			n.PushFrame(f, direction)
+			return
+		}
+		n.buffer += f.Text
+		i := readyIndex(n.buffer)
+		text := n.buffer[:i]
+		n.buffer = n.buffer[i:]
+		n.push(n.Normalize(text))
+	case *frames.EndFrame:
+		n.push(n.Normalize(n.buffer))
+		n.reset()
+		n.PushFrame(f, direction)
+	default:
+		n.PushFrame(frame, direction)
+	}
+}
+
+// Normalize applies the rules to text, following the text normalized before it.
+func (n *TextNormalizer) Normalize(text string) string {
+	if n.rules[RuleMarkdown] {
+		text = n.stripMarkdown(text)
+	}
+	if n.rules[RuleURLs] {
+		text = dropURLs(text)
+	}
+	if n.rules[RuleEmoji] {
+		text = replaceEmoji(text, n.emojiMode, n.language)
+	}
+	if n.rules[RuleDates] {
+		text = expandDates(text, n.language)
+	}
+	if n.rules[RuleCurrency] {
+		text = expandCurrency(text, n.language)
+	}
+	if n.rules[RuleNumbers] {
+		text = expandNumbers(text, n.language)
+	}
+	return text
+}
+
+func (n *TextNormalizer) push(text string) {
+	if text != "" {
+		n.PushFrame(frames.NewTextFrame(text), processors.FrameDirectionDownstream)
+	}
+}
+
+// reset drops the held text and the Markdown state.
+func (n *TextNormalizer) reset() {
+	n.buffer = ""
+	n.md = markdownState{atLineStart: true}
+}
+
+// readyIndex returns the length of the start of text complete enough to be
+// normalized: all of it if it ends a sentence, else up to the last break, and
+// before a link or an inline code not closed there.
+func readyIndex(text string) int {
+	i := len(text)
+	if !endsSentence(text) {
+		i = lastBreak(text)
+	}
+	if open := openIndex(text[:i]); open >= 0 && len(text)-open <= maxHeldBytes {
+		i = open
+	}
+	return i
+}
+
+// endsSentence returns whether text ends with a sentence punctuation mark, but a
+// period after a digit (a decimal point may follow).
+func endsSentence(text string) bool {
+	last, size := utf8.DecodeLastRuneInString(text)
+	switch last {
+	case '!', '?', '。', '！', '？':
+		return true
+	case '.':
+		before, _ := utf8.DecodeLastRuneInString(text[:len(text)-size])
+		return unicode.IsLetter(before)
+	}
+	return false
+}
+
+// lastBreak returns the index after the last whitespace or CJK character of text,
+// but the CJK characters after a digit, e.g. in a date "2024年1月".
+func lastBreak(text string) int {
+	i, prev := 0, rune(0)
+	for j, r := range text {
+		if unicode.IsSpace(r) || isCJK(r) && !unicode.IsDigit(prev) {
+			i = j + utf8.RuneLen(r)
+		}
+		prev = r
+	}
+	return i
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		r >= 0x3000 && r <= 0x303F ||
+		r >= 0xFF01 && r <= 0xFF0F || r >= 0xFF1A && r <= 0xFF20
+}
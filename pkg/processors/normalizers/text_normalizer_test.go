@@ -0,0 +1,134 @@
+package normalizers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+// mockProcessor records the frames it receives.
+type mockProcessor struct {
+	*processors.FrameProcessor
+	received []frames.Frame
+}
+
+func newMockProcessor() *mockProcessor {
+	return &mockProcessor{FrameProcessor: processors.NewFrameProcessor("mock_processor")}
+}
+
+func (p *mockProcessor) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	p.received = append(p.received, frame)
+}
+
+func (p *mockProcessor) texts() []string {
+	var texts []string
+	for _, frame := range p.received {
+		if text, ok := frame.(*frames.TextFrame); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	return texts
+}
+
+// normalize streams tokens through normalizer and returns the texts pushed.
+func normalize(normalizer *TextNormalizer, tokens ...string) []string {
+	mockProc := newMockProcessor()
+	normalizer.Link(mockProc)
+	start := frames.NewStartFrame()
+	start.AllowInterruptions = true
+	normalizer.ProcessFrame(start, processors.FrameDirectionDownstream)
+	for _, token := range tokens {
+		normalizer.ProcessFrame(frames.NewTextFrame(token), processors.FrameDirectionDownstream)
+	}
+	normalizer.ProcessFrame(frames.NewEndFrame(), processors.FrameDirectionDownstream)
+	return mockProc.texts()
+}
+
+func TestTextNormalizer_MarkdownAcrossFrames(t *testing.T) {
+	texts := normalize(NewTextNormalizer(),
+		"**Hel", "lo** wor", "ld. See [the", " docs](https://ex", "ample.com/a) or ", "run `go", " test`", " now.")
+	assert.Equal(t, []string{"Hello ", "world. See ", "the docs or ", "run ", "go test now."}, texts)
+}
+
+func TestTextNormalizer_MarkdownBlocks(t *testing.T) {
+	text := "# Title\n\nSome _italic_ and ~~old~~ text:\n- one\n* two\n1. three\n> quoted\n---\n" +
+		"```go\nfmt.Println(\"**\")\n```\nThat's ![a cat](cat.png) all."
+	expected := "Title\n\nSome italic and old text:\none\ntwo\nthree\nquoted\nThat's a cat all."
+	assert.Equal(t, expected, strings.Join(normalize(NewTextNormalizer(), text), ""))
+
+	// Token by token.
+	var tokens []string
+	for _, word := range strings.SplitAfter(text, " ") {
+		tokens = append(tokens, word[:len(word)/2], word[len(word)/2:])
+	}
+	assert.Equal(t, expected, strings.Join(normalize(NewTextNormalizer(), tokens...), ""))
+
+	// The code blocks kept without their fences.
+	texts := normalize(NewTextNormalizer().WithoutRules(RuleCodeBlocks, RuleNumbers), "Run:\n```sh\n", "go test ./...\n", "```\nok")
+	assert.Equal(t, "Run:\ngo test ./...\nok", strings.Join(texts, ""))
+}
+
+func TestTextNormalizer_MarkdownEmphasis(t *testing.T) {
+	// Only the asterisks opening or closing an emphasis are stripped.
+	markdown := NewTextNormalizer().WithRules(RuleMarkdown)
+	texts := normalize(markdown, "2 * 3 = 6 and 2*3, **bold**, **Note:** a *b* c")
+	assert.Equal(t, "2 * 3 = 6 and 2*3, bold, Note: a b c", strings.Join(texts, ""))
+	assert.Equal(t, "two * three = six.", strings.Join(normalize(NewTextNormalizer(), "2 * 3 = 6."), ""))
+
+	// Closing an emphasis in a text cut after a CJK character.
+	texts = normalize(NewTextNormalizer().WithRules(RuleMarkdown).WithLanguage(LanguageChinese), "**你好", "** 世界")
+	assert.Equal(t, "你好 世界", strings.Join(texts, ""))
+}
+
+func TestTextNormalizer_Emoji(t *testing.T) {
+	assert.Equal(t, "Great job! Thanks ", strings.Join(normalize(NewTextNormalizer(), "Great job!👍🏽 Thanks 🎉🤷"), ""))
+
+	verbalize := NewTextNormalizer().WithEmojiMode(EmojiVerbalize)
+	assert.Equal(t, "Great job thumbs up, thanks", strings.Join(normalize(verbalize, "Great job👍🏽, thanks🤷‍♂️"), ""))
+
+	verbalize = NewTextNormalizer().WithEmojiMode(EmojiVerbalize).WithLanguage(LanguageChinese)
+	assert.Equal(t, "做得好点赞", strings.Join(normalize(verbalize, "做得好👍"), ""))
+}
+
+func TestTextNormalizer_NumbersAcrossFrames(t *testing.T) {
+	texts := normalize(NewTextNormalizer(), "It costs $", "1,2", "00.5", "0 on 2024-", "03-01, ", "up 5", "%.")
+	assert.Equal(t, "It costs one thousand two hundred dollars and fifty cents on March first, twenty twenty-four, up five percent.", strings.Join(texts, ""))
+
+	texts = normalize(NewTextNormalizer().WithLanguage(LanguageChinese), "价格是", "¥1", "2.05", "，日期", "2024年", "1月", "2日。")
+	assert.Equal(t, []string{"价格是", "十二元五分，日期", "二零二四年一月二日。"}, texts)
+}
+
+func TestTextNormalizer_Rules(t *testing.T) {
+	text := "**Call** 911 at https://example.com 🚑"
+	assert.Equal(t, "Call nine hundred eleven at  ", strings.Join(normalize(NewTextNormalizer(), text), ""))
+	assert.Equal(t, "Call 911 at  ", strings.Join(normalize(NewTextNormalizer().WithoutRules(RuleNumbers), text), ""))
+	assert.Equal(t, "**Call** 911 at https://example.com ", strings.Join(normalize(NewTextNormalizer().WithRules(RuleEmoji), text), ""))
+}
+
+func TestTextNormalizer_Interruption(t *testing.T) {
+	normalizer := NewTextNormalizer()
+	mockProc := newMockProcessor()
+	normalizer.Link(mockProc)
+	start := frames.NewStartFrame()
+	start.AllowInterruptions = true
+	normalizer.ProcessFrame(start, processors.FrameDirectionDownstream)
+
+	normalizer.ProcessFrame(frames.NewTextFrame("```\nstale code [li"), processors.FrameDirectionDownstream)
+	normalizer.ProcessFrame(frames.NewStartInterruptionFrame(), processors.FrameDirectionDownstream)
+	normalizer.ProcessFrame(frames.NewTextFrame("**Fresh** text"), processors.FrameDirectionDownstream)
+	normalizer.ProcessFrame(frames.NewEndFrame(), processors.FrameDirectionDownstream)
+
+	assert.Equal(t, []string{"Fresh ", "text"}, mockProc.texts())
+	assert.IsType(t, &frames.EndFrame{}, mockProc.received[len(mockProc.received)-1])
+}
+
+func TestTextNormalizer_Upstream(t *testing.T) {
+	normalizer := NewTextNormalizer()
+	mockProc := newMockProcessor()
+	normalizer.SetPrev(mockProc)
+	normalizer.ProcessFrame(frames.NewTextFrame("**3**"), processors.FrameDirectionUpstream)
+	assert.Equal(t, []string{"**3**"}, mockProc.texts())
+}
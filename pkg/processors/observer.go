@@ -0,0 +1,87 @@
+package processors
+
+import (
+	"time"
+
+	"github.com/weedge/pipeline-go/pkg/frames"
+)
+
+// Reasons a processor drops a frame, see FrameDropEvent.
+const (
+	// DropReasonQueueFull is a frame not queued because the queue is full.
+	DropReasonQueueFull = "queue_full"
+	// DropReasonStopped is a frame not queued because the queue workers are stopped.
+	DropReasonStopped = "stopped"
+	// DropReasonInterrupted is a queued frame dropped by an interruption or a cancel.
+	DropReasonInterrupted = "interrupted"
+)
+
+// FramePushEvent is a frame pushed by a processor to its neighbour.
+type FramePushEvent struct {
+	Source      IFrameProcessor
+	Destination IFrameProcessor
+	Frame       frames.Frame
+	Direction   FrameDirection
+	Timestamp   time.Time
+	// QueuedAt is when the frame was queued by the source, zero if it wasn't.
+	QueuedAt time.Time
+}
+
+// FrameDropEvent is a frame a processor dropped instead of pushing it.
+type FrameDropEvent struct {
+	Processor IFrameProcessor
+	Frame     frames.Frame
+	Direction FrameDirection
+	Reason    string
+	Timestamp time.Time
+}
+
+// FrameObserver is notified of the frames pushed between processors and of
+// the frames they drop. It is called synchronously from the pushing goroutine,
+// so implementations must be concurrency-safe and must not block.
+type FrameObserver interface {
+	OnPushFrame(event FramePushEvent)
+	OnDropFrame(event FrameDropEvent)
+}
+
+// FrameProcessedObserver is a FrameObserver also notified when the destination
+// of a pushed frame returns from its ProcessFrame, panicking or not.
+type FrameProcessedObserver interface {
+	FrameObserver
+	OnFrameProcessed(event FramePushEvent)
+}
+
+// AddObserver adds an observer of the frames the processor pushes and drops.
+// Observers must be added before frames flow through the processor.
+func (p *FrameProcessor) AddObserver(observer FrameObserver) {
+	p.observers = append(p.observers, observer)
+}
+
+// notifyPush notifies the push of frame to dest and returns the function notifying it was processed.
+func (p *FrameProcessor) notifyPush(dest IFrameProcessor, frame frames.Frame, direction FrameDirection, queuedAt time.Time) func() {
+	if len(p.observers) == 0 {
+		return func() {}
+	}
+	event := FramePushEvent{Source: p, Destination: dest, Frame: frame, Direction: direction, Timestamp: p.Clock().Now(), QueuedAt: queuedAt}
+	for _, observer := range p.observers {
+		observer.OnPushFrame(event)
+	}
+	return func() {
+		for _, observer := range p.observers {
+			if o, ok := observer.(FrameProcessedObserver); ok {
+				o.OnFrameProcessed(event)
+			}
+		}
+	}
+}
+
+func (p *FrameProcessor) notifyDrop(frame frames.Frame, direction FrameDirection, reason string) {
+	p.stats.dropped.Add(1)
+	if len(p.observers) == 0 {
+		return
+	}
+	event := FrameDropEvent{Processor: p, Frame: frame, Direction: direction, Reason: reason, Timestamp: p.Clock().Now()}
+	for _, observer := range p.observers {
+		observer.OnDropFrame(event)
+	}
+}
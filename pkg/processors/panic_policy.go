@@ -0,0 +1,135 @@
+package processors
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/logger"
+)
+
+// PanicPolicy is what PushFrame does when the ProcessFrame it calls panics.
+// The policy of the processor that panicked applies.
+type PanicPolicy int
+
+const (
+	// PanicPolicyErrorFrame pushes a non-fatal ErrorFrame wrapping a *PanicError upstream.
+	PanicPolicyErrorFrame PanicPolicy = iota
+	// PanicPolicyFatalErrorFrame pushes a fatal ErrorFrame upstream, which cancels the pipeline task.
+	PanicPolicyFatalErrorFrame
+	// PanicPolicyRepanic panics again with the *PanicError.
+	PanicPolicyRepanic
+	// PanicPolicyIgnore only logs the panic.
+	PanicPolicyIgnore
+)
+
+// String returns the string representation of PanicPolicy
+func (p PanicPolicy) String() string {
+	switch p {
+	case PanicPolicyErrorFrame:
+		return "ErrorFrame"
+	case PanicPolicyFatalErrorFrame:
+		return "FatalErrorFrame"
+	case PanicPolicyRepanic:
+		return "Repanic"
+	case PanicPolicyIgnore:
+		return "Ignore"
+	default:
+		return "Unknown"
+	}
+}
+
+// PanicError is a panic recovered from a processor's ProcessFrame.
+type PanicError struct {
+	Processor string
+	Frame     frames.Frame
+	Value     any
+	Stack     string
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic in %s processing %s: %v", e.Processor, e.Frame, e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// PanicPolicy returns the panic policy of the processor.
+func (p *FrameProcessor) PanicPolicy() PanicPolicy {
+	return p.panicPolicy
+}
+
+// SetPanicPolicy sets what happens when the processor's ProcessFrame panics.
+func (p *FrameProcessor) SetPanicPolicy(policy PanicPolicy) {
+	p.panicPolicy = policy
+}
+
+// PanicCount returns how many times the processor's ProcessFrame panicked.
+func (p *FrameProcessor) PanicCount() uint64 {
+	return p.panics.Load()
+}
+
+// RecoverPanic recovers a panic of a goroutine of the processor, working on
+// frame outside of ProcessFrame, and applies the processor's panic policy to it
+// like PushFrame does. It has to be deferred directly:
+//
+//	go func() {
+//		defer p.RecoverPanic(frame)
+//		...
+//	}()
+func (p *FrameProcessor) RecoverPanic(frame frames.Frame) {
+	if r := recover(); r != nil {
+		if _, repanicked := r.(*PanicError); !repanicked {
+			p.panics.Add(1)
+		}
+		p.handlePanic(r, nil, frame)
+	}
+}
+
+// handlePanic applies the panic policy to r, recovered while pushing frame to dest.
+func (p *FrameProcessor) handlePanic(r any, dest IFrameProcessor, frame frames.Frame) {
+	// A *PanicError was re-panicked further down the chain, by the policy of
+	// the processor that panicked: it is already counted and logged, and keeps
+	// unwinding whatever the policies of the processors before.
+	if panicErr, repanicked := r.(*PanicError); repanicked {
+		panic(panicErr)
+	}
+
+	buf := make([]byte, 10240)
+	n := runtime.Stack(buf, false)
+	panicErr := &PanicError{Processor: p.name, Frame: frame, Value: r, Stack: string(buf[:n])}
+	if dest != nil {
+		panicErr.Processor = dest.Name()
+	}
+
+	// Apply the policy of the processor that panicked, and report the error
+	// from its position so it goes upstream like any error it would push.
+	policy, reporter := p.panicPolicy, p
+	if b, ok := dest.(baseProcessor); ok {
+		destBase := b.frameProcessor()
+		policy = destBase.panicPolicy
+		destBase.panics.Add(1)
+		if destBase.prev != nil {
+			reporter = destBase
+		}
+	}
+
+	panicked := panicErr.Processor
+	if dest != nil {
+		panicked = fmt.Sprintf("%s(%T)", panicked, dest)
+	}
+	msg := fmt.Sprintf("Uncaught panic in %s: %v\nStack trace:\n%s", panicked, panicErr.Value, panicErr.Stack)
+	switch policy {
+	case PanicPolicyIgnore:
+		logger.Info(msg)
+	case PanicPolicyRepanic:
+		logger.Error(msg)
+		panic(panicErr)
+	default:
+		logger.Error(msg)
+		reporter.PushError(frames.NewErrorFrame(panicErr, policy == PanicPolicyFatalErrorFrame))
+	}
+}
@@ -0,0 +1,134 @@
+package processors
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weedge/pipeline-go/pkg/frames"
+)
+
+// panicProcessor panics on TextFrames.
+type panicProcessor struct {
+	*FrameProcessor
+}
+
+func newPanicProcessor(policy PanicPolicy) *panicProcessor {
+	p := &panicProcessor{FrameProcessor: NewFrameProcessor("panic_processor")}
+	p.SetPanicPolicy(policy)
+	return p
+}
+
+func (p *panicProcessor) ProcessFrame(frame frames.Frame, direction FrameDirection) {
+	if text, ok := frame.(*frames.TextFrame); ok {
+		panic("boom: " + text.Text)
+	}
+	p.PushFrame(frame, direction)
+}
+
+// panicChain links up <-> pusher <-> panicker.
+func panicChain(policy PanicPolicy) (up, pusher *mockProcessor, panicker *panicProcessor) {
+	up, pusher, panicker = NewMockProcessorWithName("up"), NewMockProcessorWithName("pusher"), newPanicProcessor(policy)
+	pusher.SetPrev(up)
+	pusher.Link(panicker)
+	panicker.SetPrev(pusher)
+	return up, pusher, panicker
+}
+
+func TestPushFrame_PanicErrorFrame(t *testing.T) {
+	for _, policy := range []PanicPolicy{PanicPolicyErrorFrame, PanicPolicyFatalErrorFrame} {
+		t.Run(policy.String(), func(t *testing.T) {
+			up, pusher, panicker := panicChain(policy)
+
+			pusher.PushFrame(frames.NewTextFrame("hi"), FrameDirectionDownstream)
+
+			received := up.GetReceivedDirectionUpstreamFrames()
+			assert.Equal(t, 1, len(received))
+			errFrame, ok := received[0].(*frames.ErrorFrame)
+			assert.True(t, ok)
+			assert.Equal(t, policy == PanicPolicyFatalErrorFrame, errFrame.Fatal)
+
+			var panicErr *PanicError
+			assert.True(t, errors.As(errFrame.Error, &panicErr))
+			assert.Equal(t, "panic_processor", panicErr.Processor)
+			assert.Equal(t, "boom: hi", panicErr.Value)
+			assert.IsType(t, &frames.TextFrame{}, panicErr.Frame)
+			assert.True(t, strings.Contains(panicErr.Stack, "panicProcessor).ProcessFrame"), panicErr.Stack)
+
+			assert.Equal(t, uint64(1), panicker.PanicCount())
+			assert.Equal(t, uint64(0), pusher.PanicCount())
+		})
+	}
+}
+
+func TestPushFrame_PanicIgnore(t *testing.T) {
+	up, pusher, panicker := panicChain(PanicPolicyIgnore)
+
+	pusher.PushFrame(frames.NewTextFrame("hi"), FrameDirectionDownstream)
+	pusher.PushFrame(frames.NewTextFrame("again"), FrameDirectionDownstream)
+
+	assert.Empty(t, up.GetReceivedDirectionUpstreamFrames())
+	assert.Equal(t, uint64(2), panicker.PanicCount())
+}
+
+func TestPushFrame_PanicRepanic(t *testing.T) {
+	_, pusher, panicker := panicChain(PanicPolicyRepanic)
+
+	defer func() {
+		r := recover()
+		panicErr, ok := r.(*PanicError)
+		assert.True(t, ok)
+		assert.Equal(t, "panic_processor", panicErr.Processor)
+		assert.Equal(t, uint64(1), panicker.PanicCount())
+	}()
+	pusher.PushFrame(frames.NewTextFrame("hi"), FrameDirectionDownstream)
+	t.Fatal("expected a panic")
+}
+
+func TestPushFrame_RepanicThroughChain(t *testing.T) {
+	// source -> middle -> panicker: the panicker re-panics, middle has the default policy.
+	source, middle, panicker := NewMockProcessorWithName("source"), NewMockProcessorWithName("middle"), newPanicProcessor(PanicPolicyRepanic)
+	source.Link(middle)
+	middle.SetPrev(source)
+	middle.Link(panicker)
+	panicker.SetPrev(middle)
+
+	defer func() {
+		panicErr, ok := recover().(*PanicError)
+		if assert.True(t, ok) {
+			assert.Equal(t, "panic_processor", panicErr.Processor)
+		}
+		assert.Equal(t, uint64(1), panicker.PanicCount())
+		assert.Equal(t, uint64(0), middle.PanicCount())
+		assert.Empty(t, source.GetReceivedDirectionUpstreamFrames())
+	}()
+	source.PushFrame(frames.NewTextFrame("hi"), FrameDirectionDownstream)
+	t.Fatal("expected a panic")
+}
+
+func TestRecoverPanic(t *testing.T) {
+	up, pusher, _ := panicChain(PanicPolicyErrorFrame)
+	pusher.SetPanicPolicy(PanicPolicyFatalErrorFrame)
+	frame := frames.NewTextFrame("hi")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer pusher.RecoverPanic(frame)
+		panic("background boom")
+	}()
+	<-done
+
+	// The policy of the processor whose goroutine panicked applies.
+	assert.Equal(t, uint64(1), pusher.PanicCount())
+	received := up.GetReceivedDirectionUpstreamFrames()
+	if assert.Equal(t, 1, len(received)) {
+		errFrame := received[0].(*frames.ErrorFrame)
+		assert.True(t, errFrame.Fatal)
+		var panicErr *PanicError
+		assert.True(t, errors.As(errFrame.Error, &panicErr))
+		assert.Equal(t, "pusher", panicErr.Processor)
+		assert.Equal(t, frame, panicErr.Frame)
+	}
+}
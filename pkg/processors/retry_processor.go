@@ -0,0 +1,406 @@
+package processors
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/logger"
+)
+
+// CircuitState is the state of a RetryProcessor circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets frames through to the wrapped processor.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects frames until the open timeout elapses.
+	CircuitOpen
+	// CircuitHalfOpen lets one trial frame through, its outcome closes or reopens the circuit.
+	CircuitHalfOpen
+)
+
+// String returns the string representation of CircuitState
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "Closed"
+	case CircuitOpen:
+		return "Open"
+	case CircuitHalfOpen:
+		return "HalfOpen"
+	default:
+		return "Unknown"
+	}
+}
+
+// CircuitOpenError reports a frame rejected because the circuit breaker is open.
+type CircuitOpenError struct {
+	Processor string
+	Frame     frames.Frame
+	// Failures is the number of consecutive failures that opened the circuit.
+	Failures int
+	// RetryAt is when the circuit lets a trial frame through again.
+	RetryAt time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit of %s open after %d consecutive failures, rejected %s until %s",
+		e.Processor, e.Failures, e.Frame, e.RetryAt.Format(time.RFC3339Nano))
+}
+
+// RetryProcessor wraps a IFrameProcessor and calls its ProcessFrame again when
+// it reports a retryable ErrorFrame during the call, waiting an exponential
+// backoff with jitter between attempts. Once the attempts are exhausted the
+// last ErrorFrame is pushed upstream.
+//
+// A circuit breaker opens after a number of consecutive failed attempts: while
+// open, frames are not sent to the wrapped processor and a non-fatal ErrorFrame
+// wrapping a *CircuitOpenError is pushed upstream instead. After the open
+// timeout one trial frame is let through to close the circuit again.
+//
+// Frames the wrapped processor pushed downstream before failing are not taken
+// back, a retried attempt pushes them again. Lifecycle frames (start, end,
+// cancel, interruptions) are never retried nor rejected.
+type RetryProcessor struct {
+	*FrameProcessor
+	wrappedProcessor IFrameProcessor
+	tap              *retryTap
+
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	multiplier     float64
+	jitter         float64
+	retryable      func(errorFrame *frames.ErrorFrame) bool
+
+	failureThreshold int
+	openTimeout      time.Duration
+
+	// attemptMu serializes the attempts, errMu guards the attempt state below.
+	attemptMu   sync.Mutex
+	errMu       sync.Mutex
+	attempting  bool
+	attemptErr  *frames.ErrorFrame
+	passthrough map[*frames.ErrorFrame]struct{}
+	abort       chan struct{}
+
+	breakerMu           sync.Mutex
+	state               CircuitState
+	consecutiveFailures int
+	openedAt            time.Time
+
+	retries  atomic.Uint64
+	failures atomic.Uint64
+}
+
+// retryTap is the previous processor of the wrapped processor: it hands the
+// frames the wrapped processor pushes upstream to the RetryProcessor.
+type retryTap struct {
+	*FrameProcessor
+	retry *RetryProcessor
+}
+
+func (t *retryTap) ProcessFrame(frame frames.Frame, direction FrameDirection) {
+	t.retry.handleWrappedUpstream(frame)
+}
+
+// NewRetryProcessor creates a new RetryProcessor around processor,
+// with 3 attempts, a 100ms initial backoff doubling up to 5s with 20% jitter,
+// and a circuit opening for 30s after 5 consecutive failures.
+func NewRetryProcessor(processor IFrameProcessor) *RetryProcessor {
+	p := &RetryProcessor{
+		FrameProcessor:   NewFrameProcessor("Retry(" + processor.Name() + ")"),
+		wrappedProcessor: processor,
+		maxAttempts:      3,
+		initialBackoff:   100 * time.Millisecond,
+		maxBackoff:       5 * time.Second,
+		multiplier:       2,
+		jitter:           0.2,
+		retryable: func(errorFrame *frames.ErrorFrame) bool {
+			return !errorFrame.Fatal
+		},
+		failureThreshold: 5,
+		openTimeout:      30 * time.Second,
+		passthrough:      make(map[*frames.ErrorFrame]struct{}),
+		abort:            make(chan struct{}),
+	}
+	p.tap = &retryTap{FrameProcessor: NewFrameProcessor(p.Name() + ".tap"), retry: p}
+	processor.SetPrev(p.tap)
+	return p
+}
+
+// WithMaxAttempts sets how many times a frame is sent to the wrapped processor, at least 1.
+func (p *RetryProcessor) WithMaxAttempts(maxAttempts int) *RetryProcessor {
+	p.maxAttempts = max(maxAttempts, 1)
+	return p
+}
+
+// WithBackoff sets the backoff before the first retry, its growth factor and its upper bound.
+func (p *RetryProcessor) WithBackoff(initial, maxBackoff time.Duration, multiplier float64) *RetryProcessor {
+	p.initialBackoff = initial
+	p.maxBackoff = maxBackoff
+	if multiplier >= 1 {
+		p.multiplier = multiplier
+	}
+	return p
+}
+
+// WithJitter sets the jitter as a fraction of the backoff, the backoff is
+// randomized in [backoff*(1-jitter), backoff*(1+jitter)]. 0 disables it.
+func (p *RetryProcessor) WithJitter(jitter float64) *RetryProcessor {
+	p.jitter = min(max(jitter, 0), 1)
+	return p
+}
+
+// WithRetryable sets which ErrorFrames reported by the wrapped processor are
+// retried (default: the non-fatal ones). The others are pushed upstream as is.
+func (p *RetryProcessor) WithRetryable(retryable func(errorFrame *frames.ErrorFrame) bool) *RetryProcessor {
+	p.retryable = retryable
+	return p
+}
+
+// WithCircuitBreaker sets the number of consecutive failed attempts opening
+// the circuit and how long it stays open. A threshold <= 0 disables it.
+func (p *RetryProcessor) WithCircuitBreaker(failureThreshold int, openTimeout time.Duration) *RetryProcessor {
+	p.failureThreshold = failureThreshold
+	p.openTimeout = openTimeout
+	return p
+}
+
+// Wrapped returns the retried processor.
+func (p *RetryProcessor) Wrapped() IFrameProcessor {
+	return p.wrappedProcessor
+}
+
+// Retries returns how many times a frame was sent again to the wrapped processor.
+func (p *RetryProcessor) Retries() uint64 {
+	return p.retries.Load()
+}
+
+// Failures returns how many frames failed after all their attempts or were rejected by the open circuit.
+func (p *RetryProcessor) Failures() uint64 {
+	return p.failures.Load()
+}
+
+// CircuitState returns the current state of the circuit breaker.
+func (p *RetryProcessor) CircuitState() CircuitState {
+	p.breakerMu.Lock()
+	defer p.breakerMu.Unlock()
+	if p.state == CircuitOpen && p.Clock().Since(p.openedAt) >= p.openTimeout {
+		return CircuitHalfOpen
+	}
+	return p.state
+}
+
+// Link links the wrapped processor to next.
+func (p *RetryProcessor) Link(next IFrameProcessor) {
+	p.FrameProcessor.Link(next)
+	p.wrappedProcessor.Link(next)
+}
+
+// SetPrev sets the previous processor, the frames the wrapped processor pushes
+// upstream go to it unless they are failures being retried.
+func (p *RetryProcessor) SetPrev(prev IFrameProcessor) {
+	p.FrameProcessor.SetPrev(prev)
+}
+
+func (p *RetryProcessor) SetVerbose(verbose bool) {
+	p.FrameProcessor.SetVerbose(verbose)
+	p.wrappedProcessor.SetVerbose(verbose)
+}
+
+// Cleanup cleans up the wrapped processor.
+func (p *RetryProcessor) Cleanup() {
+	p.wrappedProcessor.Cleanup()
+}
+
+// ProcessFrame sends frame to the wrapped processor, retrying it on failure.
+func (p *RetryProcessor) ProcessFrame(frame frames.Frame, direction FrameDirection) {
+	p.FrameProcessor.ProcessFrame(frame, direction)
+
+	if direction == FrameDirectionUpstream {
+		// From the next processor: errors passing through the wrapped one are not its failures.
+		if errorFrame, ok := frame.(*frames.ErrorFrame); ok {
+			p.errMu.Lock()
+			p.passthrough[errorFrame] = struct{}{}
+			p.errMu.Unlock()
+			// Also forgotten if the wrapped processor drops the error.
+			defer func() {
+				p.errMu.Lock()
+				delete(p.passthrough, errorFrame)
+				p.errMu.Unlock()
+			}()
+		}
+		p.wrappedProcessor.ProcessFrame(frame, direction)
+		return
+	}
+
+	if isLifecycleFrame(frame) {
+		if _, ok := frame.(*frames.CancelFrame); ok || p.ShouldInterrupt(frame) {
+			p.abortBackoff()
+		}
+		p.wrappedProcessor.ProcessFrame(frame, direction)
+		return
+	}
+
+	p.attemptMu.Lock()
+	defer p.attemptMu.Unlock()
+
+	if err := p.allow(frame); err != nil {
+		p.failures.Add(1)
+		logger.Warnf("%s", err)
+		p.PushError(frames.NewErrorFrame(err, false))
+		return
+	}
+
+	p.errMu.Lock()
+	abort := p.abort
+	p.errMu.Unlock()
+	for attempt := 1; ; attempt++ {
+		errorFrame := p.attempt(frame, direction)
+		if errorFrame == nil {
+			p.recordSuccess()
+			return
+		}
+		if !p.retryable(errorFrame) {
+			p.PushError(errorFrame)
+			return
+		}
+		opened := p.recordFailure()
+		if attempt >= p.maxAttempts || opened {
+			p.failures.Add(1)
+			logger.Warnf("%s giving up on %s after %d attempts: %s", p.Name(), frame, attempt, errorFrame.Error)
+			p.PushError(errorFrame)
+			return
+		}
+
+		backoff := p.backoff(attempt)
+		logger.Infof("%s attempt %d on %s failed: %s, retrying in %s", p.Name(), attempt, frame, errorFrame.Error, backoff)
+		timer := p.Clock().NewTimer(backoff)
+		select {
+		case <-timer.C():
+		case <-abort:
+			timer.Stop()
+			logger.Infof("%s retry of %s aborted", p.Name(), frame)
+			return
+		}
+		p.retries.Add(1)
+	}
+}
+
+// attempt calls the wrapped processor and returns the retryable-candidate ErrorFrame it reported.
+func (p *RetryProcessor) attempt(frame frames.Frame, direction FrameDirection) *frames.ErrorFrame {
+	p.errMu.Lock()
+	p.attempting, p.attemptErr = true, nil
+	p.errMu.Unlock()
+
+	p.wrappedProcessor.ProcessFrame(frame, direction)
+
+	p.errMu.Lock()
+	defer p.errMu.Unlock()
+	p.attempting = false
+	return p.attemptErr
+}
+
+// handleWrappedUpstream gets the frames pushed upstream by the wrapped processor.
+func (p *RetryProcessor) handleWrappedUpstream(frame frames.Frame) {
+	if errorFrame, ok := frame.(*frames.ErrorFrame); ok {
+		p.errMu.Lock()
+		_, passthrough := p.passthrough[errorFrame]
+		delete(p.passthrough, errorFrame)
+		// Keep the first error of the attempt, the attempt is retried or reported once.
+		capture := !passthrough && p.attempting && p.attemptErr == nil
+		if capture {
+			p.attemptErr = errorFrame
+		}
+		p.errMu.Unlock()
+		if capture {
+			return
+		}
+	}
+	p.PushFrame(frame, FrameDirectionUpstream)
+}
+
+// backoff returns the wait before the retry following attempt.
+func (p *RetryProcessor) backoff(attempt int) time.Duration {
+	backoff := float64(p.initialBackoff)
+	for i := 1; i < attempt; i++ {
+		backoff *= p.multiplier
+		if p.maxBackoff > 0 && backoff >= float64(p.maxBackoff) {
+			break
+		}
+	}
+	if p.maxBackoff > 0 {
+		backoff = min(backoff, float64(p.maxBackoff))
+	}
+	if p.jitter > 0 {
+		backoff *= 1 - p.jitter + 2*p.jitter*rand.Float64()
+	}
+	return time.Duration(backoff)
+}
+
+// abortBackoff stops the retries waiting for their backoff.
+func (p *RetryProcessor) abortBackoff() {
+	p.errMu.Lock()
+	defer p.errMu.Unlock()
+	close(p.abort)
+	p.abort = make(chan struct{})
+}
+
+// allow returns a *CircuitOpenError if the circuit rejects frame.
+func (p *RetryProcessor) allow(frame frames.Frame) error {
+	p.breakerMu.Lock()
+	defer p.breakerMu.Unlock()
+	if p.state != CircuitOpen {
+		return nil
+	}
+	retryAt := p.openedAt.Add(p.openTimeout)
+	if !p.Clock().Now().Before(retryAt) {
+		logger.Infof("%s circuit half-open, trying %s", p.Name(), frame)
+		p.state = CircuitHalfOpen
+		return nil
+	}
+	return &CircuitOpenError{Processor: p.wrappedProcessor.Name(), Frame: frame, Failures: p.consecutiveFailures, RetryAt: retryAt}
+}
+
+func (p *RetryProcessor) recordSuccess() {
+	p.breakerMu.Lock()
+	defer p.breakerMu.Unlock()
+	if p.state != CircuitClosed {
+		logger.Infof("%s circuit closed", p.Name())
+	}
+	p.state = CircuitClosed
+	p.consecutiveFailures = 0
+}
+
+// recordFailure counts a failed attempt and returns whether it opened the circuit.
+func (p *RetryProcessor) recordFailure() bool {
+	p.breakerMu.Lock()
+	defer p.breakerMu.Unlock()
+	p.consecutiveFailures++
+	if p.failureThreshold <= 0 {
+		return false
+	}
+	if p.state == CircuitHalfOpen || p.consecutiveFailures >= p.failureThreshold {
+		p.state = CircuitOpen
+		p.openedAt = p.Clock().Now()
+		logger.Warnf("%s circuit open after %d consecutive failures", p.Name(), p.consecutiveFailures)
+		return true
+	}
+	return false
+}
+
+// isLifecycleFrame reports whether frame drives the pipeline rather than carries data.
+func isLifecycleFrame(frame frames.Frame) bool {
+	switch frame.(type) {
+	case *frames.StartFrame, *frames.EndFrame, *frames.CancelFrame, *frames.StopTaskFrame,
+		*frames.StartInterruptionFrame, frames.StartInterruptionFrame,
+		*frames.StopInterruptionFrame, frames.StopInterruptionFrame:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,236 @@
+package processors
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weedge/pipeline-go/pkg/clock"
+	"github.com/weedge/pipeline-go/pkg/frames"
+)
+
+// flakyProcessor reports an ErrorFrame for the TextFrames of its first failures calls.
+type flakyProcessor struct {
+	*FrameProcessor
+	mu       sync.Mutex
+	failures int
+	fatal    bool
+	calls    int
+}
+
+func newFlakyProcessor(failures int) *flakyProcessor {
+	return &flakyProcessor{FrameProcessor: NewFrameProcessor("flaky"), failures: failures}
+}
+
+func (p *flakyProcessor) ProcessFrame(frame frames.Frame, direction FrameDirection) {
+	if _, ok := frame.(*frames.TextFrame); ok && direction == FrameDirectionDownstream {
+		p.mu.Lock()
+		p.calls++
+		fail := p.calls <= p.failures
+		p.mu.Unlock()
+		if fail {
+			p.PushError(frames.NewErrorFrame(errors.New("service unavailable"), p.fatal))
+			return
+		}
+	}
+	p.PushFrame(frame, direction)
+}
+
+func (p *flakyProcessor) callCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls
+}
+
+func retryChain(flaky *flakyProcessor) (up, down *mockProcessor, retry *RetryProcessor) {
+	up, down = NewMockProcessorWithName("up"), NewMockProcessorWithName("down")
+	retry = NewRetryProcessor(flaky).WithBackoff(time.Millisecond, 4*time.Millisecond, 2).WithJitter(0)
+	retry.SetPrev(up)
+	retry.Link(down)
+	down.SetPrev(retry)
+	return up, down, retry
+}
+
+func errorFrames(fs []frames.Frame) []*frames.ErrorFrame {
+	var errs []*frames.ErrorFrame
+	for _, f := range fs {
+		if errorFrame, ok := f.(*frames.ErrorFrame); ok {
+			errs = append(errs, errorFrame)
+		}
+	}
+	return errs
+}
+
+func TestRetryProcessor_Recovers(t *testing.T) {
+	flaky := newFlakyProcessor(2)
+	up, down, retry := retryChain(flaky)
+
+	retry.ProcessFrame(frames.NewTextFrame("hi"), FrameDirectionDownstream)
+
+	assert.Equal(t, 3, flaky.callCount())
+	assert.Equal(t, uint64(2), retry.Retries())
+	assert.Equal(t, uint64(0), retry.Failures())
+	assert.Empty(t, errorFrames(up.GetReceivedDirectionUpstreamFrames()))
+	assert.Equal(t, 1, len(down.GetReceivedDirectionDownstreamFrames()))
+	assert.Equal(t, CircuitClosed, retry.CircuitState())
+}
+
+func TestRetryProcessor_GivesUp(t *testing.T) {
+	flaky := newFlakyProcessor(100)
+	up, down, retry := retryChain(flaky)
+	retry.WithMaxAttempts(3).WithCircuitBreaker(0, 0)
+
+	retry.ProcessFrame(frames.NewTextFrame("hi"), FrameDirectionDownstream)
+
+	assert.Equal(t, 3, flaky.callCount())
+	assert.Equal(t, uint64(1), retry.Failures())
+	errs := errorFrames(up.GetReceivedDirectionUpstreamFrames())
+	assert.Equal(t, 1, len(errs))
+	assert.EqualError(t, errs[0].Error, "service unavailable")
+	assert.Empty(t, down.GetReceivedDirectionDownstreamFrames())
+}
+
+func TestRetryProcessor_NotRetryable(t *testing.T) {
+	flaky := newFlakyProcessor(100)
+	flaky.fatal = true
+	up, _, retry := retryChain(flaky)
+
+	retry.ProcessFrame(frames.NewTextFrame("hi"), FrameDirectionDownstream)
+
+	assert.Equal(t, 1, flaky.callCount())
+	errs := errorFrames(up.GetReceivedDirectionUpstreamFrames())
+	assert.Equal(t, 1, len(errs))
+	assert.True(t, errs[0].Fatal)
+}
+
+func TestRetryProcessor_CircuitBreaker(t *testing.T) {
+	flaky := newFlakyProcessor(2)
+	up, down, retry := retryChain(flaky)
+	retry.WithMaxAttempts(1).WithCircuitBreaker(2, 30*time.Millisecond)
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	retry.SetClock(fake)
+
+	retry.ProcessFrame(frames.NewTextFrame("1"), FrameDirectionDownstream)
+	assert.Equal(t, CircuitClosed, retry.CircuitState())
+	retry.ProcessFrame(frames.NewTextFrame("2"), FrameDirectionDownstream)
+	assert.Equal(t, CircuitOpen, retry.CircuitState())
+
+	// While open the wrapped processor isn't called, lifecycle frames still pass.
+	retry.ProcessFrame(frames.NewTextFrame("3"), FrameDirectionDownstream)
+	retry.ProcessFrame(frames.NewStartFrame(), FrameDirectionDownstream)
+	assert.Equal(t, 2, flaky.callCount())
+	errs := errorFrames(up.GetReceivedDirectionUpstreamFrames())
+	assert.Equal(t, 3, len(errs))
+	var openErr *CircuitOpenError
+	assert.ErrorAs(t, errs[2].Error, &openErr)
+	assert.Equal(t, "flaky", openErr.Processor)
+	assert.Equal(t, 2, openErr.Failures)
+	assert.IsType(t, &frames.StartFrame{}, down.GetReceivedDirectionDownstreamFrames()[0])
+
+	// After the open timeout a trial frame closes the circuit.
+	fake.Advance(29 * time.Millisecond)
+	assert.Equal(t, CircuitOpen, retry.CircuitState())
+	fake.Advance(time.Millisecond)
+	assert.Equal(t, CircuitHalfOpen, retry.CircuitState())
+	retry.ProcessFrame(frames.NewTextFrame("4"), FrameDirectionDownstream)
+	assert.Equal(t, CircuitClosed, retry.CircuitState())
+	assert.Equal(t, 3, flaky.callCount())
+	assert.Equal(t, uint64(3), retry.Failures())
+}
+
+// failingNext reports an ErrorFrame upstream for each TextFrame it gets.
+type failingNext struct {
+	*FrameProcessor
+}
+
+func (p *failingNext) ProcessFrame(frame frames.Frame, direction FrameDirection) {
+	if _, ok := frame.(*frames.TextFrame); ok {
+		p.PushError(frames.NewErrorFrame(errors.New("downstream failure"), false))
+		return
+	}
+	p.PushFrame(frame, direction)
+}
+
+func TestRetryProcessor_PassthroughErrors(t *testing.T) {
+	flaky := newFlakyProcessor(0)
+	up := NewMockProcessorWithName("up")
+	next := &failingNext{FrameProcessor: NewFrameProcessor("next")}
+	retry := NewRetryProcessor(flaky).WithBackoff(time.Millisecond, time.Millisecond, 2)
+	retry.SetPrev(up)
+	retry.Link(next)
+	next.SetPrev(retry)
+
+	// The error of a later processor goes through the wrapped one during the attempt, it isn't retried.
+	retry.ProcessFrame(frames.NewTextFrame("hi"), FrameDirectionDownstream)
+
+	assert.Equal(t, 1, flaky.callCount())
+	errs := errorFrames(up.GetReceivedDirectionUpstreamFrames())
+	assert.Equal(t, 1, len(errs))
+	assert.EqualError(t, errs[0].Error, "downstream failure")
+	assert.Equal(t, uint64(0), retry.Retries())
+}
+
+// swallowingProcessor drops the upstream ErrorFrames.
+type swallowingProcessor struct {
+	*FrameProcessor
+}
+
+func (p *swallowingProcessor) ProcessFrame(frame frames.Frame, direction FrameDirection) {
+	if _, ok := frame.(*frames.ErrorFrame); ok && direction == FrameDirectionUpstream {
+		return
+	}
+	p.PushFrame(frame, direction)
+}
+
+func TestRetryProcessor_SwallowedPassthroughErrors(t *testing.T) {
+	retry := NewRetryProcessor(&swallowingProcessor{NewFrameProcessor("swallowing")})
+	retry.SetPrev(NewMockProcessorWithName("up"))
+
+	for i := 0; i < 3; i++ {
+		retry.ProcessFrame(frames.NewErrorFrame(errors.New("downstream failure"), false), FrameDirectionUpstream)
+	}
+
+	// The errors dropped by the wrapped processor aren't kept.
+	retry.errMu.Lock()
+	defer retry.errMu.Unlock()
+	assert.Empty(t, retry.passthrough)
+}
+
+func TestRetryProcessor_Backoff(t *testing.T) {
+	retry := NewRetryProcessor(newFlakyProcessor(0)).WithBackoff(10*time.Millisecond, 50*time.Millisecond, 2).WithJitter(0)
+	assert.Equal(t, 10*time.Millisecond, retry.backoff(1))
+	assert.Equal(t, 20*time.Millisecond, retry.backoff(2))
+	assert.Equal(t, 40*time.Millisecond, retry.backoff(3))
+	assert.Equal(t, 50*time.Millisecond, retry.backoff(4))
+	assert.Equal(t, 50*time.Millisecond, retry.backoff(100))
+
+	retry.WithJitter(0.5)
+	for i := 0; i < 100; i++ {
+		backoff := retry.backoff(2)
+		assert.GreaterOrEqual(t, backoff, 10*time.Millisecond)
+		assert.LessOrEqual(t, backoff, 30*time.Millisecond)
+	}
+}
+
+func TestRetryProcessor_CancelAbortsBackoff(t *testing.T) {
+	flaky := newFlakyProcessor(100)
+	_, _, retry := retryChain(flaky)
+	retry.WithBackoff(time.Hour, time.Hour, 2)
+
+	done := make(chan struct{})
+	go func() {
+		retry.ProcessFrame(frames.NewTextFrame("hi"), FrameDirectionDownstream)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool { return flaky.callCount() == 1 }, time.Second, time.Millisecond)
+
+	retry.ProcessFrame(frames.NewCancelFrame(), FrameDirectionDownstream)
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("backoff not aborted")
+	}
+	assert.Equal(t, 1, flaky.callCount())
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/weedge/pipeline-go/pkg/processors/audio"
+)
+
+const (
+	// DefaultToneFrequency is the default frequency of the tone of a ToneTTS.
+	DefaultToneFrequency = 440.0
+	// DefaultToneDurationPerChar is the default duration of the tone of a ToneTTS per character.
+	DefaultToneDurationPerChar = 10 * time.Millisecond
+)
+
+// ToneTTS is a deterministic TTS for tests: it synthesizes a text into a sine
+// tone of durationPerChar per character, in chunks of 10ms.
+type ToneTTS struct {
+	frequency       float64
+	durationPerChar time.Duration
+}
+
+// NewToneTTS creates a new ToneTTS.
+func NewToneTTS() *ToneTTS {
+	return &ToneTTS{
+		frequency:       DefaultToneFrequency,
+		durationPerChar: DefaultToneDurationPerChar,
+	}
+}
+
+// WithFrequency sets the frequency of the tone in Hz.
+func (t *ToneTTS) WithFrequency(frequency float64) *ToneTTS {
+	t.frequency = frequency
+	return t
+}
+
+// WithDurationPerChar sets the duration of the tone per character.
+func (t *ToneTTS) WithDurationPerChar(durationPerChar time.Duration) *ToneTTS {
+	t.durationPerChar = durationPerChar
+	return t
+}
+
+// Synthesize synthesizes the tone of text.
+func (t *ToneTTS) Synthesize(ctx context.Context, text string, sampleRate int, onAudio func(audio []byte)) error {
+	if sampleRate <= 0 {
+		return fmt.Errorf("synthesize: invalid sample rate %d", sampleRate)
+	}
+	total := int(t.durationPerChar * time.Duration(len([]rune(text))) * time.Duration(sampleRate) / time.Second)
+	chunk := max(sampleRate/100, 1)
+	for start := 0; start < total; start += chunk {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		samples := make([]float64, min(chunk, total-start))
+		for i := range samples {
+			samples[i] = 0.5 * math.Sin(2*math.Pi*t.frequency*float64(start+i)/float64(sampleRate))
+		}
+		data, err := audio.EncodeSamples(samples, audio.SampleFormatS16)
+		if err != nil {
+			return err
+		}
+		onAudio(data)
+	}
+	return nil
+}
+
+// EchoSTT is a deterministic STT for tests: it returns its transcripts in
+// turn, then a description of the audio transcribed.
+type EchoSTT struct {
+	lock        sync.Mutex
+	transcripts []string
+}
+
+// NewEchoSTT creates a new EchoSTT returning transcripts.
+func NewEchoSTT(transcripts ...string) *EchoSTT {
+	return &EchoSTT{transcripts: transcripts}
+}
+
+// Transcribe returns the next transcript, else e.g. "1.5s of audio".
+func (e *EchoSTT) Transcribe(ctx context.Context, audio []byte, sampleRate, numChannels int) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if len(e.transcripts) > 0 {
+		text := e.transcripts[0]
+		e.transcripts = e.transcripts[1:]
+		return text, nil
+	}
+	return fmt.Sprintf("%s of audio", durationOf(len(audio), sampleRate, numChannels)), nil
+}
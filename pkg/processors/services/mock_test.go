@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/pipeline"
+	"github.com/weedge/pipeline-go/pkg/pipeline/pipelinetest"
+	"github.com/weedge/pipeline-go/pkg/processors"
+	"github.com/weedge/pipeline-go/pkg/processors/aggregators"
+	"github.com/weedge/pipeline-go/pkg/processors/audio"
+)
+
+func synthesize(t *testing.T, tts TTS, text string) []byte {
+	var out []byte
+	assert.NoError(t, tts.Synthesize(context.Background(), text, 16000, func(audio []byte) { out = append(out, audio...) }))
+	return out
+}
+
+func TestToneTTS(t *testing.T) {
+	tts := NewToneTTS().WithDurationPerChar(50 * time.Millisecond)
+	tone := synthesize(t, tts, "你好")
+	assert.Len(t, tone, bytesOf(100*time.Millisecond, 16000, 1))
+	assert.Equal(t, tone, synthesize(t, tts, "你好"))
+
+	samples, err := audio.DecodeSamples(tone, audio.SampleFormatS16)
+	assert.NoError(t, err)
+	assert.InDelta(t, 0, samples[0], 1e-4)
+	assert.NotEqual(t, tone, synthesize(t, tts.WithFrequency(880), "你好"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, tts.Synthesize(ctx, "Hi", 16000, func([]byte) {}), context.Canceled)
+}
+
+func TestEchoSTT(t *testing.T) {
+	stt := NewEchoSTT("Hello")
+	for _, want := range []string{"Hello", "1.5s of audio"} {
+		text, err := stt.Transcribe(context.Background(), make([]byte, 48000), 16000, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, want, text)
+	}
+}
+
+// echoBot answers each LLMContextFrame with a response repeating the last message.
+type echoBot struct {
+	*processors.FrameProcessor
+}
+
+func (b *echoBot) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	b.FrameProcessor.ProcessFrame(frame, direction)
+	f, ok := frame.(*frames.LLMContextFrame)
+	if !ok {
+		b.PushFrame(frame, direction)
+		return
+	}
+	messages := f.Context.Messages()
+	b.PushFrame(frames.NewLLMFullResponseStartFrame(), direction)
+	b.PushFrame(frames.NewTextFrame("You said: "), direction)
+	b.PushFrame(frames.NewTextFrame(messages[len(messages)-1].Content), direction)
+	b.PushFrame(frames.NewLLMFullResponseEndFrame(), direction)
+}
+
+func TestVoicePipeline(t *testing.T) {
+	user, assistant := aggregators.NewLLMContextAggregatorPair(nil)
+	var send []frames.Frame
+	send = append(send, frames.NewStartInterruptionFrame())
+	send = append(send, repeat(10, audio10ms)...)
+	send = append(send, frames.NewStopInterruptionFrame())
+
+	result := pipelinetest.RunTest(t,
+		[]processors.IFrameProcessor{
+			NewSTTService(NewEchoSTT("What time is it?")),
+			user,
+			&echoBot{processors.NewFrameProcessor("echoBot")},
+			NewTTSService(NewToneTTS()).WithChunkDuration(100 * time.Millisecond),
+			assistant,
+		},
+		send,
+		[]frames.Frame{
+			frames.NewStartInterruptionFrame(),
+			frames.NewStopInterruptionFrame(),
+			frames.NewLLMFullResponseStartFrame(),
+			frames.NewTTSStartedFrame(),
+			frames.NewAudioRawFrame(nil, 16000, 1, 2),
+			frames.NewTTSStoppedFrame(),
+			frames.NewTextFrame("You said: "),
+			frames.NewTTSStartedFrame(),
+			frames.NewAudioRawFrame(nil, 16000, 1, 2),
+			frames.NewAudioRawFrame(nil, 16000, 1, 2),
+			frames.NewTTSStoppedFrame(),
+			frames.NewTextFrame("What time is it?"),
+			frames.NewLLMContextFrame(user.Context()),
+			frames.NewLLMFullResponseEndFrame(),
+		},
+		nil,
+		pipelinetest.WithParams(pipeline.PipelineParams{AllowInterruptions: true}),
+		pipelinetest.IgnoreFields("Audio", "NumFrames"),
+	)
+
+	assert.Equal(t, []int{3200, 3200, 1920}, audioSizes(result.Down))
+	assert.Equal(t, []frames.LLMMessage{
+		{Role: frames.LLMRoleUser, Content: "What time is it?"},
+		{Role: frames.LLMRoleAssistant, Content: "You said: What time is it?"},
+	}, user.Context().Messages())
+}
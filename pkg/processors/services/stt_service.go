@@ -0,0 +1,175 @@
+// Package services holds the base processors of the AI services of a voice
+// pipeline: speech-to-text and text-to-speech. A vendor only implements the
+// STT or TTS interface, the processors handle the frames around it.
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+// STT is a speech-to-text vendor.
+type STT interface {
+	// Transcribe transcribes audio, 16-bit PCM of sampleRate and numChannels.
+	Transcribe(ctx context.Context, audio []byte, sampleRate, numChannels int) (string, error)
+}
+
+const (
+	// DefaultSTTPreroll is the default duration of the audio before the user
+	// started speaking transcribed with the speech.
+	DefaultSTTPreroll = 500 * time.Millisecond
+	// DefaultSTTMaxDuration is the default duration of the speech after which it is transcribed, however long.
+	DefaultSTTMaxDuration = 30 * time.Second
+)
+
+// STTService transcribes the user speech with a STT vendor.
+//
+// The downstream AudioRawFrames are buffered while the user speaks, from a
+// UserStartedSpeakingFrame or StartInterruptionFrame (e.g. of a VADProcessor)
+// to a UserStoppedSpeakingFrame or StopInterruptionFrame, with the preroll
+// before: the speech detected late. The speech is then transcribed and pushed
+// in a TranscriptionFrame before the stop frame, and also once the buffered
+// speech is maxDuration long. The audio isn't pushed downstream, unless
+// WithPassRawAudio. The TTFB and processing time of the transcriptions are
+// measured, and a failed transcription pushes a non-fatal ErrorFrame upstream.
+//
+// The transcription is run on the goroutine pushing the frames.
+type STTService struct {
+	*processors.FrameProcessor
+	stt          STT
+	language     string
+	preroll      time.Duration
+	maxDuration  time.Duration
+	passRawAudio bool
+
+	buffer      []byte
+	sampleRate  int
+	numChannels int
+	speaking    bool
+}
+
+// NewSTTService creates a new STTService transcribing with stt.
+func NewSTTService(stt STT) *STTService {
+	return &STTService{
+		FrameProcessor: processors.NewFrameProcessor("STTService"),
+		stt:            stt,
+		preroll:        DefaultSTTPreroll,
+		maxDuration:    DefaultSTTMaxDuration,
+	}
+}
+
+// WithLanguage sets the language reported in the TranscriptionFrames.
+func (s *STTService) WithLanguage(language string) *STTService {
+	s.language = language
+	return s
+}
+
+// WithPreroll sets the duration of the audio before the user started speaking transcribed with the speech.
+func (s *STTService) WithPreroll(preroll time.Duration) *STTService {
+	s.preroll = preroll
+	return s
+}
+
+// WithMaxDuration sets the duration of the speech after which it is transcribed, 0 for no maximum.
+func (s *STTService) WithMaxDuration(maxDuration time.Duration) *STTService {
+	s.maxDuration = maxDuration
+	return s
+}
+
+// WithPassRawAudio sets whether the AudioRawFrames are pushed downstream too.
+func (s *STTService) WithPassRawAudio(passRawAudio bool) *STTService {
+	s.passRawAudio = passRawAudio
+	return s
+}
+
+// ProcessFrame transcribes the downstream audio of the user speech and passes the other frames through.
+func (s *STTService) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	s.FrameProcessor.ProcessFrame(frame, direction)
+
+	switch f := frame.(type) {
+	case *frames.AudioRawFrame:
+		if direction != processors.FrameDirectionDownstream {
+			s.PushFrame(f, direction)
+			return
+		}
+		s.buffer = append(s.buffer, f.Audio...)
+		s.sampleRate, s.numChannels = f.SampleRate, f.NumChannels
+		if !s.speaking {
+			s.trimPreroll()
+		} else if s.maxDuration > 0 && s.bufferDuration() >= s.maxDuration {
+			s.transcribe()
+		}
+		if s.passRawAudio {
+			s.PushFrame(f, direction)
+		}
+	case *frames.UserStartedSpeakingFrame, *frames.StartInterruptionFrame:
+		s.speaking = true
+		s.PushFrame(frame, direction)
+	case *frames.UserStoppedSpeakingFrame, *frames.StopInterruptionFrame:
+		if s.speaking {
+			s.speaking = false
+			s.transcribe()
+		}
+		s.PushFrame(frame, direction)
+	case *frames.EndFrame, *frames.CancelFrame:
+		s.buffer, s.speaking = nil, false
+		s.PushFrame(frame, direction)
+	default:
+		s.PushFrame(frame, direction)
+	}
+}
+
+// transcribe transcribes the buffered audio, pushes its transcription and empties the buffer.
+func (s *STTService) transcribe() {
+	audio := s.buffer
+	s.buffer = nil
+	if len(audio) == 0 {
+		return
+	}
+
+	s.StartTTFBMetrics()
+	s.StartProcessingMetrics()
+	text, err := s.stt.Transcribe(context.Background(), audio, s.sampleRate, s.numChannels)
+	s.StopTTFBMetrics()
+	s.StopProcessingMetrics()
+	if err != nil {
+		s.PushError(frames.NewErrorFrame(err, false))
+		return
+	}
+	if text != "" {
+		s.PushFrame(frames.NewTranscriptionFrame(text, s.language), processors.FrameDirectionDownstream)
+	}
+}
+
+// trimPreroll drops the buffered audio older than the preroll.
+func (s *STTService) trimPreroll() {
+	keep := bytesOf(s.preroll, s.sampleRate, s.numChannels)
+	if len(s.buffer) > keep {
+		s.buffer = append([]byte(nil), s.buffer[len(s.buffer)-keep:]...)
+	}
+}
+
+func (s *STTService) bufferDuration() time.Duration {
+	return durationOf(len(s.buffer), s.sampleRate, s.numChannels)
+}
+
+// sampleWidth is the width of the 16-bit PCM samples of the services.
+const sampleWidth = 2
+
+// bytesOf returns the number of bytes of d of 16-bit PCM audio, a whole number of samples.
+func bytesOf(d time.Duration, sampleRate, numChannels int) int {
+	frameSize := sampleWidth * max(numChannels, 1)
+	return int(d*time.Duration(sampleRate)/time.Second) * frameSize
+}
+
+// durationOf returns the duration of n bytes of 16-bit PCM audio.
+func durationOf(n, sampleRate, numChannels int) time.Duration {
+	if sampleRate <= 0 {
+		return 0
+	}
+	frameSize := sampleWidth * max(numChannels, 1)
+	return time.Duration(n/frameSize) * time.Second / time.Duration(sampleRate)
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/pipeline/pipelinetest"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+// silence returns an AudioRawFrame of d of 16kHz mono silence.
+func silence(d time.Duration) *frames.AudioRawFrame {
+	return frames.NewAudioRawFrame(make([]byte, bytesOf(d, 16000, 1)), 16000, 1, sampleWidth)
+}
+
+// repeat returns n times frame.
+func repeat(n int, frame func() frames.Frame) []frames.Frame {
+	var out []frames.Frame
+	for i := 0; i < n; i++ {
+		out = append(out, frame())
+	}
+	return out
+}
+
+func audio10ms() frames.Frame { return silence(10 * time.Millisecond) }
+
+func TestSTTService_Speech(t *testing.T) {
+	stt := NewSTTService(NewEchoSTT()).WithLanguage("en").WithPreroll(20 * time.Millisecond)
+	var send []frames.Frame
+	send = append(send, repeat(5, audio10ms)...)
+	send = append(send, frames.NewUserStartedSpeakingFrame())
+	send = append(send, repeat(3, audio10ms)...)
+	send = append(send, frames.NewUserStoppedSpeakingFrame())
+
+	// The speech is transcribed with the preroll, the audio is consumed.
+	pipelinetest.RunTest(t,
+		[]processors.IFrameProcessor{stt},
+		send,
+		[]frames.Frame{
+			frames.NewUserStartedSpeakingFrame(),
+			frames.NewTranscriptionFrame("50ms of audio", "en"),
+			frames.NewUserStoppedSpeakingFrame(),
+		},
+		nil,
+	)
+}
+
+func TestSTTService_MaxDuration(t *testing.T) {
+	stt := NewSTTService(NewEchoSTT("one", "two", "three")).
+		WithPreroll(0).WithMaxDuration(20 * time.Millisecond).WithPassRawAudio(true)
+	var send []frames.Frame
+	send = append(send, frames.NewStartInterruptionFrame())
+	send = append(send, repeat(5, audio10ms)...)
+	send = append(send, frames.NewStopInterruptionFrame())
+
+	pipelinetest.RunTest(t,
+		[]processors.IFrameProcessor{stt},
+		send,
+		[]frames.Frame{
+			frames.NewStartInterruptionFrame(),
+			silence(10 * time.Millisecond),
+			frames.NewTranscriptionFrame("one", ""),
+			silence(10 * time.Millisecond),
+			silence(10 * time.Millisecond),
+			frames.NewTranscriptionFrame("two", ""),
+			silence(10 * time.Millisecond),
+			silence(10 * time.Millisecond),
+			frames.NewTranscriptionFrame("three", ""),
+			frames.NewStopInterruptionFrame(),
+		},
+		nil,
+	)
+}
+
+type sttFunc func(ctx context.Context, audio []byte, sampleRate, numChannels int) (string, error)
+
+func (f sttFunc) Transcribe(ctx context.Context, audio []byte, sampleRate, numChannels int) (string, error) {
+	return f(ctx, audio, sampleRate, numChannels)
+}
+
+func TestSTTService_Error(t *testing.T) {
+	stt := NewSTTService(sttFunc(func(context.Context, []byte, int, int) (string, error) {
+		return "", errors.New("transcribe: quota exceeded")
+	}))
+
+	pipelinetest.RunTest(t,
+		[]processors.IFrameProcessor{stt},
+		[]frames.Frame{frames.NewUserStartedSpeakingFrame(), audio10ms(), frames.NewUserStoppedSpeakingFrame()},
+		[]frames.Frame{frames.NewUserStartedSpeakingFrame(), frames.NewUserStoppedSpeakingFrame()},
+		[]frames.Frame{frames.NewErrorFrame(errors.New("transcribe: quota exceeded"), false)},
+	)
+}
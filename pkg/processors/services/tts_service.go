@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+// TTS is a text-to-speech vendor.
+type TTS interface {
+	// Synthesize synthesizes text into 16-bit mono PCM audio of sampleRate,
+	// passed to onAudio in chunks of any size, until done or ctx is done.
+	Synthesize(ctx context.Context, text string, sampleRate int, onAudio func(audio []byte)) error
+}
+
+const (
+	// DefaultTTSSampleRate is the sample rate of the audio of a TTSService when
+	// neither set nor in the StartFrame.
+	DefaultTTSSampleRate = 16000
+	// DefaultTTSChunkDuration is the default duration of the AudioRawFrames of a TTSService.
+	DefaultTTSChunkDuration = 20 * time.Millisecond
+)
+
+// TTSService synthesizes the downstream TextFrames with a TTS vendor, e.g.
+// after a TextChunkAggregator pushing whole sentences.
+//
+// A text is pushed as a TTSStartedFrame, the audio in AudioRawFrames of
+// chunkDuration (the last one shorter), a TTSStoppedFrame and the TextFrame
+// itself, unless WithPassText(false). The audio is 16-bit mono PCM of the
+// sample rate set, else of the StartFrame AudioOutSampleRate, else of
+// DefaultTTSSampleRate. The TTFB until the first audio and the processing time
+// are measured, the characters synthesized are reported as usage metrics, and a
+// failed synthesis pushes a non-fatal ErrorFrame upstream.
+//
+// The texts are synthesized in the background by the AsyncFrameProcessor
+// worker, in order with the other frames queued downstream but the
+// SystemFrames, pushed right away: an EndFrame is pushed once the texts are all
+// done, and an allowed StartInterruptionFrame or a CancelFrame aborts the
+// synthesis and drops the frames queued. A panic of the TTS vendor is handled
+// by the panic policy.
+type TTSService struct {
+	*processors.AsyncFrameProcessor
+	tts           TTS
+	sampleRate    int
+	chunkDuration time.Duration
+	passText      bool
+}
+
+// NewTTSService creates a new TTSService synthesizing with tts.
+func NewTTSService(tts TTS) *TTSService {
+	s := &TTSService{
+		AsyncFrameProcessor: processors.NewAsyncFrameProcessor("TTSService"),
+		tts:                 tts,
+		chunkDuration:       DefaultTTSChunkDuration,
+		passText:            true,
+	}
+	s.WithQueueHandler(s.handleQueued)
+	return s
+}
+
+// WithSampleRate sets the sample rate of the audio, else the StartFrame AudioOutSampleRate is used.
+func (s *TTSService) WithSampleRate(sampleRate int) *TTSService {
+	s.sampleRate = sampleRate
+	return s
+}
+
+// WithChunkDuration sets the duration of the AudioRawFrames.
+func (s *TTSService) WithChunkDuration(chunkDuration time.Duration) *TTSService {
+	s.chunkDuration = chunkDuration
+	return s
+}
+
+// WithPassText sets whether the TextFrames are pushed downstream after their audio.
+func (s *TTSService) WithPassText(passText bool) *TTSService {
+	s.passText = passText
+	return s
+}
+
+// ProcessFrame queues the downstream TextFrames to be synthesized, and the other
+// frames downstream to be pushed after the texts before them, but the SystemFrames.
+func (s *TTSService) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	if direction != processors.FrameDirectionDownstream {
+		s.FrameProcessor.ProcessFrame(frame, direction)
+		s.PushFrame(frame, direction)
+		return
+	}
+	if s.ShouldInterrupt(frame) {
+		// The synthesis is stopped before the metrics are, it measures them.
+		s.HandleInterruptions(frame)
+		s.FrameProcessor.ProcessFrame(frame, direction)
+		return
+	}
+
+	switch f := frame.(type) {
+	case *frames.StartFrame:
+		if s.sampleRate <= 0 {
+			s.sampleRate = f.AudioOutSampleRate
+		}
+		if s.sampleRate <= 0 {
+			s.sampleRate = DefaultTTSSampleRate
+		}
+	case *frames.EndFrame:
+		// The EndFrame is queued after the texts, the queue is drained then.
+		s.QueueFrame(f, direction)
+	}
+	s.AsyncFrameProcessor.ProcessFrame(frame, direction)
+
+	switch frame.(type) {
+	case *frames.EndFrame, *frames.StartInterruptionFrame, frames.StartInterruptionFrame:
+		// Queued above, or pushed by the AsyncFrameProcessor.
+	default:
+		// The CancelFrame is pushed once the queued frames are dropped.
+		if frames.ExtractSystemFrame(frame) != nil {
+			s.PushFrame(frame, direction)
+			return
+		}
+		s.QueueFrame(frame, direction)
+	}
+}
+
+// handleQueued synthesizes the TextFrames queued, and pushes the other frames.
+func (s *TTSService) handleQueued(ctx context.Context, frame frames.Frame, direction processors.FrameDirection) {
+	if f, ok := frame.(*frames.TextFrame); ok {
+		s.synthesize(ctx, f)
+		return
+	}
+	s.PushFrame(frame, direction)
+}
+
+// synthesize synthesizes the text of frame and pushes its audio, until done or ctx is canceled.
+func (s *TTSService) synthesize(ctx context.Context, frame *frames.TextFrame) {
+	if strings.TrimSpace(frame.Text) == "" {
+		if s.passText {
+			s.PushFrame(frame, processors.FrameDirectionDownstream)
+		}
+		return
+	}
+
+	// The metrics are pushed right away, by the worker: queued, they would
+	// follow the frames queued after the text.
+	s.StartTTFBMetrics()
+	s.StartProcessingMetrics()
+	s.FrameProcessor.StartTTSUsageMetrics(frame.Text)
+	s.PushFrame(frames.NewTTSStartedFrame(), processors.FrameDirectionDownstream)
+	chunkSize := max(bytesOf(s.chunkDuration, s.sampleRate, 1), sampleWidth)
+	var buffer []byte
+	err := s.tts.Synthesize(ctx, frame.Text, s.sampleRate, func(audio []byte) {
+		if ctx.Err() != nil || len(audio) == 0 {
+			return
+		}
+		s.FrameProcessor.StopTTFBMetrics()
+		buffer = append(buffer, audio...)
+		for len(buffer) >= chunkSize {
+			s.pushAudio(buffer[:chunkSize])
+			buffer = buffer[chunkSize:]
+		}
+	})
+	if ctx.Err() != nil {
+		// Aborted, the rest of the text is dropped.
+		return
+	}
+	if err != nil {
+		s.PushError(frames.NewErrorFrame(err, false))
+	}
+	if len(buffer) >= sampleWidth {
+		s.pushAudio(buffer[:len(buffer)/sampleWidth*sampleWidth])
+	}
+	s.FrameProcessor.StopAllMetrics()
+	s.PushFrame(frames.NewTTSStoppedFrame(), processors.FrameDirectionDownstream)
+	if s.passText {
+		s.PushFrame(frame, processors.FrameDirectionDownstream)
+	}
+}
+
+func (s *TTSService) pushAudio(audio []byte) {
+	audio = append([]byte(nil), audio...)
+	s.PushFrame(frames.NewAudioRawFrame(audio, s.sampleRate, 1, sampleWidth), processors.FrameDirectionDownstream)
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weedge/pipeline-go/pkg/clock"
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/pipeline"
+	"github.com/weedge/pipeline-go/pkg/pipeline/pipelinetest"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+// audioSizes returns the sizes of the AudioRawFrames of received.
+func audioSizes(received []pipelinetest.ReceivedFrame) []int {
+	var sizes []int
+	for _, r := range received {
+		if f, ok := r.Frame.(*frames.AudioRawFrame); ok {
+			sizes = append(sizes, len(f.Audio))
+		}
+	}
+	return sizes
+}
+
+func TestTTSService(t *testing.T) {
+	// "Hi." is 30ms of tone: a 20ms chunk and the 10ms rest.
+	result := pipelinetest.RunTest(t,
+		[]processors.IFrameProcessor{NewTTSService(NewToneTTS())},
+		[]frames.Frame{frames.NewTextFrame("Hi."), frames.NewTextFrame(" "), frames.NewLLMFullResponseEndFrame()},
+		[]frames.Frame{
+			frames.NewTTSStartedFrame(),
+			frames.NewAudioRawFrame(nil, 16000, 1, 2),
+			frames.NewAudioRawFrame(nil, 16000, 1, 2),
+			frames.NewTTSStoppedFrame(),
+			frames.NewTextFrame("Hi."),
+			frames.NewTextFrame(" "),
+			frames.NewLLMFullResponseEndFrame(),
+		},
+		nil,
+		pipelinetest.IgnoreFields("Audio", "NumFrames"),
+	)
+	assert.Equal(t, []int{640, 320}, audioSizes(result.Down))
+}
+
+func TestTTSService_Metrics(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	data := frames.MetricsData{Processor: "TTSService", Timestamp: fake.Now()}
+
+	pipelinetest.RunTest(t,
+		[]processors.IFrameProcessor{NewTTSService(NewToneTTS()).WithSampleRate(8000).WithPassText(false)},
+		[]frames.Frame{frames.NewTextFrame("Hi")},
+		[]frames.Frame{
+			frames.NewMetricsFrameWithCharacters(frames.TTSUsageMetricsData{MetricsData: data, Value: 2}),
+			frames.NewTTSStartedFrame(),
+			frames.NewMetricsFrameWithTTFB(frames.TTFBMetricsData{MetricsData: data}),
+			frames.NewAudioRawFrame(make([]byte, 320), 8000, 1, 2),
+			frames.NewMetricsFrameWithProcessing(frames.ProcessingMetricsData{MetricsData: data}),
+			frames.NewTTSStoppedFrame(),
+		},
+		nil,
+		pipelinetest.WithParams(pipeline.PipelineParams{EnableMetrics: true, EnableUsageMetrics: true}),
+		pipelinetest.WithClock(fake),
+		pipelinetest.IgnoreFields("Audio"),
+	)
+}
+
+type ttsFunc func(ctx context.Context, text string, sampleRate int, onAudio func([]byte)) error
+
+func (f ttsFunc) Synthesize(ctx context.Context, text string, sampleRate int, onAudio func([]byte)) error {
+	return f(ctx, text, sampleRate, onAudio)
+}
+
+func TestTTSService_Error(t *testing.T) {
+	tts := ttsFunc(func(ctx context.Context, text string, sampleRate int, onAudio func([]byte)) error {
+		onAudio(make([]byte, 100))
+		return errors.New("synthesize: voice not found")
+	})
+
+	// The audio synthesized before the error is pushed.
+	pipelinetest.RunTest(t,
+		[]processors.IFrameProcessor{NewTTSService(tts)},
+		[]frames.Frame{frames.NewTextFrame("Hi")},
+		[]frames.Frame{
+			frames.NewTTSStartedFrame(),
+			frames.NewAudioRawFrame(make([]byte, 100), 16000, 1, 2),
+			frames.NewTTSStoppedFrame(),
+			frames.NewTextFrame("Hi"),
+		},
+		[]frames.Frame{frames.NewErrorFrame(errors.New("synthesize: voice not found"), false)},
+	)
+}
+
+func TestTTSService_Panic(t *testing.T) {
+	tone := NewToneTTS()
+	tts := ttsFunc(func(ctx context.Context, text string, sampleRate int, onAudio func([]byte)) error {
+		if text == "boom" {
+			panic("vendor bug")
+		}
+		return tone.Synthesize(ctx, text, sampleRate, onAudio)
+	})
+	boom := frames.NewTextFrame("boom")
+
+	// The panic of the vendor is an ErrorFrame, the next texts are synthesized.
+	result := pipelinetest.RunTest(t,
+		[]processors.IFrameProcessor{NewTTSService(tts)},
+		[]frames.Frame{boom, frames.NewTextFrame("Hi")},
+		[]frames.Frame{
+			frames.NewTTSStartedFrame(),
+			frames.NewTTSStartedFrame(),
+			frames.NewAudioRawFrame(nil, 16000, 1, 2),
+			frames.NewTTSStoppedFrame(),
+			frames.NewTextFrame("Hi"),
+		},
+		[]frames.Frame{frames.NewErrorFrame(nil, false)},
+		pipelinetest.IgnoreFields("Audio", "NumFrames", "Error"),
+	)
+	if assert.Len(t, result.Up, 1) {
+		var panicErr *processors.PanicError
+		assert.True(t, errors.As(result.Up[0].Frame.(*frames.ErrorFrame).Error, &panicErr))
+		assert.Equal(t, "vendor bug", panicErr.Value)
+		assert.Equal(t, boom, panicErr.Frame)
+	}
+}
+
+// frameRecorder records the frames pushed to it from any goroutine.
+type frameRecorder struct {
+	*processors.FrameProcessor
+	lock     sync.Mutex
+	received []frames.Frame
+}
+
+func newFrameRecorder() *frameRecorder {
+	return &frameRecorder{FrameProcessor: processors.NewFrameProcessor("recorder")}
+}
+
+func (r *frameRecorder) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.received = append(r.received, frame)
+}
+
+func (r *frameRecorder) names() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	var names []string
+	for _, f := range r.received {
+		names = append(names, fmt.Sprintf("%T", f))
+	}
+	return names
+}
+
+func TestTTSService_Interruption(t *testing.T) {
+	synthesizing := make(chan string, 3)
+	tts := ttsFunc(func(ctx context.Context, text string, sampleRate int, onAudio func([]byte)) error {
+		synthesizing <- text
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	service := NewTTSService(tts)
+	recorder := newFrameRecorder()
+	service.Link(recorder)
+	start := frames.NewStartFrame()
+	start.AllowInterruptions = true
+
+	service.ProcessFrame(start, processors.FrameDirectionDownstream)
+	service.ProcessFrame(frames.NewTextFrame("Once upon a time"), processors.FrameDirectionDownstream)
+	service.ProcessFrame(frames.NewTextFrame("there was"), processors.FrameDirectionDownstream)
+	service.ProcessFrame(frames.NewLLMFullResponseEndFrame(), processors.FrameDirectionDownstream)
+	assert.Equal(t, "Once upon a time", <-synthesizing)
+
+	// The synthesis is aborted and the frames queued after it are dropped.
+	service.ProcessFrame(frames.NewStartInterruptionFrame(), processors.FrameDirectionDownstream)
+	assert.Equal(t, []string{
+		"*frames.StartFrame",
+		"*frames.TTSStartedFrame",
+		"*frames.StartInterruptionFrame",
+	}, recorder.names())
+
+	// The next response is synthesized.
+	service.ProcessFrame(frames.NewTextFrame("Yes?"), processors.FrameDirectionDownstream)
+	assert.Equal(t, "Yes?", <-synthesizing)
+	service.ProcessFrame(frames.NewCancelFrame(), processors.FrameDirectionDownstream)
+	assert.Len(t, synthesizing, 0)
+	assert.Equal(t, "*frames.CancelFrame", recorder.names()[len(recorder.names())-1])
+}
@@ -0,0 +1,156 @@
+package processors
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Latency histogram buckets: the first one is up to 10µs, each next one doubles
+// its upper bound up to ~84s, the last one counts the latencies above.
+const (
+	latencyBuckets     = 24
+	latencyFirstBucket = 10 * time.Microsecond
+)
+
+// DirectionCounts counts frames per direction.
+type DirectionCounts struct {
+	Downstream uint64
+	Upstream   uint64
+}
+
+// LatencyStats are the processing latencies of a processor: how long its
+// ProcessFrame took, including the synchronous pushes to its neighbours.
+// Percentiles are estimated from a histogram with buckets doubling from 10µs.
+type LatencyStats struct {
+	Count uint64
+	P50   time.Duration
+	P95   time.Duration
+	P99   time.Duration
+
+	buckets [latencyBuckets + 1]uint64
+}
+
+// ProcessorStats is a snapshot of the statistics of a processor, see FrameProcessor.Stats.
+type ProcessorStats struct {
+	Name string
+	// FramesIn are the frames pushed to the processor by its neighbours.
+	FramesIn DirectionCounts
+	// FramesOut are the frames the processor pushed to its neighbours.
+	FramesOut DirectionCounts
+	// Dropped are the frames the processor dropped instead of pushing them, see FrameDropEvent.
+	Dropped uint64
+	// Errors are the ErrorFrames the processor pushed with PushError, panics included.
+	Errors uint64
+	// Panics are the panics recovered from the processor's ProcessFrame.
+	Panics uint64
+	// LastFrameTime is when the processor last got or pushed a frame, zero if never.
+	LastFrameTime time.Time
+	Latency       LatencyStats
+}
+
+// Merge adds the statistics of other to s, e.g. to aggregate the statistics of
+// the processors of a pipeline. The name of s is kept.
+func (s *ProcessorStats) Merge(other ProcessorStats) {
+	s.FramesIn.Downstream += other.FramesIn.Downstream
+	s.FramesIn.Upstream += other.FramesIn.Upstream
+	s.FramesOut.Downstream += other.FramesOut.Downstream
+	s.FramesOut.Upstream += other.FramesOut.Upstream
+	s.Dropped += other.Dropped
+	s.Errors += other.Errors
+	s.Panics += other.Panics
+	if other.LastFrameTime.After(s.LastFrameTime) {
+		s.LastFrameTime = other.LastFrameTime
+	}
+	for i, count := range other.Latency.buckets {
+		s.Latency.buckets[i] += count
+	}
+	s.Latency.computePercentiles()
+}
+
+func (l *LatencyStats) computePercentiles() {
+	l.Count = 0
+	for _, count := range l.buckets {
+		l.Count += count
+	}
+	l.P50 = l.percentile(0.50)
+	l.P95 = l.percentile(0.95)
+	l.P99 = l.percentile(0.99)
+}
+
+// percentile interpolates the q percentile within the bucket it falls in.
+func (l *LatencyStats) percentile(q float64) time.Duration {
+	if l.Count == 0 {
+		return 0
+	}
+	rank := q * float64(l.Count)
+	var cumulative uint64
+	lower, upper := time.Duration(0), latencyFirstBucket
+	for i, count := range l.buckets {
+		if count > 0 && float64(cumulative+count) >= rank {
+			if i == latencyBuckets {
+				// Above the last bound, the best estimate is the bound.
+				return lower
+			}
+			fraction := (rank - float64(cumulative)) / float64(count)
+			return lower + time.Duration(fraction*float64(upper-lower))
+		}
+		cumulative += count
+		lower, upper = upper, upper*2
+	}
+	return lower
+}
+
+// processorStats are the counters of a processor, updated concurrently.
+type processorStats struct {
+	in        [2]atomic.Uint64 // indexed by FrameDirection
+	out       [2]atomic.Uint64
+	dropped   atomic.Uint64
+	errors    atomic.Uint64
+	lastFrame atomic.Int64 // unix nanoseconds
+	latency   [latencyBuckets + 1]atomic.Uint64
+}
+
+func (s *processorStats) countIn(direction FrameDirection, now time.Time) {
+	if direction == FrameDirectionUpstream || direction == FrameDirectionDownstream {
+		s.in[direction].Add(1)
+	}
+	s.lastFrame.Store(now.UnixNano())
+}
+
+func (s *processorStats) countOut(direction FrameDirection, now time.Time) {
+	if direction == FrameDirectionUpstream || direction == FrameDirectionDownstream {
+		s.out[direction].Add(1)
+	}
+	s.lastFrame.Store(now.UnixNano())
+}
+
+func (s *processorStats) observeLatency(latency time.Duration) {
+	bucket, bound := 0, latencyFirstBucket
+	for bucket < latencyBuckets && latency > bound {
+		bucket++
+		bound *= 2
+	}
+	s.latency[bucket].Add(1)
+}
+
+// Stats returns a snapshot of the statistics of the processor. They are kept
+// as the frames are pushed, whether metrics are enabled or not.
+func (p *FrameProcessor) Stats() ProcessorStats {
+	s := &p.stats
+	stats := ProcessorStats{
+		Name:      p.name,
+		FramesIn:  DirectionCounts{Downstream: s.in[FrameDirectionDownstream].Load(), Upstream: s.in[FrameDirectionUpstream].Load()},
+		FramesOut: DirectionCounts{Downstream: s.out[FrameDirectionDownstream].Load(), Upstream: s.out[FrameDirectionUpstream].Load()},
+		Dropped:   s.dropped.Load(),
+		Errors:    s.errors.Load(),
+		Panics:    p.panics.Load(),
+	}
+	if last := s.lastFrame.Load(); last != 0 {
+		stats.LastFrameTime = time.Unix(0, last)
+	}
+	for i := range s.latency {
+		stats.Latency.buckets[i] = s.latency[i].Load()
+	}
+	stats.Latency.computePercentiles()
+	return stats
+}
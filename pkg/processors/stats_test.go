@@ -0,0 +1,82 @@
+package processors
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weedge/pipeline-go/pkg/frames"
+)
+
+func TestFrameProcessorStats(t *testing.T) {
+	up, pusher, panicker := panicChain(PanicPolicyErrorFrame)
+	assert.True(t, pusher.Stats().LastFrameTime.IsZero())
+
+	before := time.Now()
+	pusher.PushFrame(frames.NewStartFrame(), FrameDirectionDownstream)
+	pusher.PushFrame(frames.NewTextFrame("hi"), FrameDirectionDownstream)
+	pusher.PushFrame(frames.NewTextFrame("hi"), FrameDirectionUpstream)
+
+	stats := pusher.Stats()
+	assert.Equal(t, "pusher", stats.Name)
+	assert.Equal(t, DirectionCounts{Downstream: 2, Upstream: 2}, stats.FramesOut) // with the panic ErrorFrame
+	assert.Equal(t, DirectionCounts{Upstream: 1}, stats.FramesIn)
+	assert.False(t, stats.LastFrameTime.Before(before))
+
+	stats = panicker.Stats()
+	assert.Equal(t, DirectionCounts{Downstream: 2}, stats.FramesIn)
+	assert.Equal(t, DirectionCounts{Upstream: 1}, stats.FramesOut)
+	assert.Equal(t, uint64(1), stats.Errors)
+	assert.Equal(t, uint64(1), stats.Panics)
+	assert.Equal(t, uint64(2), stats.Latency.Count)
+
+	assert.Equal(t, DirectionCounts{Upstream: 2}, up.Stats().FramesIn)
+	assert.Equal(t, uint64(2), up.Stats().Latency.Count)
+}
+
+func TestFrameProcessorStats_Dropped(t *testing.T) {
+	async := NewAsyncFrameProcessorWithPushQueueSize("async", 1, 1)
+	async.stopPushTask() // nothing takes the frames out of the queue of 1
+
+	async.QueueFrame(frames.NewTextFrame("queued"), FrameDirectionDownstream)
+	async.QueueFrame(frames.NewTextFrame("dropped"), FrameDirectionDownstream)
+
+	assert.Equal(t, uint64(1), async.Stats().Dropped)
+}
+
+func TestLatencyStats(t *testing.T) {
+	p := &FrameProcessor{}
+	for i := 0; i < 90; i++ {
+		p.stats.observeLatency(5 * time.Microsecond) // first bucket, up to 10µs
+	}
+	for i := 0; i < 10; i++ {
+		p.stats.observeLatency(30 * time.Millisecond) // (20.48ms, 40.96ms] bucket
+	}
+
+	latency := p.Stats().Latency
+	assert.Equal(t, uint64(100), latency.Count)
+	assert.InDelta(t, 50.0/90*float64(10*time.Microsecond), float64(latency.P50), 1) // interpolated in the first bucket
+	assert.True(t, latency.P95 > 20*time.Millisecond && latency.P95 <= 41*time.Millisecond, latency.P95)
+	assert.True(t, latency.P99 > latency.P95 && latency.P99 <= 41*time.Millisecond, latency.P99)
+
+	// Latencies above the last bucket are estimated at its bound.
+	p.stats.latency[latencyBuckets].Store(1000)
+	assert.Equal(t, latencyFirstBucket<<(latencyBuckets-1), p.Stats().Latency.P99)
+
+	// Merged stats recompute the percentiles over both histograms.
+	merged := ProcessorStats{Name: "merged"}
+	merged.Merge(latencyStatsOf(5*time.Microsecond, 10))
+	merged.Merge(latencyStatsOf(30*time.Millisecond, 10))
+	assert.Equal(t, "merged", merged.Name)
+	assert.Equal(t, uint64(20), merged.Latency.Count)
+	assert.True(t, merged.Latency.P50 <= 10*time.Microsecond, merged.Latency.P50)
+	assert.True(t, merged.Latency.P95 > 20*time.Millisecond, merged.Latency.P95)
+}
+
+func latencyStatsOf(latency time.Duration, count int) ProcessorStats {
+	p := &FrameProcessor{}
+	for i := 0; i < count; i++ {
+		p.stats.observeLatency(latency)
+	}
+	return p.Stats()
+}
@@ -0,0 +1,300 @@
+package vision
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/weedge/pipeline-go/pkg/clock"
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/logger"
+	"github.com/weedge/pipeline-go/pkg/notifiers"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+// diffThumbnailSize is the grayscale thumbnail frames are compared on in SampleModeDiff.
+const diffThumbnailSize = 32
+
+// SampleMode selects how ImageFrameSampler picks the frames it keeps.
+type SampleMode int
+
+const (
+	// SampleModeInterval keeps the first frame after each interval and drops the rest.
+	SampleModeInterval SampleMode = iota
+	// SampleModeLatest holds the most recent frame and releases it when the notifier
+	// fires, or on every interval tick when no notifier is set.
+	SampleModeLatest
+	// SampleModeDiff keeps frames whose mean pixel difference from the last kept
+	// frame exceeds the threshold.
+	SampleModeDiff
+)
+
+// String returns the string representation of SampleMode
+func (m SampleMode) String() string {
+	switch m {
+	case SampleModeInterval:
+		return "Interval"
+	case SampleModeLatest:
+		return "Latest"
+	case SampleModeDiff:
+		return "Diff"
+	default:
+		return "Unknown"
+	}
+}
+
+// ImageFrameSampler rate limits downstream ImageRawFrame streams (e.g. a 30fps
+// camera feeding a vision model). Other frames pass through untouched.
+// An allowed StartInterruptionFrame drops the held frame and resets sampling;
+// an EndFrame releases the held frame and a CancelFrame drops it.
+type ImageFrameSampler struct {
+	*processors.FrameProcessor
+	mode      SampleMode
+	interval  time.Duration
+	notifier  notifiers.Notifier
+	maxAge    time.Duration
+	threshold float64
+
+	lock       sync.Mutex
+	lastKept   time.Time
+	held       *frames.ImageRawFrame
+	heldAt     time.Time
+	lastThumb  []uint8
+	once       sync.Once
+	stop       chan struct{}
+	stopOnce   sync.Once
+	kept       atomic.Uint64
+	dropped    atomic.Uint64
+	listenerWg sync.WaitGroup
+}
+
+func newImageFrameSampler(mode SampleMode) *ImageFrameSampler {
+	return &ImageFrameSampler{
+		FrameProcessor: processors.NewFrameProcessor("ImageFrameSampler"),
+		mode:           mode,
+		stop:           make(chan struct{}),
+	}
+}
+
+// NewIntervalFrameSampler keeps at most one frame per interval.
+func NewIntervalFrameSampler(interval time.Duration) *ImageFrameSampler {
+	p := newImageFrameSampler(SampleModeInterval)
+	p.interval = interval
+	return p
+}
+
+// NewLatestFrameSampler holds the latest frame and releases it each time notifier fires.
+func NewLatestFrameSampler(notifier notifiers.Notifier) *ImageFrameSampler {
+	p := newImageFrameSampler(SampleModeLatest)
+	p.notifier = notifier
+	return p
+}
+
+// NewTickerFrameSampler holds the latest frame and releases it every interval.
+func NewTickerFrameSampler(interval time.Duration) *ImageFrameSampler {
+	p := newImageFrameSampler(SampleModeLatest)
+	p.interval = interval
+	return p
+}
+
+// NewDiffFrameSampler keeps frames whose mean absolute pixel difference (0-1)
+// from the previously kept frame is above threshold.
+func NewDiffFrameSampler(threshold float64) *ImageFrameSampler {
+	p := newImageFrameSampler(SampleModeDiff)
+	p.threshold = threshold
+	return p
+}
+
+// WithMaxAge drops a held frame that is older than maxAge when it is released (SampleModeLatest).
+func (p *ImageFrameSampler) WithMaxAge(maxAge time.Duration) *ImageFrameSampler {
+	p.maxAge = maxAge
+	return p
+}
+
+// Mode returns the sampling mode.
+func (p *ImageFrameSampler) Mode() SampleMode {
+	return p.mode
+}
+
+// Kept returns how many image frames were pushed downstream.
+func (p *ImageFrameSampler) Kept() uint64 {
+	return p.kept.Load()
+}
+
+// Dropped returns how many image frames were dropped.
+func (p *ImageFrameSampler) Dropped() uint64 {
+	return p.dropped.Load()
+}
+
+// ProcessFrame samples downstream image frames and passes other frames through.
+func (p *ImageFrameSampler) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	p.FrameProcessor.ProcessFrame(frame, direction)
+
+	if p.mode == SampleModeLatest {
+		p.once.Do(p.startReleaseListener)
+	}
+	if p.ShouldInterrupt(frame) {
+		p.reset()
+	}
+
+	switch f := frame.(type) {
+	case *frames.ImageRawFrame:
+		if direction != processors.FrameDirectionDownstream {
+			p.PushFrame(f, direction)
+			return
+		}
+		p.sample(f)
+	case *frames.EndFrame, *frames.CancelFrame:
+		// The held frame is pushed before the end, and dropped on a cancel.
+		if _, ok := frame.(*frames.EndFrame); ok {
+			p.release()
+		} else {
+			p.reset()
+		}
+		p.stopReleaseListener()
+		logger.Infof("%s(%s) kept %d image frames, dropped %d", p.Name(), p.mode, p.Kept(), p.Dropped())
+		p.PushFrame(frame, direction)
+	default:
+		p.PushFrame(frame, direction)
+	}
+}
+
+func (p *ImageFrameSampler) sample(frame *frames.ImageRawFrame) {
+	now := p.Clock().Now()
+	switch p.mode {
+	case SampleModeInterval:
+		p.lock.Lock()
+		keep := p.lastKept.IsZero() || now.Sub(p.lastKept) >= p.interval
+		if keep {
+			p.lastKept = now
+		}
+		p.lock.Unlock()
+		p.keepOrDrop(frame, keep)
+	case SampleModeLatest:
+		p.lock.Lock()
+		if p.held != nil {
+			p.dropped.Add(1)
+		}
+		p.held, p.heldAt = frame, now
+		p.lock.Unlock()
+	case SampleModeDiff:
+		thumb, err := thumbnail(frame)
+		if err != nil {
+			// Can't compare undecodable images, let them through.
+			logger.Warnf("%s can't decode %s: %v", p.Name(), frame, err)
+			p.keepOrDrop(frame, true)
+			return
+		}
+		p.lock.Lock()
+		keep := p.lastThumb == nil || meanAbsDiff(p.lastThumb, thumb) > p.threshold
+		if keep {
+			p.lastThumb = thumb
+		}
+		p.lock.Unlock()
+		p.keepOrDrop(frame, keep)
+	}
+}
+
+// reset drops the held frame and forgets the last kept frame.
+func (p *ImageFrameSampler) reset() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.held != nil {
+		p.dropped.Add(1)
+		p.held = nil
+	}
+	p.lastKept = time.Time{}
+	p.lastThumb = nil
+}
+
+func (p *ImageFrameSampler) keepOrDrop(frame *frames.ImageRawFrame, keep bool) {
+	if !keep {
+		p.dropped.Add(1)
+		return
+	}
+	p.kept.Add(1)
+	p.PushFrame(frame, processors.FrameDirectionDownstream)
+}
+
+// release pushes the held frame, if any and not too old.
+func (p *ImageFrameSampler) release() {
+	p.lock.Lock()
+	frame, heldAt := p.held, p.heldAt
+	p.held = nil
+	p.lock.Unlock()
+	if frame == nil {
+		return
+	}
+	p.keepOrDrop(frame, p.maxAge <= 0 || p.Clock().Since(heldAt) <= p.maxAge)
+}
+
+func (p *ImageFrameSampler) startReleaseListener() {
+	var tick <-chan time.Time
+	var notify <-chan struct{}
+	var ticker clock.Ticker
+	if p.notifier != nil {
+		notify = p.notifier.Wait()
+	} else if p.interval > 0 {
+		ticker = p.Clock().NewTicker(p.interval)
+		tick = ticker.C()
+	} else {
+		return
+	}
+
+	p.listenerWg.Add(1)
+	go func() {
+		defer p.listenerWg.Done()
+		if ticker != nil {
+			defer ticker.Stop()
+		}
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-notify:
+				p.release()
+			case <-tick:
+				p.release()
+			}
+		}
+	}()
+}
+
+func (p *ImageFrameSampler) stopReleaseListener() {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+	})
+	p.listenerWg.Wait()
+}
+
+// Cleanup stops the release listener.
+func (p *ImageFrameSampler) Cleanup() {
+	p.stopReleaseListener()
+}
+
+// thumbnail decodes frame into a small grayscale image for cheap comparisons.
+func thumbnail(frame *frames.ImageRawFrame) ([]uint8, error) {
+	img, err := DecodeImage(frame)
+	if err != nil {
+		return nil, err
+	}
+	small := ResizeImage(img, frames.ImageSize{Width: diffThumbnailSize, Height: diffThumbnailSize}, ResizeStretch)
+	return toGray(small).Pix, nil
+}
+
+// meanAbsDiff returns the mean absolute difference of two equally sized gray images in [0, 1].
+func meanAbsDiff(a, b []uint8) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return math.Inf(1)
+	}
+	var sum int
+	for i := range a {
+		d := int(a[i]) - int(b[i])
+		if d < 0 {
+			d = -d
+		}
+		sum += d
+	}
+	return float64(sum) / float64(len(a)) / 255
+}
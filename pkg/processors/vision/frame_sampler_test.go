@@ -0,0 +1,184 @@
+package vision
+
+import (
+	"image"
+	"image/color"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/notifiers"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+// syncCollector records downstream image frames from any goroutine.
+type syncCollector struct {
+	*processors.FrameProcessor
+	mu     sync.Mutex
+	images []*frames.ImageRawFrame
+}
+
+func newSyncCollector() *syncCollector {
+	return &syncCollector{FrameProcessor: processors.NewFrameProcessor("sync_collector")}
+}
+
+func (p *syncCollector) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	if img, ok := frame.(*frames.ImageRawFrame); ok && direction == processors.FrameDirectionDownstream {
+		p.mu.Lock()
+		p.images = append(p.images, img)
+		p.mu.Unlock()
+	}
+}
+
+func (p *syncCollector) imageFrames() []*frames.ImageRawFrame {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*frames.ImageRawFrame(nil), p.images...)
+}
+
+func rawFrame(id byte) *frames.ImageRawFrame {
+	return frames.NewImageRawFrame([]byte{id, id, id}, frames.ImageSize{Width: 1, Height: 1}, FormatRaw, ModeRGB)
+}
+
+func flatImage(v uint8) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 48))
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+	return img
+}
+
+func TestIntervalFrameSampler(t *testing.T) {
+	mockProc := NewMockProcessor()
+	sampler := NewIntervalFrameSampler(50 * time.Millisecond)
+	sampler.Link(mockProc)
+
+	for i := 0; i < 5; i++ {
+		sampler.ProcessFrame(rawFrame(byte(i)), processors.FrameDirectionDownstream)
+	}
+	time.Sleep(60 * time.Millisecond)
+	sampler.ProcessFrame(rawFrame(5), processors.FrameDirectionDownstream)
+	sampler.ProcessFrame(frames.NewTextFrame("pass"), processors.FrameDirectionDownstream)
+
+	out := mockProc.imageFrames()
+	assert.Equal(t, 2, len(out))
+	assert.Equal(t, byte(0), out[0].Image[0])
+	assert.Equal(t, byte(5), out[1].Image[0])
+	assert.Equal(t, uint64(2), sampler.Kept())
+	assert.Equal(t, uint64(4), sampler.Dropped())
+	assert.Equal(t, 3, len(mockProc.GetReceivedFrames(processors.FrameDirectionDownstream)))
+}
+
+func TestLatestFrameSampler(t *testing.T) {
+	collector := newSyncCollector()
+	notifier := notifiers.NewChannelNotifier()
+	sampler := NewLatestFrameSampler(notifier)
+	sampler.Link(collector)
+	defer sampler.Cleanup()
+
+	for i := 0; i < 3; i++ {
+		sampler.ProcessFrame(rawFrame(byte(i)), processors.FrameDirectionDownstream)
+	}
+	assert.Empty(t, collector.imageFrames())
+
+	notifier.Notify()
+	assert.Eventually(t, func() bool { return len(collector.imageFrames()) == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, byte(2), collector.imageFrames()[0].Image[0])
+
+	// Nothing held, nothing released.
+	notifier.Notify()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 1, len(collector.imageFrames()))
+	assert.Equal(t, uint64(1), sampler.Kept())
+	assert.Equal(t, uint64(2), sampler.Dropped())
+}
+
+func TestTickerFrameSampler_MaxAge(t *testing.T) {
+	collector := newSyncCollector()
+	sampler := NewTickerFrameSampler(20 * time.Millisecond)
+	sampler.Link(collector)
+
+	sampler.ProcessFrame(rawFrame(1), processors.FrameDirectionDownstream)
+	assert.Eventually(t, func() bool { return len(collector.imageFrames()) == 1 }, time.Second, 5*time.Millisecond)
+	sampler.ProcessFrame(frames.NewEndFrame(), processors.FrameDirectionDownstream)
+
+	// A stale held frame is dropped on release.
+	stale := NewLatestFrameSampler(notifiers.NewChannelNotifier()).WithMaxAge(time.Nanosecond)
+	stale.Link(collector)
+	stale.ProcessFrame(rawFrame(2), processors.FrameDirectionDownstream)
+	time.Sleep(time.Millisecond)
+	stale.release()
+	stale.Cleanup()
+	assert.Equal(t, 1, len(collector.imageFrames()))
+	assert.Equal(t, uint64(1), stale.Dropped())
+}
+
+func TestDiffFrameSampler(t *testing.T) {
+	mockProc := NewMockProcessor()
+	sampler := NewDiffFrameSampler(0.05)
+	sampler.Link(mockProc)
+
+	for _, v := range []uint8{100, 102, 101, 180, 181, 100} {
+		sampler.ProcessFrame(pngFrame(t, flatImage(v)), processors.FrameDirectionDownstream)
+	}
+
+	out := mockProc.imageFrames()
+	assert.Equal(t, 3, len(out))
+	assert.Equal(t, uint64(3), sampler.Kept())
+	assert.Equal(t, uint64(3), sampler.Dropped())
+
+	img, err := DecodeImage(out[1])
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(180), img.NRGBAAt(0, 0).R)
+}
+
+func TestLatestFrameSampler_Interruption(t *testing.T) {
+	collector := newSyncCollector()
+	notifier := notifiers.NewChannelNotifier()
+	sampler := NewLatestFrameSampler(notifier)
+	sampler.Link(collector)
+	defer sampler.Cleanup()
+
+	startFrame := frames.NewStartFrame()
+	startFrame.AllowInterruptions = true
+	sampler.ProcessFrame(startFrame, processors.FrameDirectionDownstream)
+	sampler.ProcessFrame(rawFrame(1), processors.FrameDirectionDownstream)
+	sampler.ProcessFrame(frames.NewStartInterruptionFrame(), processors.FrameDirectionDownstream)
+
+	// The pre-interruption frame is never released.
+	notifier.Notify()
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, collector.imageFrames())
+	assert.Equal(t, uint64(1), sampler.Dropped())
+}
+
+func TestLatestFrameSampler_EndAndCancel(t *testing.T) {
+	// The frame held at the end is released.
+	collector := newSyncCollector()
+	sampler := NewLatestFrameSampler(notifiers.NewChannelNotifier())
+	sampler.Link(collector)
+	sampler.ProcessFrame(rawFrame(1), processors.FrameDirectionDownstream)
+	sampler.ProcessFrame(rawFrame(2), processors.FrameDirectionDownstream)
+	sampler.ProcessFrame(frames.NewEndFrame(), processors.FrameDirectionDownstream)
+
+	if assert.Equal(t, 1, len(collector.imageFrames())) {
+		assert.Equal(t, byte(2), collector.imageFrames()[0].Image[0])
+	}
+	assert.Equal(t, uint64(1), sampler.Kept())
+	assert.Equal(t, uint64(1), sampler.Dropped())
+
+	// The frame held on a cancel is dropped, and counted.
+	collector = newSyncCollector()
+	sampler = NewLatestFrameSampler(notifiers.NewChannelNotifier())
+	sampler.Link(collector)
+	sampler.ProcessFrame(rawFrame(1), processors.FrameDirectionDownstream)
+	sampler.ProcessFrame(frames.NewCancelFrame(), processors.FrameDirectionDownstream)
+
+	assert.Empty(t, collector.imageFrames())
+	assert.Equal(t, uint64(0), sampler.Kept())
+	assert.Equal(t, uint64(1), sampler.Dropped())
+}
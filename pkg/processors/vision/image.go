@@ -0,0 +1,356 @@
+package vision
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"strings"
+
+	"github.com/weedge/pipeline-go/pkg/frames"
+)
+
+// ImageRawFrame.Format values. FormatRaw (or an empty format) means the frame
+// holds uncompressed pixels laid out according to its Mode.
+const (
+	FormatRaw  = "RAW"
+	FormatPNG  = "PNG"
+	FormatJPEG = "JPEG"
+)
+
+// ImageRawFrame.Mode values for raw pixel layouts.
+const (
+	// ModeRGB is 3 bytes per pixel.
+	ModeRGB = "RGB"
+	// ModeRGBA is 4 bytes per pixel, non-premultiplied alpha.
+	ModeRGBA = "RGBA"
+	// ModeL is 1 byte per pixel grayscale (luminance).
+	ModeL = "L"
+)
+
+// ResizeMode controls how the aspect ratio is handled when resizing.
+type ResizeMode int
+
+const (
+	// ResizeStretch scales to exactly the target size, ignoring the aspect ratio.
+	ResizeStretch ResizeMode = iota
+	// ResizeFit scales to fit inside the target size, keeping the aspect ratio.
+	// The result can be smaller than the target in one dimension.
+	ResizeFit
+	// ResizeFill scales to cover the target size, keeping the aspect ratio,
+	// and crops the overflow around the center.
+	ResizeFill
+	// ResizePad scales like ResizeFit and pads (letterboxes) to exactly the target size.
+	ResizePad
+)
+
+// String returns the string representation of ResizeMode
+func (m ResizeMode) String() string {
+	switch m {
+	case ResizeStretch:
+		return "Stretch"
+	case ResizeFit:
+		return "Fit"
+	case ResizeFill:
+		return "Fill"
+	case ResizePad:
+		return "Pad"
+	default:
+		return "Unknown"
+	}
+}
+
+// normalizeFormat upper-cases the format and maps aliases, "" means raw pixels.
+func normalizeFormat(format string) string {
+	switch f := strings.ToUpper(format); f {
+	case "", FormatRaw:
+		return FormatRaw
+	case "JPG":
+		return FormatJPEG
+	default:
+		return f
+	}
+}
+
+// IsEncoded reports whether the frame holds PNG/JPEG bytes rather than raw pixels.
+func IsEncoded(frame *frames.ImageRawFrame) bool {
+	return normalizeFormat(frame.Format) != FormatRaw
+}
+
+// bytesPerPixel returns the raw pixel size of mode, 0 if unsupported.
+func bytesPerPixel(mode string) int {
+	switch strings.ToUpper(mode) {
+	case ModeRGB:
+		return 3
+	case ModeRGBA:
+		return 4
+	case ModeL:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// DecodeImage decodes the frame's PNG/JPEG bytes or raw pixels into an NRGBA image.
+func DecodeImage(frame *frames.ImageRawFrame) (*image.NRGBA, error) {
+	switch format := normalizeFormat(frame.Format); format {
+	case FormatPNG, FormatJPEG:
+		img, _, err := image.Decode(bytes.NewReader(frame.Image))
+		if err != nil {
+			return nil, fmt.Errorf("decode %s image: %w", format, err)
+		}
+		return toNRGBA(img), nil
+	case FormatRaw:
+		return decodeRaw(frame.Image, frame.Size, frame.Mode)
+	default:
+		return nil, fmt.Errorf("unsupported image format: %s", frame.Format)
+	}
+}
+
+func decodeRaw(data []byte, size frames.ImageSize, mode string) (*image.NRGBA, error) {
+	bpp := bytesPerPixel(mode)
+	if bpp == 0 {
+		return nil, fmt.Errorf("unsupported image mode: %s", mode)
+	}
+	if size.Width <= 0 || size.Height <= 0 || len(data) != size.Width*size.Height*bpp {
+		return nil, fmt.Errorf("raw %s image of %d bytes does not match size %dx%d", mode, len(data), size.Width, size.Height)
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, size.Width, size.Height))
+	for i, j := 0, 0; i < len(data); i, j = i+bpp, j+4 {
+		switch bpp {
+		case 1:
+			img.Pix[j], img.Pix[j+1], img.Pix[j+2], img.Pix[j+3] = data[i], data[i], data[i], 0xff
+		case 3:
+			img.Pix[j], img.Pix[j+1], img.Pix[j+2], img.Pix[j+3] = data[i], data[i+1], data[i+2], 0xff
+		case 4:
+			copy(img.Pix[j:j+4], data[i:i+4])
+		}
+	}
+	return img, nil
+}
+
+func toNRGBA(img image.Image) *image.NRGBA {
+	if nrgba, ok := img.(*image.NRGBA); ok && nrgba.Rect.Min == (image.Point{}) {
+		return nrgba
+	}
+	b := img.Bounds()
+	out := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			out.Set(x, y, color.NRGBAModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)))
+		}
+	}
+	return out
+}
+
+// EncodeImage encodes img as raw pixels in mode, or as PNG/JPEG. quality (1-100)
+// applies to JPEG; PNG uses pngCompression.
+func EncodeImage(img *image.NRGBA, format, mode string, quality int, pngCompression png.CompressionLevel) ([]byte, error) {
+	switch f := normalizeFormat(format); f {
+	case FormatRaw:
+		return encodeRaw(img, mode)
+	case FormatPNG:
+		var buf bytes.Buffer
+		enc := &png.Encoder{CompressionLevel: pngCompression}
+		var src image.Image = img
+		switch strings.ToUpper(mode) {
+		case ModeL:
+			src = toGray(img)
+		case ModeRGB:
+			src = opaque(img)
+		}
+		if err := enc.Encode(&buf, src); err != nil {
+			return nil, fmt.Errorf("encode PNG image: %w", err)
+		}
+		return buf.Bytes(), nil
+	case FormatJPEG:
+		var buf bytes.Buffer
+		var src image.Image = opaque(img)
+		if strings.ToUpper(mode) == ModeL {
+			src = toGray(img)
+		}
+		if err := jpeg.Encode(&buf, src, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, fmt.Errorf("encode JPEG image: %w", err)
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unsupported image format: %s", format)
+	}
+}
+
+func encodeRaw(img *image.NRGBA, mode string) ([]byte, error) {
+	bpp := bytesPerPixel(mode)
+	if bpp == 0 {
+		return nil, fmt.Errorf("unsupported image mode: %s", mode)
+	}
+	if bpp == 4 {
+		return append([]byte(nil), img.Pix...), nil
+	}
+
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	data := make([]byte, 0, w*h*bpp)
+	for i := 0; i < len(img.Pix); i += 4 {
+		r, g, b := img.Pix[i], img.Pix[i+1], img.Pix[i+2]
+		if bpp == 1 {
+			data = append(data, luminance(r, g, b))
+		} else {
+			data = append(data, r, g, b)
+		}
+	}
+	return data, nil
+}
+
+// luminance converts RGB to gray using the ITU-R BT.601 weights.
+func luminance(r, g, b uint8) uint8 {
+	return uint8((19595*uint32(r) + 38470*uint32(g) + 7471*uint32(b) + 1<<15) >> 16)
+}
+
+func toGray(img *image.NRGBA) *image.Gray {
+	gray := image.NewGray(img.Rect)
+	for i, j := 0, 0; i < len(img.Pix); i, j = i+4, j+1 {
+		gray.Pix[j] = luminance(img.Pix[i], img.Pix[i+1], img.Pix[i+2])
+	}
+	return gray
+}
+
+// opaque returns a copy of img with the alpha channel dropped.
+func opaque(img *image.NRGBA) *image.NRGBA {
+	out := image.NewNRGBA(img.Rect)
+	copy(out.Pix, img.Pix)
+	for i := 3; i < len(out.Pix); i += 4 {
+		out.Pix[i] = 0xff
+	}
+	return out
+}
+
+// ConvertMode converts img to the given raw mode, returning an NRGBA image whose
+// pixels only carry the information mode can represent.
+func ConvertMode(img *image.NRGBA, mode string) (*image.NRGBA, error) {
+	data, err := encodeRaw(img, mode)
+	if err != nil {
+		return nil, err
+	}
+	return decodeRaw(data, frames.ImageSize{Width: img.Rect.Dx(), Height: img.Rect.Dy()}, mode)
+}
+
+// ResizeImage scales img to size according to mode. Downscaling averages the
+// covered source pixels (box filter), upscaling interpolates bilinearly.
+func ResizeImage(img *image.NRGBA, size frames.ImageSize, mode ResizeMode) *image.NRGBA {
+	srcW, srcH := img.Rect.Dx(), img.Rect.Dy()
+	if size.Width <= 0 || size.Height <= 0 || srcW == 0 || srcH == 0 {
+		return img
+	}
+
+	scaleX := float64(size.Width) / float64(srcW)
+	scaleY := float64(size.Height) / float64(srcH)
+	dstW, dstH := size.Width, size.Height
+	switch mode {
+	case ResizeFit, ResizePad:
+		scale := math.Min(scaleX, scaleY)
+		dstW = max(int(math.Round(float64(srcW)*scale)), 1)
+		dstH = max(int(math.Round(float64(srcH)*scale)), 1)
+	case ResizeFill:
+		scale := math.Max(scaleX, scaleY)
+		dstW = max(int(math.Round(float64(srcW)*scale)), 1)
+		dstH = max(int(math.Round(float64(srcH)*scale)), 1)
+	}
+
+	scaled := img
+	if dstW != srcW || dstH != srcH {
+		scaled = scale(img, dstW, dstH)
+	}
+
+	switch mode {
+	case ResizeFill:
+		x0, y0 := (dstW-size.Width)/2, (dstH-size.Height)/2
+		out := image.NewNRGBA(image.Rect(0, 0, size.Width, size.Height))
+		for y := 0; y < size.Height; y++ {
+			copy(out.Pix[y*out.Stride:y*out.Stride+size.Width*4], scaled.Pix[(y+y0)*scaled.Stride+x0*4:])
+		}
+		return out
+	case ResizePad:
+		x0, y0 := (size.Width-dstW)/2, (size.Height-dstH)/2
+		out := image.NewNRGBA(image.Rect(0, 0, size.Width, size.Height))
+		for i := 3; i < len(out.Pix); i += 4 {
+			out.Pix[i] = 0xff // opaque black bars
+		}
+		for y := 0; y < dstH; y++ {
+			copy(out.Pix[(y+y0)*out.Stride+x0*4:], scaled.Pix[y*scaled.Stride:y*scaled.Stride+dstW*4])
+		}
+		return out
+	default:
+		return scaled
+	}
+}
+
+// scale resamples img to w x h.
+func scale(img *image.NRGBA, w, h int) *image.NRGBA {
+	srcW, srcH := img.Rect.Dx(), img.Rect.Dy()
+	out := image.NewNRGBA(image.Rect(0, 0, w, h))
+	fx := float64(srcW) / float64(w)
+	fy := float64(srcH) / float64(h)
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var px [4]float64
+			if fx > 1 || fy > 1 {
+				px = boxSample(img, float64(x)*fx, float64(y)*fy, math.Max(fx, 1), math.Max(fy, 1))
+			} else {
+				px = bilinearSample(img, (float64(x)+0.5)*fx-0.5, (float64(y)+0.5)*fy-0.5)
+			}
+			o := y*out.Stride + x*4
+			for c := 0; c < 4; c++ {
+				out.Pix[o+c] = uint8(math.Min(math.Max(math.Round(px[c]), 0), 255))
+			}
+		}
+	}
+	return out
+}
+
+// boxSample averages the source area [x0, x0+w) x [y0, y0+h) weighted by pixel coverage.
+func boxSample(img *image.NRGBA, x0, y0, w, h float64) [4]float64 {
+	var sum [4]float64
+	var total float64
+	srcW, srcH := img.Rect.Dx(), img.Rect.Dy()
+	for sy := int(y0); sy < srcH && float64(sy) < y0+h; sy++ {
+		wy := math.Min(float64(sy+1), y0+h) - math.Max(float64(sy), y0)
+		for sx := int(x0); sx < srcW && float64(sx) < x0+w; sx++ {
+			wx := math.Min(float64(sx+1), x0+w) - math.Max(float64(sx), x0)
+			weight := wx * wy
+			o := sy*img.Stride + sx*4
+			for c := 0; c < 4; c++ {
+				sum[c] += float64(img.Pix[o+c]) * weight
+			}
+			total += weight
+		}
+	}
+	if total > 0 {
+		for c := range sum {
+			sum[c] /= total
+		}
+	}
+	return sum
+}
+
+func bilinearSample(img *image.NRGBA, x, y float64) [4]float64 {
+	srcW, srcH := img.Rect.Dx(), img.Rect.Dy()
+	x = math.Min(math.Max(x, 0), float64(srcW-1))
+	y = math.Min(math.Max(y, 0), float64(srcH-1))
+	x0, y0 := int(x), int(y)
+	x1, y1 := min(x0+1, srcW-1), min(y0+1, srcH-1)
+	dx, dy := x-float64(x0), y-float64(y0)
+
+	var px [4]float64
+	for c := 0; c < 4; c++ {
+		p00 := float64(img.Pix[y0*img.Stride+x0*4+c])
+		p10 := float64(img.Pix[y0*img.Stride+x1*4+c])
+		p01 := float64(img.Pix[y1*img.Stride+x0*4+c])
+		p11 := float64(img.Pix[y1*img.Stride+x1*4+c])
+		px[c] = (p00*(1-dx)+p10*dx)*(1-dy) + (p01*(1-dx)+p11*dx)*dy
+	}
+	return px
+}
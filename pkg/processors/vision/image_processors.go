@@ -0,0 +1,218 @@
+package vision
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"strings"
+
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+const defaultJPEGQuality = 90
+
+// processImage applies transform to downstream ImageRawFrames and passes everything else through.
+// Images that fail to transform are dropped and reported upstream as a non-fatal ErrorFrame.
+func processImage(
+	p *processors.FrameProcessor,
+	frame frames.Frame,
+	direction processors.FrameDirection,
+	transform func(*frames.ImageRawFrame) (*frames.ImageRawFrame, error),
+) {
+	p.ProcessFrame(frame, direction)
+
+	imageFrame, ok := frame.(*frames.ImageRawFrame)
+	if !ok || direction != processors.FrameDirectionDownstream {
+		p.PushFrame(frame, direction)
+		return
+	}
+
+	out, err := transform(imageFrame)
+	if err != nil {
+		p.PushError(frames.NewErrorFrame(fmt.Errorf("%s %s: %w", p.Name(), imageFrame, err), false))
+		return
+	}
+	p.PushFrame(out, direction)
+}
+
+// imageSize returns the dimensions of a decoded image.
+func imageSize(img *image.NRGBA) frames.ImageSize {
+	return frames.ImageSize{Width: img.Rect.Dx(), Height: img.Rect.Dy()}
+}
+
+// ImageDecoder decodes PNG/JPEG ImageRawFrames into raw pixels of the given mode.
+// Raw input frames are converted to the mode.
+type ImageDecoder struct {
+	*processors.FrameProcessor
+	mode string
+}
+
+// NewImageDecoder creates a new ImageDecoder, mode is ModeRGB, ModeRGBA or ModeL.
+func NewImageDecoder(mode string) *ImageDecoder {
+	return &ImageDecoder{
+		FrameProcessor: processors.NewFrameProcessor("ImageDecoder"),
+		mode:           mode,
+	}
+}
+
+func (p *ImageDecoder) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	processImage(p.FrameProcessor, frame, direction, func(f *frames.ImageRawFrame) (*frames.ImageRawFrame, error) {
+		img, err := DecodeImage(f)
+		if err != nil {
+			return nil, err
+		}
+		data, err := EncodeImage(img, FormatRaw, p.mode, 0, png.DefaultCompression)
+		if err != nil {
+			return nil, err
+		}
+		return frames.NewImageRawFrame(data, imageSize(img), FormatRaw, strings.ToUpper(p.mode)), nil
+	})
+}
+
+// ImageResizer scales ImageRawFrames to a target size, keeping the frame's
+// representation: raw frames stay raw in the same mode, PNG/JPEG frames are re-encoded.
+type ImageResizer struct {
+	*processors.FrameProcessor
+	size           frames.ImageSize
+	resizeMode     ResizeMode
+	quality        int
+	pngCompression png.CompressionLevel
+}
+
+// NewImageResizer creates a new ImageResizer.
+func NewImageResizer(size frames.ImageSize, resizeMode ResizeMode) *ImageResizer {
+	return &ImageResizer{
+		FrameProcessor: processors.NewFrameProcessor("ImageResizer"),
+		size:           size,
+		resizeMode:     resizeMode,
+		quality:        defaultJPEGQuality,
+		pngCompression: png.DefaultCompression,
+	}
+}
+
+// WithQuality sets the JPEG quality (1-100) used when re-encoding JPEG frames.
+func (p *ImageResizer) WithQuality(quality int) *ImageResizer {
+	p.quality = quality
+	return p
+}
+
+// WithPNGCompression sets the compression level used when re-encoding PNG frames.
+func (p *ImageResizer) WithPNGCompression(level png.CompressionLevel) *ImageResizer {
+	p.pngCompression = level
+	return p
+}
+
+func (p *ImageResizer) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	processImage(p.FrameProcessor, frame, direction, func(f *frames.ImageRawFrame) (*frames.ImageRawFrame, error) {
+		img, err := DecodeImage(f)
+		if err != nil {
+			return nil, err
+		}
+		img = ResizeImage(img, p.size, p.resizeMode)
+
+		mode := f.Mode
+		if mode == "" {
+			mode = ModeRGB
+		}
+		data, err := EncodeImage(img, f.Format, mode, p.quality, p.pngCompression)
+		if err != nil {
+			return nil, err
+		}
+		return frames.NewImageRawFrame(data, imageSize(img), f.Format, strings.ToUpper(mode)), nil
+	})
+}
+
+// ImageModeConverter converts the Mode of ImageRawFrames (e.g. RGBA to RGB, RGB to L),
+// keeping raw frames raw and re-encoding PNG/JPEG frames.
+type ImageModeConverter struct {
+	*processors.FrameProcessor
+	mode           string
+	quality        int
+	pngCompression png.CompressionLevel
+}
+
+// NewImageModeConverter creates a new ImageModeConverter.
+func NewImageModeConverter(mode string) *ImageModeConverter {
+	return &ImageModeConverter{
+		FrameProcessor: processors.NewFrameProcessor("ImageModeConverter"),
+		mode:           mode,
+		quality:        defaultJPEGQuality,
+		pngCompression: png.DefaultCompression,
+	}
+}
+
+// WithQuality sets the JPEG quality (1-100) used when re-encoding JPEG frames.
+func (p *ImageModeConverter) WithQuality(quality int) *ImageModeConverter {
+	p.quality = quality
+	return p
+}
+
+func (p *ImageModeConverter) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	processImage(p.FrameProcessor, frame, direction, func(f *frames.ImageRawFrame) (*frames.ImageRawFrame, error) {
+		if strings.EqualFold(f.Mode, p.mode) {
+			return f, nil
+		}
+		if bytesPerPixel(p.mode) == 0 {
+			return nil, fmt.Errorf("unsupported image mode: %s", p.mode)
+		}
+		img, err := DecodeImage(f)
+		if err != nil {
+			return nil, err
+		}
+		data, err := EncodeImage(img, f.Format, p.mode, p.quality, p.pngCompression)
+		if err != nil {
+			return nil, err
+		}
+		return frames.NewImageRawFrame(data, imageSize(img), f.Format, strings.ToUpper(p.mode)), nil
+	})
+}
+
+// ImageEncoder encodes ImageRawFrames as PNG or JPEG. Frames already in the
+// target format are re-encoded too, so the quality setting always applies.
+type ImageEncoder struct {
+	*processors.FrameProcessor
+	format         string
+	quality        int
+	pngCompression png.CompressionLevel
+}
+
+// NewImageEncoder creates a new ImageEncoder, format is FormatPNG or FormatJPEG.
+func NewImageEncoder(format string) *ImageEncoder {
+	return &ImageEncoder{
+		FrameProcessor: processors.NewFrameProcessor("ImageEncoder"),
+		format:         normalizeFormat(format),
+		quality:        defaultJPEGQuality,
+		pngCompression: png.DefaultCompression,
+	}
+}
+
+// WithQuality sets the JPEG quality (1-100).
+func (p *ImageEncoder) WithQuality(quality int) *ImageEncoder {
+	p.quality = quality
+	return p
+}
+
+// WithPNGCompression sets the PNG compression level.
+func (p *ImageEncoder) WithPNGCompression(level png.CompressionLevel) *ImageEncoder {
+	p.pngCompression = level
+	return p
+}
+
+func (p *ImageEncoder) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	processImage(p.FrameProcessor, frame, direction, func(f *frames.ImageRawFrame) (*frames.ImageRawFrame, error) {
+		img, err := DecodeImage(f)
+		if err != nil {
+			return nil, err
+		}
+		mode := strings.ToUpper(f.Mode)
+		if mode == "" || (p.format == FormatJPEG && mode == ModeRGBA) {
+			mode = ModeRGB // JPEG has no alpha channel
+		}
+		data, err := EncodeImage(img, p.format, mode, p.quality, p.pngCompression)
+		if err != nil {
+			return nil, err
+		}
+		return frames.NewImageRawFrame(data, imageSize(img), p.format, strings.ToUpper(mode)), nil
+	})
+}
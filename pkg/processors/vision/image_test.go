@@ -0,0 +1,248 @@
+package vision
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+// mockProcessor is a simple processor for testing.
+type mockProcessor struct {
+	*processors.FrameProcessor
+	receivedFrames map[processors.FrameDirection][]frames.Frame
+}
+
+func NewMockProcessor() *mockProcessor {
+	return &mockProcessor{
+		FrameProcessor: processors.NewFrameProcessor("mock_processor"),
+		receivedFrames: make(map[processors.FrameDirection][]frames.Frame),
+	}
+}
+
+func (p *mockProcessor) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	p.receivedFrames[direction] = append(p.receivedFrames[direction], frame)
+	p.FrameProcessor.PushFrame(frame, direction)
+}
+
+func (p *mockProcessor) GetReceivedFrames(direction processors.FrameDirection) []frames.Frame {
+	return p.receivedFrames[direction]
+}
+
+// imageFrames returns the ImageRawFrames received downstream.
+func (p *mockProcessor) imageFrames() []*frames.ImageRawFrame {
+	var out []*frames.ImageRawFrame
+	for _, f := range p.receivedFrames[processors.FrameDirectionDownstream] {
+		if img, ok := f.(*frames.ImageRawFrame); ok {
+			out = append(out, img)
+		}
+	}
+	return out
+}
+
+// gradient returns a w x h image with red growing along x and green along y.
+func gradient(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 255 / max(w-1, 1)), G: uint8(y * 255 / max(h-1, 1)), B: 64, A: 255})
+		}
+	}
+	return img
+}
+
+func pngFrame(t *testing.T, img image.Image) *frames.ImageRawFrame {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	b := img.Bounds()
+	return frames.NewImageRawFrame(buf.Bytes(), frames.ImageSize{Width: b.Dx(), Height: b.Dy()}, "PNG", "RGBA")
+}
+
+func jpegFrame(t *testing.T, img image.Image) *frames.ImageRawFrame {
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}))
+	b := img.Bounds()
+	return frames.NewImageRawFrame(buf.Bytes(), frames.ImageSize{Width: b.Dx(), Height: b.Dy()}, "JPEG", "RGB")
+}
+
+func TestImageDecoder(t *testing.T) {
+	src := gradient(32, 16)
+
+	for _, tt := range []struct {
+		mode string
+		bpp  int
+	}{{ModeRGB, 3}, {ModeRGBA, 4}, {ModeL, 1}} {
+		mockProc := NewMockProcessor()
+		decoder := NewImageDecoder(tt.mode)
+		decoder.Link(mockProc)
+
+		decoder.ProcessFrame(pngFrame(t, src), processors.FrameDirectionDownstream)
+
+		out := mockProc.imageFrames()
+		assert.Equal(t, 1, len(out))
+		assert.Equal(t, FormatRaw, out[0].Format)
+		assert.Equal(t, tt.mode, out[0].Mode)
+		assert.Equal(t, frames.ImageSize{Width: 32, Height: 16}, out[0].Size)
+		assert.Equal(t, 32*16*tt.bpp, len(out[0].Image))
+	}
+
+	// Raw RGB pixels match the source exactly.
+	mockProc := NewMockProcessor()
+	decoder := NewImageDecoder(ModeRGB)
+	decoder.Link(mockProc)
+	decoder.ProcessFrame(pngFrame(t, src), processors.FrameDirectionDownstream)
+	raw := mockProc.imageFrames()[0].Image
+	c := src.NRGBAAt(31, 15)
+	assert.Equal(t, []byte{c.R, c.G, c.B}, raw[len(raw)-3:])
+}
+
+func TestImageDecoder_JPEG(t *testing.T) {
+	mockProc := NewMockProcessor()
+	decoder := NewImageDecoder(ModeRGB)
+	decoder.Link(mockProc)
+
+	decoder.ProcessFrame(jpegFrame(t, gradient(64, 48)), processors.FrameDirectionDownstream)
+
+	out := mockProc.imageFrames()
+	assert.Equal(t, 1, len(out))
+	assert.Equal(t, frames.ImageSize{Width: 64, Height: 48}, out[0].Size)
+	// Lossy, but close: pixel (32, 24) has R~130, G~130, B=64.
+	px := out[0].Image[(24*64+32)*3:]
+	assert.InDelta(t, 130, int(px[0]), 8)
+	assert.InDelta(t, 130, int(px[1]), 8)
+	assert.InDelta(t, 64, int(px[2]), 8)
+}
+
+func TestImageResizer_AspectModes(t *testing.T) {
+	target := frames.ImageSize{Width: 100, Height: 100}
+	tests := []struct {
+		mode     ResizeMode
+		expected frames.ImageSize
+	}{
+		{ResizeStretch, frames.ImageSize{Width: 100, Height: 100}},
+		{ResizeFit, frames.ImageSize{Width: 100, Height: 50}},
+		{ResizeFill, frames.ImageSize{Width: 100, Height: 100}},
+		{ResizePad, frames.ImageSize{Width: 100, Height: 100}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			mockProc := NewMockProcessor()
+			resizer := NewImageResizer(target, tt.mode)
+			resizer.Link(mockProc)
+
+			resizer.ProcessFrame(pngFrame(t, gradient(200, 100)), processors.FrameDirectionDownstream)
+
+			out := mockProc.imageFrames()
+			assert.Equal(t, 1, len(out))
+			assert.Equal(t, tt.expected, out[0].Size)
+			assert.Equal(t, "PNG", out[0].Format)
+
+			img, err := DecodeImage(out[0])
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected.Width, img.Rect.Dx())
+			assert.Equal(t, tt.expected.Height, img.Rect.Dy())
+		})
+	}
+}
+
+func TestResizeImage_Content(t *testing.T) {
+	src := gradient(200, 100)
+
+	// Padding adds opaque black bars above and below the 100x50 content.
+	padded := ResizeImage(src, frames.ImageSize{Width: 100, Height: 100}, ResizePad)
+	assert.Equal(t, color.NRGBA{A: 255}, padded.NRGBAAt(50, 10))
+	assert.Equal(t, uint8(64), padded.NRGBAAt(50, 50).B)
+
+	// Filling crops the horizontal overflow around the center.
+	filled := ResizeImage(src, frames.ImageSize{Width: 100, Height: 100}, ResizeFill)
+	assert.InDelta(t, 64, int(filled.NRGBAAt(0, 50).R), 4)
+	assert.InDelta(t, 191, int(filled.NRGBAAt(99, 50).R), 4)
+
+	// Down and up scaling preserve a flat color.
+	flat := image.NewNRGBA(image.Rect(0, 0, 7, 5))
+	for i := range flat.Pix {
+		flat.Pix[i] = 200
+	}
+	for _, size := range []frames.ImageSize{{Width: 3, Height: 2}, {Width: 15, Height: 11}} {
+		out := ResizeImage(flat, size, ResizeStretch)
+		for _, v := range out.Pix {
+			assert.Equal(t, uint8(200), v)
+		}
+	}
+}
+
+func TestImageResizer_Raw(t *testing.T) {
+	mockProc := NewMockProcessor()
+	resizer := NewImageResizer(frames.ImageSize{Width: 8, Height: 4}, ResizeStretch)
+	resizer.Link(mockProc)
+
+	raw := make([]byte, 16*8*3)
+	resizer.ProcessFrame(frames.NewImageRawFrame(raw, frames.ImageSize{Width: 16, Height: 8}, "", ModeRGB), processors.FrameDirectionDownstream)
+
+	out := mockProc.imageFrames()
+	assert.Equal(t, 1, len(out))
+	assert.Equal(t, ModeRGB, out[0].Mode)
+	assert.Equal(t, 8*4*3, len(out[0].Image))
+}
+
+func TestImageModeConverter(t *testing.T) {
+	mockProc := NewMockProcessor()
+	converter := NewImageModeConverter(ModeL)
+	converter.Link(mockProc)
+
+	rgba := []byte{255, 0, 0, 128, 0, 255, 0, 255}
+	converter.ProcessFrame(frames.NewImageRawFrame(rgba, frames.ImageSize{Width: 2, Height: 1}, FormatRaw, ModeRGBA), processors.FrameDirectionDownstream)
+
+	out := mockProc.imageFrames()
+	assert.Equal(t, 1, len(out))
+	assert.Equal(t, ModeL, out[0].Mode)
+	assert.Equal(t, []byte{76, 150}, out[0].Image)
+}
+
+func TestImageEncoder_Quality(t *testing.T) {
+	src := gradient(128, 128)
+	sizes := map[int]int{}
+	for _, quality := range []int{20, 95} {
+		mockProc := NewMockProcessor()
+		encoder := NewImageEncoder("jpeg").WithQuality(quality)
+		encoder.Link(mockProc)
+
+		encoder.ProcessFrame(pngFrame(t, src), processors.FrameDirectionDownstream)
+
+		out := mockProc.imageFrames()
+		assert.Equal(t, 1, len(out))
+		assert.Equal(t, FormatJPEG, out[0].Format)
+		assert.Equal(t, ModeRGB, out[0].Mode)
+		_, err := jpeg.Decode(bytes.NewReader(out[0].Image))
+		assert.NoError(t, err)
+		sizes[quality] = len(out[0].Image)
+	}
+	assert.Less(t, sizes[20], sizes[95])
+}
+
+func TestImageProcessor_InvalidImage(t *testing.T) {
+	upMock := NewMockProcessor()
+	mockProc := NewMockProcessor()
+	decoder := NewImageDecoder(ModeRGB)
+	decoder.SetPrev(upMock)
+	decoder.Link(mockProc)
+
+	decoder.ProcessFrame(frames.NewImageRawFrame([]byte{1, 2, 3}, frames.ImageSize{}, "PNG", "RGB"), processors.FrameDirectionDownstream)
+	decoder.ProcessFrame(frames.NewTextFrame("pass"), processors.FrameDirectionDownstream)
+
+	assert.Empty(t, mockProc.imageFrames())
+	assert.Equal(t, 1, len(mockProc.GetReceivedFrames(processors.FrameDirectionDownstream)))
+
+	errors := upMock.GetReceivedFrames(processors.FrameDirectionUpstream)
+	assert.Equal(t, 1, len(errors))
+	errFrame, ok := errors[0].(*frames.ErrorFrame)
+	assert.True(t, ok)
+	assert.False(t, errFrame.Fatal)
+}
@@ -0,0 +1,223 @@
+package processors
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/weedge/pipeline-go/pkg/clock"
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/logger"
+)
+
+// WatchdogEscalation is what a WatchdogProcessor does when a ProcessFrame call
+// is still stuck after the escalation timeout.
+type WatchdogEscalation int
+
+const (
+	// WatchdogEscalateNone only reports the stuck call with a warning ErrorFrame.
+	WatchdogEscalateNone WatchdogEscalation = iota
+	// WatchdogEscalateFatal pushes a fatal ErrorFrame upstream, which cancels the pipeline task.
+	WatchdogEscalateFatal
+	// WatchdogEscalateCancel aborts the stuck call of a wrapped WatchdogCanceler,
+	// or else pushes a fatal ErrorFrame upstream like WatchdogEscalateFatal.
+	WatchdogEscalateCancel
+)
+
+// WatchdogCanceler is a processor whose stuck ProcessFrame call a
+// WatchdogProcessor can abort with WatchdogEscalateCancel.
+type WatchdogCanceler interface {
+	// CancelStuck aborts the ProcessFrame call processing frame, so that it
+	// returns as soon as it can. It is called from another goroutine while the
+	// call is running, and must not push frames.
+	CancelStuck(frame frames.Frame, direction FrameDirection)
+}
+
+// String returns the string representation of WatchdogEscalation
+func (e WatchdogEscalation) String() string {
+	switch e {
+	case WatchdogEscalateNone:
+		return "None"
+	case WatchdogEscalateFatal:
+		return "Fatal"
+	case WatchdogEscalateCancel:
+		return "Cancel"
+	default:
+		return "Unknown"
+	}
+}
+
+// StuckProcessorError reports a ProcessFrame call that exceeded the watchdog timeout.
+type StuckProcessorError struct {
+	Processor string
+	Frame     frames.Frame
+	Direction FrameDirection
+	Elapsed   time.Duration
+	// Stack is the stack of the goroutine running the stuck ProcessFrame call.
+	Stack string
+}
+
+func (e *StuckProcessorError) Error() string {
+	return fmt.Sprintf("processor %s stuck for %s processing %s %s", e.Processor, e.Elapsed, e.Frame, e.Direction)
+}
+
+// WatchdogProcessor wraps a IFrameProcessor and measures each ProcessFrame call.
+// A call running longer than the timeout is reported upstream with a non-fatal
+// ErrorFrame wrapping a *StuckProcessorError; if the call is still running after
+// the escalation timeout the configured escalation is applied.
+//
+// The wrapped processor is linked in place of the watchdog, so the frames it
+// pushes go directly to its neighbours.
+type WatchdogProcessor struct {
+	*FrameProcessor
+	wrappedProcessor IFrameProcessor
+	timeout          time.Duration
+	escalation       WatchdogEscalation
+	escalateAfter    time.Duration
+	stuckCount       atomic.Uint64
+	calls            atomic.Uint64
+}
+
+// watchdogCallLabel is the pprof label of the goroutine running a watched
+// ProcessFrame call, to find its stack once stuck.
+const watchdogCallLabel = "pipeline_watchdog_call"
+
+// NewWatchdogProcessor creates a new WatchdogProcessor around processor.
+func NewWatchdogProcessor(processor IFrameProcessor, timeout time.Duration) *WatchdogProcessor {
+	return &WatchdogProcessor{
+		FrameProcessor:   NewFrameProcessor("Watchdog(" + processor.Name() + ")"),
+		wrappedProcessor: processor,
+		timeout:          timeout,
+		escalateAfter:    timeout,
+	}
+}
+
+// WithEscalation sets the escalation applied when a call is still stuck
+// escalateAfter past the timeout (default: the timeout again).
+func (p *WatchdogProcessor) WithEscalation(escalation WatchdogEscalation, escalateAfter time.Duration) *WatchdogProcessor {
+	p.escalation = escalation
+	if escalateAfter > 0 {
+		p.escalateAfter = escalateAfter
+	}
+	return p
+}
+
+// Wrapped returns the watched processor.
+func (p *WatchdogProcessor) Wrapped() IFrameProcessor {
+	return p.wrappedProcessor
+}
+
+// StuckCount returns how many ProcessFrame calls exceeded the timeout.
+func (p *WatchdogProcessor) StuckCount() uint64 {
+	return p.stuckCount.Load()
+}
+
+// Link links the wrapped processor to next.
+func (p *WatchdogProcessor) Link(next IFrameProcessor) {
+	p.FrameProcessor.Link(next)
+	p.wrappedProcessor.Link(next)
+}
+
+// SetPrev sets the previous processor of the wrapped processor, the watchdog reports to it too.
+func (p *WatchdogProcessor) SetPrev(prev IFrameProcessor) {
+	p.FrameProcessor.SetPrev(prev)
+	p.wrappedProcessor.SetPrev(prev)
+}
+
+func (p *WatchdogProcessor) SetVerbose(verbose bool) {
+	p.FrameProcessor.SetVerbose(verbose)
+	p.wrappedProcessor.SetVerbose(verbose)
+}
+
+// Cleanup cleans up the wrapped processor.
+func (p *WatchdogProcessor) Cleanup() {
+	p.wrappedProcessor.Cleanup()
+}
+
+// ProcessFrame calls the wrapped processor's ProcessFrame under the watchdog timers.
+func (p *WatchdogProcessor) ProcessFrame(frame frames.Frame, direction FrameDirection) {
+	p.FrameProcessor.ProcessFrame(frame, direction)
+	if p.timeout <= 0 {
+		p.wrappedProcessor.ProcessFrame(frame, direction)
+		return
+	}
+
+	call := strconv.FormatInt(p.ID(), 10) + "." + strconv.FormatUint(p.calls.Add(1), 10)
+	clk := p.Clock()
+	start := clk.Now()
+	var stuck atomic.Bool
+	warnTimer := clk.AfterFunc(p.timeout, func() {
+		stuck.Store(true)
+		p.stuckCount.Add(1)
+		err := p.stuckError(frame, direction, clk.Since(start), call)
+		logger.Warnf("%s\n%s", err, err.Stack)
+		p.PushError(frames.NewErrorFrame(err, false))
+	})
+	var escalateTimer clock.Timer
+	if p.escalation != WatchdogEscalateNone {
+		escalateTimer = clk.AfterFunc(p.timeout+p.escalateAfter, func() {
+			p.escalate(frame, direction, clk.Since(start), call)
+		})
+	}
+
+	defer func() {
+		warnTimer.Stop()
+		if escalateTimer != nil {
+			escalateTimer.Stop()
+		}
+		if stuck.Load() {
+			logger.Warnf("%s recovered after %s processing %s", p.wrappedProcessor.Name(), clk.Since(start), frame)
+		}
+	}()
+	pprof.Do(context.Background(), pprof.Labels(watchdogCallLabel, call), func(context.Context) {
+		p.wrappedProcessor.ProcessFrame(frame, direction)
+	})
+}
+
+// escalate applies the configured escalation to a call still stuck.
+func (p *WatchdogProcessor) escalate(frame frames.Frame, direction FrameDirection, elapsed time.Duration, call string) {
+	err := p.stuckError(frame, direction, elapsed, call)
+	logger.Errorf("%s, escalation: %s", err, p.escalation)
+	switch p.escalation {
+	case WatchdogEscalateFatal:
+		p.PushError(frames.NewErrorFrame(err, true))
+	case WatchdogEscalateCancel:
+		if canceler, ok := p.wrappedProcessor.(WatchdogCanceler); ok {
+			canceler.CancelStuck(frame, direction)
+			return
+		}
+		p.PushError(frames.NewErrorFrame(err, true))
+	}
+}
+
+func (p *WatchdogProcessor) stuckError(frame frames.Frame, direction FrameDirection, elapsed time.Duration, call string) *StuckProcessorError {
+	return &StuckProcessorError{
+		Processor: p.wrappedProcessor.Name(),
+		Frame:     frame,
+		Direction: direction,
+		Elapsed:   elapsed,
+		Stack:     callStack(call),
+	}
+}
+
+// callStack returns the stack of the goroutine running the watched call, from
+// the goroutine profile, empty if it's gone.
+func callStack(call string) string {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+		return ""
+	}
+	// The goroutines started by the call inherit its label.
+	label := strconv.Quote(watchdogCallLabel) + ":" + strconv.Quote(call)
+	for _, stack := range strings.Split(buf.String(), "\n\n") {
+		if strings.Contains(stack, label) && strings.Contains(stack, "(*WatchdogProcessor).ProcessFrame") {
+			return stack
+		}
+	}
+	return ""
+}
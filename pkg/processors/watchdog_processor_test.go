@@ -0,0 +1,160 @@
+package processors
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weedge/pipeline-go/pkg/frames"
+)
+
+// blockingProcessor blocks on TextFrames until released.
+type blockingProcessor struct {
+	*FrameProcessor
+	release chan struct{}
+}
+
+func newBlockingProcessor() *blockingProcessor {
+	return &blockingProcessor{
+		FrameProcessor: NewFrameProcessor("blocking_processor"),
+		release:        make(chan struct{}),
+	}
+}
+
+func (p *blockingProcessor) ProcessFrame(frame frames.Frame, direction FrameDirection) {
+	if _, ok := frame.(*frames.TextFrame); ok {
+		<-p.release
+	}
+	p.PushFrame(frame, direction)
+}
+
+// cancelableProcessor is a blockingProcessor released by the watchdog.
+type cancelableProcessor struct {
+	*blockingProcessor
+	canceled []frames.Frame
+}
+
+func (p *cancelableProcessor) CancelStuck(frame frames.Frame, direction FrameDirection) {
+	p.canceled = append(p.canceled, frame)
+	close(p.release)
+}
+
+// stuckErrors waits for n ErrorFrames pushed upstream to collector.
+func stuckErrors(t *testing.T, collector *slowCollector, n int) []*frames.ErrorFrame {
+	assert.Eventually(t, func() bool { return len(collector.received()) >= n }, 2*time.Second, 5*time.Millisecond)
+	var errs []*frames.ErrorFrame
+	for _, f := range collector.received() {
+		if errFrame, ok := f.(*frames.ErrorFrame); ok {
+			errs = append(errs, errFrame)
+		}
+	}
+	return errs
+}
+
+func TestWatchdogProcessor_Warning(t *testing.T) {
+	up := &slowCollector{FrameProcessor: NewFrameProcessor("up")}
+	down := NewMockProcessor()
+	blocking := newBlockingProcessor()
+	watchdog := NewWatchdogProcessor(blocking, 20*time.Millisecond)
+	watchdog.SetPrev(up)
+	watchdog.Link(down)
+
+	done := make(chan struct{})
+	go func() {
+		watchdog.ProcessFrame(frames.NewTextFrame("slow"), FrameDirectionDownstream)
+		close(done)
+	}()
+
+	errs := stuckErrors(t, up, 1)
+	assert.Equal(t, 1, len(errs))
+	assert.False(t, errs[0].Fatal)
+	var stuckErr *StuckProcessorError
+	assert.True(t, errors.As(errs[0].Error, &stuckErr))
+	assert.Equal(t, "blocking_processor", stuckErr.Processor)
+	assert.IsType(t, &frames.TextFrame{}, stuckErr.Frame)
+	assert.GreaterOrEqual(t, stuckErr.Elapsed, 20*time.Millisecond)
+	// The stack is the one of the goroutine blocked in ProcessFrame.
+	assert.True(t, strings.Contains(stuckErr.Stack, "blockingProcessor).ProcessFrame"), stuckErr.Stack)
+	assert.Equal(t, uint64(1), watchdog.StuckCount())
+
+	close(blocking.release)
+	<-done
+	// The wrapped processor pushes to the watchdog's neighbours.
+	assert.Equal(t, 1, len(down.GetReceivedDirectionDownstreamFrames()))
+}
+
+func TestWatchdogProcessor_FastCalls(t *testing.T) {
+	up := &slowCollector{FrameProcessor: NewFrameProcessor("up")}
+	down := NewMockProcessor()
+	watchdog := NewWatchdogProcessor(NewMockProcessorWithName("fast"), 20*time.Millisecond)
+	watchdog.SetPrev(up)
+	watchdog.Link(down)
+
+	for i := 0; i < 10; i++ {
+		watchdog.ProcessFrame(frames.NewTextFrame("fast"), FrameDirectionDownstream)
+	}
+	time.Sleep(40 * time.Millisecond)
+
+	assert.Empty(t, up.received())
+	assert.Equal(t, uint64(0), watchdog.StuckCount())
+	assert.Equal(t, 10, len(down.GetReceivedDirectionDownstreamFrames()))
+}
+
+func TestWatchdogProcessor_EscalateFatal(t *testing.T) {
+	up := &slowCollector{FrameProcessor: NewFrameProcessor("up")}
+	blocking := newBlockingProcessor()
+	watchdog := NewWatchdogProcessor(blocking, 10*time.Millisecond).WithEscalation(WatchdogEscalateFatal, 10*time.Millisecond)
+	watchdog.SetPrev(up)
+	defer close(blocking.release)
+
+	go watchdog.ProcessFrame(frames.NewTextFrame("stuck"), FrameDirectionDownstream)
+
+	errs := stuckErrors(t, up, 2)
+	assert.Equal(t, 2, len(errs))
+	assert.False(t, errs[0].Fatal)
+	assert.True(t, errs[1].Fatal)
+}
+
+func TestWatchdogProcessor_EscalateCancel(t *testing.T) {
+	up := &slowCollector{FrameProcessor: NewFrameProcessor("up")}
+	down := NewMockProcessor()
+	cancelable := &cancelableProcessor{blockingProcessor: newBlockingProcessor()}
+	watchdog := NewWatchdogProcessor(cancelable, 10*time.Millisecond).WithEscalation(WatchdogEscalateCancel, 10*time.Millisecond)
+	watchdog.SetPrev(up)
+	watchdog.Link(down)
+
+	stuck := frames.NewTextFrame("stuck")
+	done := make(chan struct{})
+	go func() {
+		watchdog.ProcessFrame(stuck, FrameDirectionDownstream)
+		close(done)
+	}()
+
+	// The stuck call is aborted, no frame is sent to the wrapped processor.
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("wrapped processor not cancelled")
+	}
+	assert.Equal(t, []frames.Frame{stuck}, cancelable.canceled)
+	assert.Equal(t, []frames.Frame{stuck}, down.GetReceivedDirectionDownstreamFrames())
+	assert.Equal(t, 1, len(stuckErrors(t, up, 1)))
+}
+
+func TestWatchdogProcessor_EscalateCancelFatal(t *testing.T) {
+	up := &slowCollector{FrameProcessor: NewFrameProcessor("up")}
+	blocking := newBlockingProcessor()
+	watchdog := NewWatchdogProcessor(blocking, 10*time.Millisecond).WithEscalation(WatchdogEscalateCancel, 10*time.Millisecond)
+	watchdog.SetPrev(up)
+	defer close(blocking.release)
+
+	go watchdog.ProcessFrame(frames.NewTextFrame("stuck"), FrameDirectionDownstream)
+
+	// Without a WatchdogCanceler, the task is cancelled instead.
+	errs := stuckErrors(t, up, 2)
+	assert.Equal(t, 2, len(errs))
+	assert.False(t, errs[0].Fatal)
+	assert.True(t, errs[1].Fatal)
+}
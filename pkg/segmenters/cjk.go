@@ -0,0 +1,28 @@
+package segmenters
+
+const (
+	cjkTerminators = "。！？!?…"
+	cjkClosers     = `"'”’」』）》〉】〕)]`
+)
+
+// CJKSegmenter is a SentenceSegmenter for Chinese and Japanese text, written
+// without spaces between sentences. Korean, written with ASCII periods and
+// spaces between sentences, is split by an EnglishSegmenter.
+//
+// A sentence ends with a run of '。', '！', '？' (or their ASCII forms) or of
+// ellipsis characters ("……"), and the closing quotes and brackets after it.
+// An ellipsis at the end of the text doesn't end a sentence yet, it may go on.
+// ASCII periods, e.g. in "3.14", don't end a sentence.
+type CJKSegmenter struct{}
+
+// NewCJKSegmenter creates a new CJKSegmenter.
+func NewCJKSegmenter() *CJKSegmenter {
+	return &CJKSegmenter{}
+}
+
+// Split implements SentenceSegmenter.
+func (s *CJKSegmenter) Split(text string) ([]string, string) {
+	return split(text, cjkTerminators, cjkClosers, func(sentence, run, after string) bool {
+		return after != "" || !isEllipsis(run)
+	})
+}
@@ -0,0 +1,83 @@
+package segmenters
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	englishTerminators = ".!?…"
+	englishClosers     = `"')]}’”`
+	englishOpeners     = `"'([{‘“`
+)
+
+// EnglishAbbreviations are the abbreviations a period doesn't end a sentence after,
+// by default, compared case insensitively.
+var EnglishAbbreviations = []string{
+	"Mr.", "Mrs.", "Ms.", "Dr.", "Prof.", "Sr.", "Jr.", "St.", "Mt.", "Gen.", "Col.", "Capt.", "Lt.", "Sgt.", "Rev.", "Hon.",
+	"vs.", "etc.", "e.g.", "i.e.", "cf.", "approx.", "no.", "vol.", "fig.", "ed.",
+	"Inc.", "Ltd.", "Co.", "Corp.", "Dept.", "Univ.", "Ave.", "Blvd.", "Rd.",
+	"Jan.", "Feb.", "Mar.", "Apr.", "Jun.", "Jul.", "Aug.", "Sep.", "Sept.", "Oct.", "Nov.", "Dec.",
+	"a.m.", "p.m.",
+}
+
+var (
+	// initialsPattern matches initials like "J." or "U.S.A.".
+	initialsPattern = regexp.MustCompile(`^(\p{L}\.)+$`)
+	// listNumberPattern matches a numbered list item like "1.".
+	listNumberPattern = regexp.MustCompile(`^\d+\.$`)
+)
+
+// EnglishSegmenter is a rule-based SentenceSegmenter for English, and other
+// languages written with spaces between sentences given their abbreviations,
+// e.g. Korean.
+//
+// A sentence ends with a run of '.', '!', '?' or '…' followed by whitespace or
+// the end of the text, and the closing quotes and brackets after it. A single
+// period doesn't end a sentence after an abbreviation, initials ("U.S.") or a
+// list number ("1."). An ellipsis at the end of the text doesn't end a sentence
+// yet, it may go on. Commas and colons don't end a sentence.
+type EnglishSegmenter struct {
+	abbreviations map[string]bool
+}
+
+// NewEnglishSegmenter creates a new EnglishSegmenter with the EnglishAbbreviations.
+func NewEnglishSegmenter() *EnglishSegmenter {
+	return (&EnglishSegmenter{}).WithAbbreviations(EnglishAbbreviations...)
+}
+
+// WithAbbreviations replaces the abbreviations of the segmenter, e.g. with the
+// abbreviations of another language.
+func (s *EnglishSegmenter) WithAbbreviations(abbreviations ...string) *EnglishSegmenter {
+	s.abbreviations = make(map[string]bool, len(abbreviations))
+	for _, abbreviation := range abbreviations {
+		s.abbreviations[strings.ToLower(abbreviation)] = true
+	}
+	return s
+}
+
+// Split implements SentenceSegmenter.
+func (s *EnglishSegmenter) Split(text string) ([]string, string) {
+	return split(text, englishTerminators, englishClosers, s.isEnd)
+}
+
+func (s *EnglishSegmenter) isEnd(sentence, run, after string) bool {
+	if after != "" {
+		if r, _ := utf8.DecodeRuneInString(after); !unicode.IsSpace(r) {
+			return false
+		}
+	} else if isEllipsis(run) {
+		return false
+	}
+	if run != "." {
+		return true
+	}
+
+	fields := strings.Fields(sentence)
+	word := strings.TrimLeft(strings.TrimRight(fields[len(fields)-1], englishClosers), englishOpeners)
+	return !s.abbreviations[strings.ToLower(word)] &&
+		!initialsPattern.MatchString(word) &&
+		!listNumberPattern.MatchString(word)
+}
@@ -0,0 +1,55 @@
+// Package segmenters splits text into sentences, e.g. to aggregate the LLM text
+// tokens into sentences for a TTS service.
+package segmenters
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// SentenceSegmenter splits text into sentences.
+type SentenceSegmenter interface {
+	// Split returns the complete sentences text starts with, and the rest of
+	// text: a sentence not ended yet. The sentences and the rest concatenated
+	// are text, whitespace included.
+	Split(text string) (sentences []string, rest string)
+}
+
+// split splits text after the runs of terminators (and the closing quotes and
+// brackets following them) isEnd reports as the end of a sentence.
+func split(text, terminators, closers string, isEnd func(sentence, run, after string) bool) ([]string, string) {
+	var sentences []string
+	start := 0
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if !strings.ContainsRune(terminators, r) {
+			i += size
+			continue
+		}
+		runEnd := skipRunes(text, i, terminators)
+		end := skipRunes(text, runEnd, closers)
+		if isEnd(text[start:end], text[i:runEnd], text[end:]) {
+			sentences = append(sentences, text[start:end])
+			start = end
+		}
+		i = end
+	}
+	return sentences, text[start:]
+}
+
+// skipRunes returns the index in text after the runes in chars from i.
+func skipRunes(text string, i int, chars string) int {
+	for i < len(text) {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if !strings.ContainsRune(chars, r) {
+			break
+		}
+		i += size
+	}
+	return i
+}
+
+// isEllipsis returns whether run is an ellipsis: several dots or ellipsis characters.
+func isEllipsis(run string) bool {
+	return strings.Trim(run, ".…") == "" && (strings.ContainsRune(run, '…') || len(run) > 1)
+}
@@ -0,0 +1,118 @@
+package segmenters
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnglishSegmenter(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		sentences []string
+		rest      string
+	}{
+		{"several sentences", "Hello world. How are you? Fine!", []string{"Hello world.", " How are you?", " Fine!"}, ""},
+		{"rest", "Hello world. How are", []string{"Hello world."}, " How are"},
+		{"no terminator", "Hello world", nil, "Hello world"},
+		{"comma and colon", "Note: first, second", nil, "Note: first, second"},
+		{"honorific", "I saw Mr. Smith yesterday. He was nice.", []string{"I saw Mr. Smith yesterday.", " He was nice."}, ""},
+		{"honorific at the end", "I saw Dr.", nil, "I saw Dr."},
+		{"latin abbreviation", "Fruits, e.g. apples, are good.", []string{"Fruits, e.g. apples, are good."}, ""},
+		{"time", "The meeting is at 3 p.m. See you.", []string{"The meeting is at 3 p.m. See you."}, ""},
+		{"initials", "The U.S.A. is big.", []string{"The U.S.A. is big."}, ""},
+		{"list number", "Steps: 1. Open it.", []string{"Steps: 1. Open it."}, ""},
+		{"decimal", "Pi is 3.14 or so.", []string{"Pi is 3.14 or so."}, ""},
+		{"terminator run", "Really?! Yes.", []string{"Really?!", " Yes."}, ""},
+		{"closing quote", `He said "stop." Then left.`, []string{`He said "stop."`, " Then left."}, ""},
+		{"ellipsis going on", "Well...", nil, "Well..."},
+		{"ellipsis", "Well... I think so.", []string{"Well...", " I think so."}, ""},
+	}
+	segmenter := NewEnglishSegmenter()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sentences, rest := segmenter.Split(tt.text)
+			assert.Equal(t, tt.sentences, sentences)
+			assert.Equal(t, tt.rest, rest)
+		})
+	}
+}
+
+func TestEnglishSegmenter_WithAbbreviations(t *testing.T) {
+	segmenter := NewEnglishSegmenter().WithAbbreviations("z.B.", "Nr.")
+	sentences, rest := segmenter.Split("Das ist z.B. Nr. 5 hier. Gut.")
+	assert.Equal(t, []string{"Das ist z.B. Nr. 5 hier.", " Gut."}, sentences)
+	assert.Equal(t, "", rest)
+
+	// The default abbreviations are replaced.
+	sentences, _ = segmenter.Split("I saw Mr. Smith.")
+	assert.Equal(t, []string{"I saw Mr.", " Smith."}, sentences)
+}
+
+func TestEnglishSegmenter_Korean(t *testing.T) {
+	segmenter := NewEnglishSegmenter().WithAbbreviations()
+	sentences, rest := segmenter.Split("안녕하세요. 반갑습니다! 어디 가세요? 저는")
+	assert.Equal(t, []string{"안녕하세요.", " 반갑습니다!", " 어디 가세요?"}, sentences)
+	assert.Equal(t, " 저는", rest)
+
+	// The CJKSegmenter doesn't split on ASCII periods.
+	sentences, _ = NewCJKSegmenter().Split("안녕하세요. 반갑습니다.")
+	assert.Empty(t, sentences)
+}
+
+func TestCJKSegmenter(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		sentences []string
+		rest      string
+	}{
+		{"several sentences", "你好。今天天气很好！你呢？", []string{"你好。", "今天天气很好！", "你呢？"}, ""},
+		{"rest", "你好。今天", []string{"你好。"}, "今天"},
+		{"ascii terminators", "真的吗?是的!", []string{"真的吗?", "是的!"}, ""},
+		{"comma", "你好，世界", nil, "你好，世界"},
+		{"decimal", "圆周率是3.14。", []string{"圆周率是3.14。"}, ""},
+		{"closing quote", "他说：“走吧。”然后走了。", []string{"他说：“走吧。”", "然后走了。"}, ""},
+		{"ellipsis going on", "我想……", nil, "我想……"},
+		{"ellipsis", "我想……也许吧。", []string{"我想……", "也许吧。"}, ""},
+		{"ellipsis then terminator", "我想……。好的", []string{"我想……。"}, "好的"},
+		{"japanese", "こんにちは。元気ですか？", []string{"こんにちは。", "元気ですか？"}, ""},
+	}
+	segmenter := NewCJKSegmenter()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sentences, rest := segmenter.Split(tt.text)
+			assert.Equal(t, tt.sentences, sentences)
+			assert.Equal(t, tt.rest, rest)
+		})
+	}
+}
+
+// FuzzSegmenters checks the sentences and the rest concatenated are the text,
+// and splitting the rest again finds no more sentences.
+func FuzzSegmenters(f *testing.F) {
+	for _, text := range []string{
+		"Hello world. How are you?", "I saw Mr. Smith. The U.S.A. is big...", "你好。我想……也许吧！", `"Stop!" he said.`, "",
+	} {
+		f.Add(text)
+	}
+	segmenters := map[string]SentenceSegmenter{"english": NewEnglishSegmenter(), "cjk": NewCJKSegmenter()}
+	f.Fuzz(func(t *testing.T, text string) {
+		for name, segmenter := range segmenters {
+			sentences, rest := segmenter.Split(text)
+			if got := strings.Join(sentences, "") + rest; got != text {
+				t.Errorf("%s: sentences %q and rest %q concatenated = %q, want %q", name, sentences, rest, got, text)
+			}
+			for i, sentence := range sentences {
+				if sentence == "" {
+					t.Errorf("%s: sentence %d of %q is empty", name, i, sentences)
+				}
+			}
+			if again, _ := segmenter.Split(rest); len(again) > 0 {
+				t.Errorf("%s: rest %q splits into %q", name, rest, again)
+			}
+		}
+	})
+}
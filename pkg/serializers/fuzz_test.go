@@ -0,0 +1,144 @@
+package serializers
+
+import (
+	"testing"
+	"testing/quick"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/idl"
+)
+
+func seedFrames() []frames.Frame {
+	return []frames.Frame{
+		frames.NewTextFrame("hello world"),
+		frames.NewTextFrame(""),
+		frames.NewAudioRawFrame([]byte{0, 1, 2, 3}, 16000, 1, 2),
+		frames.NewImageRawFrame([]byte{10, 20, 30}, frames.ImageSize{Width: 1920, Height: 1080}, "jpeg", "RGB"),
+	}
+}
+
+func marshalImageSize(t testing.TB, size string) []byte {
+	data, err := proto.Marshal(&idl.Frame{Frame: &idl.Frame_Image{Image: &idl.ImageRawFrame{Size: size}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// checkDeserialize deserializes data and checks a frame is usable and serializes back to the same frame.
+func checkDeserialize(t *testing.T, serializer Serializer, data []byte) {
+	frame, err := serializer.Deserialize(data)
+	if err != nil {
+		if frame != nil {
+			t.Errorf("Deserialize() returned a frame with error %v", err)
+		}
+		return
+	}
+	_ = frame.Name()
+	_ = frame.String()
+	again, err := serializer.Serialize(frame)
+	if err != nil {
+		t.Fatalf("Serialize() of a deserialized %s error = %v", frame, err)
+	}
+	roundTrip, err := serializer.Deserialize(again)
+	if err != nil {
+		t.Fatalf("Deserialize() of a serialized %s error = %v", frame, err)
+	}
+	if !areFramesEqual(frame, roundTrip) {
+		t.Errorf("frames are not equal after serialization/deserialization cycle: %#v, %#v", frame, roundTrip)
+	}
+}
+
+func FuzzProtobufDeserialize(f *testing.F) {
+	serializer := NewProtobufSerializer()
+	for _, frame := range seedFrames() {
+		data, err := serializer.Serialize(frame)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	for _, size := range []string{"1920", "", "x", "1920x", "x1080", "axb", "1x2x3"} {
+		f.Add(marshalImageSize(f, size))
+	}
+	f.Add([]byte{0xff, 0xff})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkDeserialize(t, serializer, data)
+	})
+}
+
+func FuzzJsonDeserialize(f *testing.F) {
+	serializer := NewJsonSerializer()
+	for _, frame := range seedFrames() {
+		data, err := serializer.Serialize(frame)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Add([]byte(`{"type":"text","data":null}`))
+	f.Add([]byte(`{"type":"audio","data":{"Audio":"not base64"}}`))
+	f.Add([]byte(`{"type":"image","data":{"Size":"1920"}}`))
+	f.Add([]byte(`{"type":"video","data":{}}`))
+	f.Add([]byte(`[]`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkDeserialize(t, serializer, data)
+	})
+}
+
+func TestProtobufSerializer_InvalidImageSize(t *testing.T) {
+	serializer := NewProtobufSerializer()
+	for _, size := range []string{"1920", "", "1920x", "x1080", "axb", "1x2x3"} {
+		frame, err := serializer.Deserialize(marshalImageSize(t, size))
+		if err == nil {
+			t.Errorf("Deserialize() of image size %q = %s, want error", size, frame)
+		}
+	}
+}
+
+// TestSerializers_RoundTripProperty checks random frames of all the supported
+// types come out of a serialization/deserialization cycle unchanged.
+func TestSerializers_RoundTripProperty(t *testing.T) {
+	serializers := map[string]Serializer{
+		"Protobuf": NewProtobufSerializer(),
+		"JSON":     NewJsonSerializer(),
+	}
+	roundTrip := func(serializer Serializer, frame frames.Frame) bool {
+		data, err := serializer.Serialize(frame)
+		if err != nil {
+			t.Logf("Serialize(%s) error = %v", frame, err)
+			return false
+		}
+		got, err := serializer.Deserialize(data)
+		if err != nil {
+			t.Logf("Deserialize() of %s error = %v", frame, err)
+			return false
+		}
+		return areFramesEqual(frame, got)
+	}
+
+	for name, serializer := range serializers {
+		t.Run(name, func(t *testing.T) {
+			text := func(text string) bool {
+				return roundTrip(serializer, frames.NewTextFrame(text))
+			}
+			// The protobuf fields of the audio parameters are unsigned 32 bits.
+			audio := func(audio []byte, sampleRate, numChannels, sampleWidth uint16) bool {
+				return roundTrip(serializer, frames.NewAudioRawFrame(audio, int(sampleRate), int(numChannels), int(sampleWidth)))
+			}
+			image := func(image []byte, width, height int32, format, mode string) bool {
+				size := frames.ImageSize{Width: int(width), Height: int(height)}
+				return roundTrip(serializer, frames.NewImageRawFrame(image, size, format, mode))
+			}
+			for _, property := range []any{text, audio, image} {
+				if err := quick.Check(property, nil); err != nil {
+					t.Error(err)
+				}
+			}
+		})
+	}
+}
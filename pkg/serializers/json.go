@@ -60,25 +60,26 @@ func (s *JsonSerializer) Deserialize(data []byte) (frames.Frame, error) {
 		return nil, fmt.Errorf("error unmarshalling frame wrapper: %w", err)
 	}
 
+	// The frames are created by their constructors, for a base frame with an ID and a name.
 	switch wrapper.Type {
 	case frameTypeText:
-		var frame frames.TextFrame
-		if err := json.Unmarshal(wrapper.Data, &frame); err != nil {
+		frame := frames.NewTextFrame("")
+		if err := json.Unmarshal(wrapper.Data, frame); err != nil {
 			return nil, fmt.Errorf("error unmarshalling text frame: %w", err)
 		}
-		return &frame, nil
+		return frame, nil
 	case frameTypeAudio:
-		var frame frames.AudioRawFrame
-		if err := json.Unmarshal(wrapper.Data, &frame); err != nil {
+		frame := frames.NewAudioRawFrame(nil, 0, 0, 0)
+		if err := json.Unmarshal(wrapper.Data, frame); err != nil {
 			return nil, fmt.Errorf("error unmarshalling audio frame: %w", err)
 		}
-		return &frame, nil
+		return frame, nil
 	case frameTypeImage:
-		var frame frames.ImageRawFrame
-		if err := json.Unmarshal(wrapper.Data, &frame); err != nil {
+		frame := frames.NewImageRawFrame(nil, frames.ImageSize{}, "", "")
+		if err := json.Unmarshal(wrapper.Data, frame); err != nil {
 			return nil, fmt.Errorf("error unmarshalling image frame: %w", err)
 		}
-		return &frame, nil
+		return frame, nil
 	default:
 		return nil, fmt.Errorf("unknown frame type in json: %s", wrapper.Type)
 	}
@@ -81,10 +81,10 @@ func (s *ProtobufSerializer) Deserialize(data []byte) (frames.Frame, error) {
 		), nil
 	case *idl.Frame_Image:
 		imageFrame := f.Image
-		sizeParts := strings.Split(imageFrame.Size, "x")
-		width, _ := strconv.Atoi(sizeParts[0])
-		height, _ := strconv.Atoi(sizeParts[1])
-		size := frames.ImageSize{Width: width, Height: height}
+		size, err := parseImageSize(imageFrame.Size)
+		if err != nil {
+			return nil, err
+		}
 		return frames.NewImageRawFrame(
 			imageFrame.Image,
 			size,
@@ -95,3 +95,20 @@ func (s *ProtobufSerializer) Deserialize(data []byte) (frames.Frame, error) {
 		return nil, fmt.Errorf("unknown frame type in protobuf")
 	}
 }
+
+// parseImageSize parses a "<width>x<height>" image size.
+func parseImageSize(s string) (frames.ImageSize, error) {
+	w, h, ok := strings.Cut(s, "x")
+	if !ok {
+		return frames.ImageSize{}, fmt.Errorf("invalid image size in protobuf: %q", s)
+	}
+	width, err := strconv.Atoi(w)
+	if err != nil {
+		return frames.ImageSize{}, fmt.Errorf("invalid image width in protobuf: %q", s)
+	}
+	height, err := strconv.Atoi(h)
+	if err != nil {
+		return frames.ImageSize{}, fmt.Errorf("invalid image height in protobuf: %q", s)
+	}
+	return frames.ImageSize{Width: width, Height: height}, nil
+}
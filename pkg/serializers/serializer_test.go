@@ -1,6 +1,7 @@
 package serializers
 
 import (
+	"bytes"
 	"reflect"
 	"testing"
 
@@ -62,6 +63,7 @@ func TestSerializers(t *testing.T) {
 }
 
 // areFramesEqual compares the data fields of two frames, ignoring the base fields.
+// Nil and empty bytes are equal, protobuf doesn't tell them apart.
 func areFramesEqual(a, b frames.Frame) bool {
 	if reflect.TypeOf(a) != reflect.TypeOf(b) {
 		return false
@@ -73,13 +75,13 @@ func areFramesEqual(a, b frames.Frame) bool {
 		return fa.Text == fb.Text
 	case *frames.AudioRawFrame:
 		fb := b.(*frames.AudioRawFrame)
-		return reflect.DeepEqual(fa.Audio, fb.Audio) &&
+		return bytes.Equal(fa.Audio, fb.Audio) &&
 			fa.SampleRate == fb.SampleRate &&
 			fa.NumChannels == fb.NumChannels &&
 			fa.SampleWidth == fb.SampleWidth
 	case *frames.ImageRawFrame:
 		fb := b.(*frames.ImageRawFrame)
-		return reflect.DeepEqual(fa.Image, fb.Image) &&
+		return bytes.Equal(fa.Image, fb.Image) &&
 			fa.Size == fb.Size &&
 			fa.Format == fb.Format &&
 			fa.Mode == fb.Mode
@@ -5,8 +5,19 @@ import (
 	"strings"
 )
 
+// 句子结尾检测的正则表达式，只编译一次
+var (
+	endPattern          = regexp.MustCompile(`[\,，\.。\?？\!！:：]$`)
+	timePattern1        = regexp.MustCompile(`\d+:\d{2}\s*[ap]\.?m\.?\.?$`)
+	timePattern2        = regexp.MustCompile(`\d+\s*[ap]\.?m\.?\.?$`)
+	upperAbbrPattern    = regexp.MustCompile(`^[A-Z]\.([A-Z]\.)*$`)
+	numberedListPattern = regexp.MustCompile(`^\d+\.$`)
+	upperLetterPattern  = regexp.MustCompile(`^[A-Z]\.$`)
+)
+
 // MatchEndOfSentence 检查文本是否以句子结尾
 // 实现了Python版本的句子结尾检测逻辑，但适配了Go的RE2语法限制
+// 只检查文本的结尾，逗号和冒号也算句子结尾；切分多个句子见 segmenters.SentenceSegmenter
 func MatchEndOfSentence(text string) bool {
 	trimmed := strings.TrimSpace(text)
 	if trimmed == "" {
@@ -14,7 +25,6 @@ func MatchEndOfSentence(text string) bool {
 	}
 
 	// 检查是否以句子结束标点符号结尾
-	endPattern := regexp.MustCompile(`[\,，\.。\?？\!！:：]$`)
 	if !endPattern.MatchString(trimmed) {
 		return false
 	}
@@ -28,8 +38,6 @@ func MatchEndOfSentence(text string) bool {
 	lastWord := words[len(words)-1]
 
 	// 检查是否是时间格式（数字后跟冒号和空格以及am/pm）
-	timePattern1 := regexp.MustCompile(`\d+:\d{2}\s*[ap]\.?m\.?\.?$`)
-	timePattern2 := regexp.MustCompile(`\d+\s*[ap]\.?m\.?\.?$`)
 	if timePattern1.MatchString(trimmed) || timePattern1.MatchString(lastWord) ||
 		timePattern2.MatchString(trimmed) || timePattern2.MatchString(lastWord) {
 		return false
@@ -44,19 +52,16 @@ func MatchEndOfSentence(text string) bool {
 	}
 
 	// 检查全大写字母缩写（如U.S.A.）
-	upperAbbrPattern := regexp.MustCompile(`^[A-Z]\.([A-Z]\.)*$`)
 	if upperAbbrPattern.MatchString(lastWord) {
 		return false
 	}
 
 	// 检查数字后跟点（列表项）
-	numberedListPattern := regexp.MustCompile(`^\d+\.$`)
 	if numberedListPattern.MatchString(lastWord) {
 		return false
 	}
 
 	// 检查是否是大写字母后跟句号（可能是缩写的一部分）
-	upperLetterPattern := regexp.MustCompile(`^[A-Z]\.$`)
 	if upperLetterPattern.MatchString(lastWord) && len(words) > 1 {
 		// 检查前一个词是否也是类似格式
 		prevWord := words[len(words)-2]
@@ -1,7 +1,9 @@
 package pkg
 
 import (
+	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 func TestMatchEndOfSentence(t *testing.T) {
@@ -126,3 +128,28 @@ func TestMatchEndOfSentence(t *testing.T) {
 		})
 	}
 }
+
+// FuzzMatchEndOfSentence checks MatchEndOfSentence ignores surrounding
+// whitespace and only matches text ending with a sentence punctuation mark.
+func FuzzMatchEndOfSentence(f *testing.F) {
+	for _, text := range []string{
+		"This is a test.", "Wow!", "这是一个测试。", "I saw Mr. Smith yesterday.",
+		"The meeting is at 3:00 p.m.", "1. First item", "I visited the U.S.A.. It was fun.",
+		"Meet me at 3 p.m.", "A. B.", "", "   ",
+	} {
+		f.Add(text)
+	}
+	f.Fuzz(func(t *testing.T, text string) {
+		match := MatchEndOfSentence(text)
+		if padded := " \t" + text + " \n"; MatchEndOfSentence(padded) != match {
+			t.Errorf("MatchEndOfSentence(%q) = %v, but %v for %q", text, match, !match, padded)
+		}
+		if !match {
+			return
+		}
+		last, _ := utf8.DecodeLastRuneInString(strings.TrimSpace(text))
+		if !strings.ContainsRune(",，.。?？!！:：", last) {
+			t.Errorf("MatchEndOfSentence(%q) = true, ending with %q", text, last)
+		}
+	})
+}
@@ -0,0 +1,172 @@
+// Package tracing traces the frames going through pipeline tasks with OpenTelemetry.
+package tracing
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/pipeline"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+const (
+	instrumentationName = "github.com/weedge/pipeline-go/pkg/tracing"
+
+	// MetadataKey is the frame metadata key of the tracing context of a frame.
+	MetadataKey = "otel.context"
+
+	// SessionSpanName is the name of the span of a task session, from its StartFrame to its EndFrame or CancelFrame.
+	SessionSpanName = "pipeline.session"
+	// QueueWaitSpanName is the name of the spans of the time frames waited in a processor queue.
+	QueueWaitSpanName = "queue.wait"
+)
+
+// Tracer opens a span per frame per processor processing it, from the push of
+// the frame to the return of the processor's ProcessFrame. The span of a frame
+// in a processor is the parent of its spans in the processors it pushes the frame
+// to; the tracing context goes along with the frame in its metadata (MetadataKey),
+// so it is kept across queues. The time a frame waited in a processor queue,
+// e.g. of an AsyncFrameProcessor, is recorded as its own span.
+//
+// The StartFrame of an observed task opens the session span, which is the root
+// of the spans of the frames of the task. A Tracer observes one task at a time.
+type Tracer struct {
+	tracer trace.Tracer
+	parent context.Context
+
+	mu      sync.Mutex
+	roots   map[*processors.FrameProcessor]bool
+	session context.Context
+	spans   map[spanKey]trace.Span
+}
+
+// spanKey identifies the span of a frame in a processor.
+type spanKey struct {
+	frame     *frames.BaseFrame
+	processor *processors.FrameProcessor
+}
+
+// NewTracer creates a new Tracer using provider, the global TracerProvider if nil.
+func NewTracer(provider trace.TracerProvider) *Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return &Tracer{
+		tracer: provider.Tracer(instrumentationName),
+		parent: context.Background(),
+		roots:  make(map[*processors.FrameProcessor]bool),
+		spans:  make(map[spanKey]trace.Span),
+	}
+}
+
+// WithParentContext sets the parent context of the session spans, e.g. the context of a request.
+func (t *Tracer) WithParentContext(ctx context.Context) *Tracer {
+	t.parent = ctx
+	return t
+}
+
+// Observe traces the frames of task, it must be called before the task runs.
+func (t *Tracer) Observe(task *pipeline.PipelineTask) {
+	procs := task.Processors()
+	if len(procs) > 0 {
+		// The task source pushes the frames queued to the task.
+		t.mu.Lock()
+		t.roots[processors.BaseProcessor(procs[0])] = true
+		t.mu.Unlock()
+	}
+	task.AddObserver(t)
+}
+
+// ContextFromFrame returns the tracing context carried by frame, e.g. to trace
+// the service calls made to process it; context.Background() if it has none.
+func ContextFromFrame(frame frames.Frame) context.Context {
+	if ctx, ok := frames.Base(frame).Metadata(MetadataKey); ok {
+		return ctx.(context.Context)
+	}
+	return context.Background()
+}
+
+// OnPushFrame implements processors.FrameObserver.
+func (t *Tracer) OnPushFrame(event processors.FramePushEvent) {
+	base := frames.Base(event.Frame)
+	if base == nil {
+		return
+	}
+	source := processors.BaseProcessor(event.Source)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := event.Frame.(*frames.StartFrame); ok && t.roots[source] {
+		ctx, _ := t.tracer.Start(t.parent, SessionSpanName, trace.WithTimestamp(event.Timestamp))
+		t.session = ctx
+		base.SetMetadata(MetadataKey, ctx)
+	}
+
+	// The parent is the span of the frame in the pushing processor, or the one
+	// the frame carries if it was pushed by a processor not traced, or the session.
+	parent := t.parent
+	if span, ok := t.spans[spanKey{base, source}]; ok {
+		parent = trace.ContextWithSpan(t.parent, span)
+	} else if ctx, ok := base.Metadata(MetadataKey); ok {
+		parent = ctx.(context.Context)
+	} else if t.session != nil {
+		parent = t.session
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("frame.type", frames.TypeName(event.Frame)),
+		attribute.String("frame.name", base.Name()),
+		attribute.String("frame.direction", event.Direction.Label()),
+	}
+	if !event.QueuedAt.IsZero() {
+		_, queueSpan := t.tracer.Start(parent, QueueWaitSpanName, trace.WithTimestamp(event.QueuedAt),
+			trace.WithAttributes(append(attrs, attribute.String("processor", processors.ProcessorName(event.Source)))...))
+		queueSpan.End(trace.WithTimestamp(event.Timestamp))
+	}
+
+	ctx, span := t.tracer.Start(parent, processors.ProcessorName(event.Destination), trace.WithTimestamp(event.Timestamp),
+		trace.WithAttributes(attrs...))
+	t.spans[spanKey{base, processors.BaseProcessor(event.Destination)}] = span
+	base.SetMetadata(MetadataKey, ctx)
+}
+
+// OnFrameProcessed implements processors.FrameProcessedObserver.
+func (t *Tracer) OnFrameProcessed(event processors.FramePushEvent) {
+	base := frames.Base(event.Frame)
+	if base == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := spanKey{base, processors.BaseProcessor(event.Destination)}
+	if span, ok := t.spans[key]; ok {
+		span.End()
+		delete(t.spans, key)
+	}
+
+	switch event.Frame.(type) {
+	case *frames.EndFrame, *frames.CancelFrame:
+		if t.session != nil && t.roots[processors.BaseProcessor(event.Source)] {
+			trace.SpanFromContext(t.session).End()
+			t.session = nil
+		}
+	}
+}
+
+// OnDropFrame implements processors.FrameObserver.
+func (t *Tracer) OnDropFrame(event processors.FrameDropEvent) {
+	if ctx, ok := frames.Base(event.Frame).Metadata(MetadataKey); ok {
+		trace.SpanFromContext(ctx.(context.Context)).AddEvent("frame dropped", trace.WithAttributes(
+			attribute.String("processor", processors.ProcessorName(event.Processor)),
+			attribute.String("reason", event.Reason),
+		))
+	}
+}
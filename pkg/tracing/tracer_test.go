@@ -0,0 +1,110 @@
+package tracing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/weedge/pipeline-go/pkg/frames"
+	"github.com/weedge/pipeline-go/pkg/pipeline"
+	"github.com/weedge/pipeline-go/pkg/processors"
+)
+
+// passProcessor pushes the frames it gets, signaling the TextFrames.
+type passProcessor struct {
+	*processors.FrameProcessor
+	texts chan *frames.TextFrame
+}
+
+func newPassProcessor(name string) *passProcessor {
+	return &passProcessor{FrameProcessor: processors.NewFrameProcessor(name), texts: make(chan *frames.TextFrame, 1)}
+}
+
+func (p *passProcessor) ProcessFrame(frame frames.Frame, direction processors.FrameDirection) {
+	p.FrameProcessor.ProcessFrame(frame, direction)
+	p.PushFrame(frame, direction)
+	if text, ok := frame.(*frames.TextFrame); ok {
+		p.texts <- text
+	}
+}
+
+// frameSpans returns the ended spans of the frame by name.
+func frameSpans(exporter *tracetest.InMemoryExporter, frameName string) map[string]tracetest.SpanStub {
+	spans := make(map[string]tracetest.SpanStub)
+	for _, span := range exporter.GetSpans() {
+		for _, attr := range span.Attributes {
+			if attr.Key == "frame.name" && attr.Value.AsString() == frameName {
+				spans[span.Name] = span
+			}
+		}
+	}
+	return spans
+}
+
+func TestTracer(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	first := newPassProcessor("first")
+	async := processors.NewAsyncFrameProcessor("async").WithPorcessFrameAllowPush(true)
+	last := newPassProcessor("last")
+	pl := pipeline.NewPipeline([]processors.IFrameProcessor{first, async, last}, nil, nil)
+	task := pipeline.NewPipelineTask(pl, pipeline.PipelineParams{})
+	NewTracer(provider).Observe(task)
+
+	done := make(chan struct{})
+	go func() {
+		task.Run()
+		close(done)
+	}()
+	text := frames.NewTextFrame("hello")
+	task.QueueFrame(text)
+	<-first.texts
+	<-last.texts
+	task.StopWhenDone()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("task not finished")
+	}
+
+	var spans map[string]tracetest.SpanStub
+	assert.Eventually(t, func() bool {
+		spans = frameSpans(exporter, text.Name())
+		_, ok := spans["last"]
+		return ok
+	}, 2*time.Second, time.Millisecond)
+
+	var session tracetest.SpanStub
+	for _, span := range exporter.GetSpans() {
+		if span.Name == SessionSpanName {
+			session = span
+		}
+	}
+	assert.True(t, session.SpanContext.IsValid())
+	assert.False(t, session.Parent.IsValid())
+
+	// The TextFrame spans are chained along the pipeline, under the session span.
+	// The unnamed pipeline span is named after its type.
+	root := spans["*pipeline.Pipeline"]
+	assert.Equal(t, session.SpanContext.SpanID(), root.Parent.SpanID())
+	assert.Equal(t, root.SpanContext.SpanID(), spans["first"].Parent.SpanID())
+	assert.Equal(t, spans["first"].SpanContext.SpanID(), spans["async"].Parent.SpanID())
+	assert.Equal(t, spans["async"].SpanContext.SpanID(), spans["last"].Parent.SpanID())
+	for name, span := range spans {
+		assert.Equal(t, session.SpanContext.TraceID(), span.SpanContext.TraceID(), name)
+	}
+
+	// The wait in the async queue is recorded apart from the processing in last.
+	queueWait, ok := spans[QueueWaitSpanName]
+	assert.True(t, ok)
+	assert.Equal(t, spans["async"].SpanContext.SpanID(), queueWait.Parent.SpanID())
+	assert.False(t, queueWait.EndTime.After(spans["last"].StartTime))
+
+	// The frame carries the context of its trace.
+	assert.Equal(t, session.SpanContext.TraceID(), trace.SpanContextFromContext(ContextFromFrame(text)).TraceID())
+}